@@ -7,94 +7,139 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 
 	workflows "github.com/example/pflow/backend/deploy/workflows"
+	"github.com/example/pflow/backend/internal/app"
 	"github.com/example/pflow/backend/internal/config"
-	"github.com/example/pflow/backend/internal/db"
 	httpserver "github.com/example/pflow/backend/internal/http"
-	"github.com/example/pflow/backend/internal/models"
-	"github.com/example/pflow/backend/internal/mq"
-	"github.com/example/pflow/backend/internal/repository"
+	"github.com/example/pflow/backend/internal/lifecycle"
+	"github.com/example/pflow/backend/internal/metrics"
 	"github.com/example/pflow/backend/internal/service"
 	"github.com/example/pflow/backend/internal/worker"
 	"github.com/example/pflow/backend/internal/workflow"
 )
 
 func main() {
-	cfg := config.Load()
+	cfg := app.LoadConfig("api")
 
-	database, err := db.New(cfg.DatabaseURL)
+	a, err := app.New(cfg)
 	if err != nil {
-		log.Fatalf("connect database: %v", err)
+		log.Fatal(err)
 	}
-	autoMigrate(database)
-
-	var publisher mq.Publisher
-	publisher, err = mq.NewRabbitPublisher(cfg.MQURL, cfg.MQTicketExchange)
-	if err != nil {
-		log.Printf("warning: rabbitmq unavailable (%v), continuing without events", err)
+	if err := a.Migrate(); err != nil {
+		log.Fatalf("auto migrate: %v", err)
 	}
-	camundaClient := workflow.NewCamundaClient(cfg.CamundaURL)
-
-	if len(workflows.TicketProcess) > 0 {
-		if err := camundaClient.DeployProcess(context.Background(), "ticket-process", workflows.TicketProcess); err != nil {
-			log.Printf("deploy workflow failed: %v", err)
-		} else {
-			log.Println("workflow deployed to camunda")
+	deployWorkflows(a.Definitions, cfg)
+
+	apiServer := httpserver.NewServer(httpserver.Services{
+		Tickets:     a.Tickets,
+		Workflow:    a.Workflow,
+		Definitions: a.Definitions,
+		Migrations:  a.Migrations,
+		SLA:         a.SLA,
+		Types:       a.Types,
+		Comments:    a.Comments,
+		Attachments: a.Attachments,
+		Routing:     a.Routing,
+		Assignments: a.Assignments,
+		Inbox:       a.Inbox,
+		Bulk:        a.Bulk,
+		Idempotency: a.Idempotency,
+		Incidents:   a.Incidents,
+		StatusSync:  a.StatusSync,
+	}, httpserver.TenantOptions{
+		Claim:   cfg.Tenancy.Claim,
+		Default: cfg.Tenancy.Default,
+		Allowed: cfg.Tenancy.Tenants,
+	})
+
+	lc := lifecycle.New()
+	for _, c := range a.Components() {
+		lc.Add(c)
+	}
+	if cfg.Worker.Embedded {
+		// The embedded worker stands in for cmd/worker, so it also owns the periodic jobs.
+		if cfg.Worker.Schedulers {
+			for _, c := range a.Schedulers(cfg) {
+				lc.Add(c)
+			}
 		}
+		registry := metrics.NewRegistry()
+		workerMetrics := worker.NewMetrics(registry)
+		apiServer.Engine.GET("/metrics", gin.WrapH(registry))
+		lc.Add(newExternalWorker(a, cfg, workerMetrics).Component(cfg.ShutdownTimeout))
+		commands := lifecycle.Loop("command subscriber", func(ctx context.Context) {
+			app.RunCommandSubscriber(ctx, a.Workflow, cfg, workerMetrics)
+		})
+		commands.StopTimeout = cfg.ShutdownTimeout
+		lc.Add(commands)
+	} else {
+		log.Println("embedded worker disabled, external tasks, commands and periodic jobs are left to cmd/worker")
 	}
-
-	ticketRepo := repository.NewTicketRepository(database)
-	workflowService := service.NewWorkflowService(database, ticketRepo, camundaClient, publisher, cfg.CamundaProcessKey)
-	apiServer := httpserver.NewServer(ticketRepo, workflowService)
+	lc.Add(lifecycle.HTTPServer("http server", &http.Server{
+		Addr:    cfg.HTTP.Port,
+		Handler: apiServer.Engine,
+	}))
 
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
-
-	go runWorker(ctx, workflowService, camundaClient, cfg)
-
-	srv := &http.Server{
-		Addr:    cfg.HTTPPort,
-		Handler: apiServer.Engine,
+	if err := lc.Start(ctx); err != nil {
+		log.Printf("startup failed: %v", err)
+		os.Exit(1)
 	}
 
-	go func() {
-		log.Printf("HTTP server listening on %s", cfg.HTTPPort)
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("server error: %v", err)
-		}
-	}()
-
 	<-ctx.Done()
+	// A second signal now terminates the process right away.
+	cancel()
 	log.Println("shutdown initiated")
-
-	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancelShutdown()
-	if err := srv.Shutdown(shutdownCtx); err != nil {
-		log.Printf("server shutdown error: %v", err)
-	}
-
-	if publisher != nil {
-		if closer, ok := publisher.(interface{ Close() error }); ok {
-			_ = closer.Close()
-		}
+	if err := lc.Stop(); err != nil {
+		log.Printf("shutdown incomplete: %v", err)
+		os.Exit(1)
 	}
 	log.Println("bye")
 }
 
-func autoMigrate(db *gorm.DB) {
-	if err := db.AutoMigrate(&models.Ticket{}); err != nil {
-		log.Fatalf("auto migrate: %v", err)
+// deployWorkflows deploys the embedded ticket process once per configured tenant, or as a shared
+// definition when the deployment is not multi-tenant.
+func deployWorkflows(definitions *service.DefinitionService, cfg config.Config) {
+	if len(workflows.TicketProcess) == 0 {
+		return
+	}
+	tenants := cfg.Tenancy.Tenants
+	if len(tenants) == 0 {
+		tenants = []string{""}
+	}
+	for _, tenantID := range tenants {
+		deployment, err := definitions.Deploy(context.Background(), "ticket-process", workflows.TicketProcess, workflow.DeployOptions{
+			Name:                     cfg.Camunda.DeploymentName,
+			Source:                   "pflow-startup",
+			EnableDuplicateFiltering: true,
+			DeployChangedOnly:        true,
+			TenantID:                 tenantID,
+		})
+		if err != nil {
+			log.Printf("deploy workflow for tenant %q failed: %v", tenantID, err)
+			continue
+		}
+		log.Printf("workflow deployment %s for tenant %q up to date in camunda", deployment.ID, tenantID)
 	}
 }
 
-func runWorker(ctx context.Context, svc *service.WorkflowService, camunda *workflow.CamundaClient, cfg config.Config) {
-	worker := worker.NewExternalWorker("ticket-processing", svc, camunda, 5*time.Second, cfg.WorkerLockDuration)
-	worker.Run(ctx)
+func newExternalWorker(a *app.App, cfg config.Config, m *worker.Metrics) *worker.ExternalWorker {
+	id := cfg.Worker.ID
+	if id == "" {
+		id = worker.DefaultID("pflow-api")
+	}
+	return worker.NewExternalWorker(worker.ExternalWorkerConfig{
+		ID:           id,
+		Topics:       service.ExternalTaskTopics,
+		Tenants:      cfg.Worker.Tenants,
+		Interval:     cfg.Worker.PollInterval,
+		LockDuration: cfg.Worker.LockDuration,
+		Metrics:      m,
+	}, a.Workflow, a.Camunda)
 }
 
 func init() {
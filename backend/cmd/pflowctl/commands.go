@@ -0,0 +1,400 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/example/pflow/backend/internal/models"
+	"github.com/example/pflow/backend/internal/repository"
+	"github.com/example/pflow/backend/internal/service"
+	"github.com/example/pflow/backend/internal/workflow"
+)
+
+// filterFlags are the ticket selection flags shared by several commands.
+type filterFlags struct {
+	ids           string
+	statuses      string
+	ticketType    string
+	requester     string
+	assignee      string
+	createdAfter  string
+	createdBefore string
+	limit         int
+}
+
+func (f *filterFlags) register(fs *flag.FlagSet, limit int) {
+	fs.StringVar(&f.ids, "id", "", "comma-separated ticket `ids`")
+	fs.StringVar(&f.statuses, "status", "", "comma-separated ticket `statuses`")
+	fs.StringVar(&f.ticketType, "type", "", "ticket `type`")
+	fs.StringVar(&f.requester, "requester", "", "`requester` of the tickets")
+	fs.StringVar(&f.assignee, "assignee", "", "`assignee` of the tickets")
+	fs.StringVar(&f.createdAfter, "created-after", "", "tickets created at or after `time` (RFC3339, 2006-01-02 or a duration ago such as 24h)")
+	fs.StringVar(&f.createdBefore, "created-before", "", "tickets created before `time` (same formats as -created-after)")
+	fs.IntVar(&f.limit, "limit", limit, "at most `n` tickets; 0 for no limit")
+}
+
+func (f *filterFlags) filter() (repository.TicketFilter, error) {
+	filter := repository.TicketFilter{Type: f.ticketType, Requester: f.requester, Assignee: f.assignee, Limit: f.limit}
+	for _, raw := range splitList(f.ids) {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return filter, fmt.Errorf("%w: invalid ticket id %q", errUsage, raw)
+		}
+		filter.IDs = append(filter.IDs, id)
+	}
+	for _, status := range splitList(f.statuses) {
+		filter.Statuses = append(filter.Statuses, models.TicketStatus(status))
+	}
+	var err error
+	if filter.CreatedAfter, err = parseTime(f.createdAfter); err != nil {
+		return filter, fmt.Errorf("%w: -created-after: %v", errUsage, err)
+	}
+	if filter.CreatedBefore, err = parseTime(f.createdBefore); err != nil {
+		return filter, fmt.Errorf("%w: -created-before: %v", errUsage, err)
+	}
+	return filter, nil
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// parseTime reads an RFC3339 time, a date in local time, or a duration meaning that long ago.
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, s, time.Local); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not a time, date or duration", s)
+}
+
+func parseTicketID(raw string) (uuid.UUID, error) {
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: invalid ticket id %q", errUsage, raw)
+	}
+	return id, nil
+}
+
+// render writes v as indented JSON, or calls table with a tab-separated writer.
+func render(g globals, v any, table func(w *tabwriter.Writer)) error {
+	if g.output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	table(w)
+	return w.Flush()
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func ticketTable(tickets []models.Ticket) func(w *tabwriter.Writer) {
+	return func(w *tabwriter.Writer) {
+		fmt.Fprintln(w, "ID\tSTATUS\tTYPE\tREQUESTER\tASSIGNEE\tCREATED\tTITLE")
+		for _, t := range tickets {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", t.ID, t.Status, orDash(t.Type), orDash(t.Requester), orDash(t.Assignee), formatTime(t.CreatedAt), t.Title)
+		}
+	}
+}
+
+func ticketDetail(t *models.Ticket) func(w *tabwriter.Writer) {
+	return func(w *tabwriter.Writer) {
+		rows := [][2]string{
+			{"ID", t.ID.String()},
+			{"Tenant", orDash(t.TenantID)},
+			{"Title", t.Title},
+			{"Type", orDash(t.Type)},
+			{"Status", string(t.Status)},
+			{"Priority", orDash(string(t.Priority))},
+			{"Requester", orDash(t.Requester)},
+			{"Assignee", orDash(t.Assignee)},
+			{"Process instance", orDash(t.ProcessInstanceID)},
+			{"Process definition", orDash(t.ProcessDefinitionID)},
+			{"SLA", orDash(string(t.SLAState))},
+			{"Created", formatTime(t.CreatedAt)},
+			{"Updated", formatTime(t.UpdatedAt)},
+		}
+		for _, row := range rows {
+			fmt.Fprintf(w, "%s:\t%s\n", row[0], row[1])
+		}
+		if t.Description != "" {
+			fmt.Fprintf(w, "Description:\t%s\n", t.Description)
+		}
+	}
+}
+
+func incidentTable(incidents []service.TicketIncident) func(w *tabwriter.Writer) {
+	return func(w *tabwriter.Writer) {
+		fmt.Fprintln(w, "INCIDENT\tTICKET\tSTATUS\tACTIVITY\tTYPE\tSINCE\tMESSAGE")
+		for _, i := range incidents {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", i.ID, i.TicketID, i.TicketStatus, i.ActivityID, i.IncidentType, orDash(i.IncidentTimestamp), i.IncidentMessage)
+		}
+	}
+}
+
+func listTickets(ctx context.Context, connect connector, g globals, args []string) error {
+	var f filterFlags
+	fs := commandFlags("tickets list", "")
+	f.register(fs, 50)
+	if err := parseCommand(fs, args, 0, 0); err != nil {
+		return err
+	}
+	filter, err := f.filter()
+	if err != nil {
+		return err
+	}
+	ops, err := connect()
+	if err != nil {
+		return err
+	}
+	tickets, err := ops.Tickets(ctx, filter)
+	if err != nil {
+		return err
+	}
+	return render(g, tickets, ticketTable(tickets))
+}
+
+func showTicket(ctx context.Context, connect connector, g globals, args []string) error {
+	fs := commandFlags("tickets show", "<ticket-id>")
+	if err := parseCommand(fs, args, 1, 1); err != nil {
+		return err
+	}
+	id, err := parseTicketID(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	ops, err := connect()
+	if err != nil {
+		return err
+	}
+	ticket, err := ops.Ticket(ctx, id)
+	if err != nil {
+		return err
+	}
+	return render(g, ticket, ticketDetail(ticket))
+}
+
+func setStatus(ctx context.Context, connect connector, g globals, args []string) error {
+	fs := commandFlags("tickets set-status", "<ticket-id>")
+	status := fs.String("status", "", "new `status` of the ticket (required)")
+	reason := fs.String("reason", "", "`reason` recorded with the change (required)")
+	if err := parseCommand(fs, args, 1, 1); err != nil {
+		return err
+	}
+	if *status == "" || strings.TrimSpace(*reason) == "" {
+		fs.Usage()
+		return fmt.Errorf("%w: -status and -reason are required", errUsage)
+	}
+	id, err := parseTicketID(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	ops, err := connect()
+	if err != nil {
+		return err
+	}
+	ticket, err := ops.ForceStatus(ctx, id, models.TicketStatus(*status), *reason)
+	if err != nil {
+		return err
+	}
+	return render(g, ticket, ticketDetail(ticket))
+}
+
+func resubmit(ctx context.Context, connect connector, g globals, args []string) error {
+	fs := commandFlags("tickets resubmit", "<ticket-id>")
+	if err := parseCommand(fs, args, 1, 1); err != nil {
+		return err
+	}
+	id, err := parseTicketID(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	ops, err := connect()
+	if err != nil {
+		return err
+	}
+	ticket, err := ops.Resubmit(ctx, id)
+	if err != nil {
+		return err
+	}
+	return render(g, ticket, ticketDetail(ticket))
+}
+
+func deploy(ctx context.Context, connect connector, g globals, args []string) error {
+	fs := commandFlags("deploy", "<file.bpmn>")
+	name := fs.String("name", "", "deployment `name`; defaults to the resource name")
+	changedOnly := fs.Bool("changed-only", false, "redeploy only the resources that changed since the last deployment")
+	noFilter := fs.Bool("no-duplicate-filter", false, "deploy even when the resource is unchanged")
+	if err := parseCommand(fs, args, 1, 1); err != nil {
+		return err
+	}
+	file := fs.Arg(0)
+	bpmn, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	resource := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	ops, err := connect()
+	if err != nil {
+		return err
+	}
+	deployment, err := ops.Deploy(ctx, resource, bpmn, workflow.DeployOptions{
+		Name:                     *name,
+		EnableDuplicateFiltering: !*noFilter,
+		DeployChangedOnly:        *changedOnly,
+	})
+	if err != nil {
+		return err
+	}
+	return render(g, deployment, func(w *tabwriter.Writer) {
+		fmt.Fprintf(w, "Deployment:\t%s\n", deployment.ID)
+		fmt.Fprintf(w, "Name:\t%s\n", deployment.Name)
+		fmt.Fprintf(w, "Time:\t%s\n", orDash(deployment.DeploymentTime))
+		if len(deployment.DeployedProcessDefinitions) == 0 {
+			fmt.Fprintln(w, "Definitions:\tnone (unchanged)")
+		}
+		for _, id := range sortedKeys(deployment.DeployedProcessDefinitions) {
+			def := deployment.DeployedProcessDefinitions[id]
+			fmt.Fprintf(w, "Definition:\t%s (%s v%d)\n", id, def.Key, def.Version)
+		}
+	})
+}
+
+func listIncidents(ctx context.Context, connect connector, g globals, args []string) error {
+	var f filterFlags
+	fs := commandFlags("incidents list", "")
+	f.register(fs, 0)
+	if err := parseCommand(fs, args, 0, 0); err != nil {
+		return err
+	}
+	filter, err := f.filter()
+	if err != nil {
+		return err
+	}
+	ops, err := connect()
+	if err != nil {
+		return err
+	}
+	incidents, err := ops.Incidents(ctx, filter)
+	if err != nil {
+		return err
+	}
+	return render(g, incidents, incidentTable(incidents))
+}
+
+func retryIncidents(ctx context.Context, connect connector, g globals, args []string) error {
+	fs := commandFlags("incidents retry", "<incident-id>...")
+	retries := fs.Int("retries", 1, "`retries` given to the failed activity")
+	if err := parseCommand(fs, args, 1, -1); err != nil {
+		return err
+	}
+	if *retries < 1 {
+		return fmt.Errorf("%w: -retries must be at least 1", errUsage)
+	}
+	ops, err := connect()
+	if err != nil {
+		return err
+	}
+	retried := []service.TicketIncident{}
+	var failed int
+	for _, id := range fs.Args() {
+		incident, err := ops.RetryIncident(ctx, id, *retries)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			failed++
+			continue
+		}
+		retried = append(retried, *incident)
+	}
+	if err := render(g, retried, incidentTable(retried)); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d incidents not retried", failed, fs.NArg())
+	}
+	return nil
+}
+
+func replayEvents(ctx context.Context, connect connector, g globals, args []string) error {
+	var f filterFlags
+	fs := commandFlags("events replay", "")
+	f.register(fs, 0)
+	if err := parseCommand(fs, args, 0, 0); err != nil {
+		return err
+	}
+	filter, err := f.filter()
+	if err != nil {
+		return err
+	}
+	ops, err := connect()
+	if err != nil {
+		return err
+	}
+	replayed, err := ops.ReplayEvents(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("replayed %d events: %w", replayed, err)
+	}
+	return render(g, map[string]int{"replayed": replayed}, func(w *tabwriter.Writer) {
+		fmt.Fprintf(w, "Replayed %d events\n", replayed)
+	})
+}
+
+func reconcile(ctx context.Context, connect connector, g globals, args []string) error {
+	fs := commandFlags("reconcile", "")
+	since := fs.String("since", "", "re-read history since `time` (RFC3339, 2006-01-02 or a duration ago); from the last watermark when empty")
+	if err := parseCommand(fs, args, 0, 0); err != nil {
+		return err
+	}
+	from, err := parseTime(*since)
+	if err != nil {
+		return fmt.Errorf("%w: -since: %v", errUsage, err)
+	}
+	ops, err := connect()
+	if err != nil {
+		return err
+	}
+	changed, err := ops.Reconcile(ctx, from)
+	if err != nil {
+		return err
+	}
+	return render(g, map[string]int{"changed": changed}, func(w *tabwriter.Writer) {
+		fmt.Fprintf(w, "Updated %d tickets\n", changed)
+	})
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm/logger"
+
+	"github.com/example/pflow/backend/internal/app"
+	"github.com/example/pflow/backend/internal/config"
+	"github.com/example/pflow/backend/internal/models"
+	"github.com/example/pflow/backend/internal/repository"
+	"github.com/example/pflow/backend/internal/service"
+	"github.com/example/pflow/backend/internal/tenant"
+	"github.com/example/pflow/backend/internal/workflow"
+)
+
+// operator carries out the commands, either directly or through the API.
+type operator interface {
+	Tickets(ctx context.Context, filter repository.TicketFilter) ([]models.Ticket, error)
+	Ticket(ctx context.Context, id uuid.UUID) (*models.Ticket, error)
+	ForceStatus(ctx context.Context, id uuid.UUID, status models.TicketStatus, reason string) (*models.Ticket, error)
+	Resubmit(ctx context.Context, id uuid.UUID) (*models.Ticket, error)
+	Deploy(ctx context.Context, name string, bpmn []byte, opts workflow.DeployOptions) (*workflow.Deployment, error)
+	Incidents(ctx context.Context, filter repository.TicketFilter) ([]service.TicketIncident, error)
+	RetryIncident(ctx context.Context, id string, retries int) (*service.TicketIncident, error)
+	ReplayEvents(ctx context.Context, filter repository.TicketFilter) (int, error)
+	Reconcile(ctx context.Context, since time.Time) (int, error)
+	Close() error
+}
+
+// closeTimeout bounds flushing events and closing connections when a direct command ends.
+const closeTimeout = 30 * time.Second
+
+// direct works on the database, Camunda and RabbitMQ with the services the API uses.
+type direct struct {
+	app    *app.App
+	tenant string
+	user   string
+}
+
+func newDirect(g globals) (*direct, error) {
+	var args []string
+	if g.config != "" {
+		args = []string{"-config", g.config}
+	}
+	cfg, err := config.Load("pflowctl", args)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	a, err := app.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if !g.verbose {
+		// Lookups that find nothing are answered to the operator; they are not worth a log line.
+		a.DB.Logger = logger.New(log.New(os.Stderr, "\n", log.LstdFlags), logger.Config{
+			SlowThreshold:             time.Second,
+			LogLevel:                  logger.Warn,
+			IgnoreRecordNotFoundError: true,
+		})
+	}
+	return &direct{app: a, tenant: g.tenant, user: g.user}, nil
+}
+
+// scope makes the services act for the selected tenant; without one they see every tenant.
+func (d *direct) scope(ctx context.Context) context.Context {
+	if d.tenant == "" {
+		return ctx
+	}
+	return tenant.WithID(ctx, d.tenant)
+}
+
+func (d *direct) Tickets(ctx context.Context, filter repository.TicketFilter) ([]models.Ticket, error) {
+	return d.app.Tickets.Find(d.scope(ctx), filter)
+}
+
+func (d *direct) Ticket(ctx context.Context, id uuid.UUID) (*models.Ticket, error) {
+	return d.app.Tickets.FindByID(d.scope(ctx), id)
+}
+
+func (d *direct) ForceStatus(ctx context.Context, id uuid.UUID, status models.TicketStatus, reason string) (*models.Ticket, error) {
+	return d.app.Workflow.ForceStatus(d.scope(ctx), id, status, reason, d.user)
+}
+
+func (d *direct) Resubmit(ctx context.Context, id uuid.UUID) (*models.Ticket, error) {
+	if err := d.app.Workflow.ResubmitTicket(d.scope(ctx), id); err != nil {
+		return nil, err
+	}
+	return d.app.Tickets.FindByID(d.scope(ctx), id)
+}
+
+func (d *direct) Deploy(ctx context.Context, name string, bpmn []byte, opts workflow.DeployOptions) (*workflow.Deployment, error) {
+	opts.Source = "pflowctl"
+	return d.app.Definitions.Deploy(d.scope(ctx), name, bpmn, opts)
+}
+
+func (d *direct) Incidents(ctx context.Context, filter repository.TicketFilter) ([]service.TicketIncident, error) {
+	return d.app.Incidents.List(d.scope(ctx), filter)
+}
+
+func (d *direct) RetryIncident(ctx context.Context, id string, retries int) (*service.TicketIncident, error) {
+	return d.app.Incidents.Retry(d.scope(ctx), id, retries)
+}
+
+func (d *direct) ReplayEvents(ctx context.Context, filter repository.TicketFilter) (int, error) {
+	return d.app.Workflow.ReplayEvents(d.scope(ctx), filter)
+}
+
+func (d *direct) Reconcile(ctx context.Context, since time.Time) (int, error) {
+	if since.IsZero() {
+		return d.app.StatusSync.Sync(ctx)
+	}
+	return d.app.StatusSync.SyncSince(ctx, since)
+}
+
+// Close waits for published events to be confirmed, then closes the connections.
+func (d *direct) Close() error {
+	components := d.app.Components()
+	var errs []error
+	for i := len(components) - 1; i >= 0; i-- {
+		ctx, cancel := context.WithTimeout(context.Background(), closeTimeout)
+		if err := components[i].Stop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("close %s: %w", components[i].Name, err))
+		}
+		cancel()
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,207 @@
+// Command pflowctl is the operator CLI for PFlow. It works directly against the database, Camunda
+// and RabbitMQ with the same configuration as the API, or through the API's admin endpoints when
+// -api is given.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const usageText = `Usage: pflowctl [global flags] <command> [flags] [args]
+
+Commands:
+  tickets list        list tickets matching filters
+  tickets show        show a ticket
+  tickets set-status  force a ticket into a status, with a reason
+  tickets resubmit    start a new process instance for a stuck ticket
+  deploy              deploy a BPMN file to Camunda
+  incidents list      list Camunda incidents of ticket processes
+  incidents retry     retry incidents by ID
+  events replay       publish ticket events again for a range of tickets
+  reconcile           derive ticket statuses from Camunda history now
+
+Run "pflowctl <command> -h" for the flags of a command.
+
+Global flags:
+`
+
+// globals are the flags given before the command.
+type globals struct {
+	config  string
+	api     string
+	token   string
+	tenant  string
+	user    string
+	output  string
+	timeout time.Duration
+	verbose bool
+}
+
+// connector opens the operator once a command has checked its arguments.
+type connector func() (operator, error)
+
+type command struct {
+	name string
+	run  func(ctx context.Context, connect connector, g globals, args []string) error
+}
+
+var commands = []command{
+	{"tickets list", listTickets},
+	{"tickets show", showTicket},
+	{"tickets set-status", setStatus},
+	{"tickets resubmit", resubmit},
+	{"deploy", deploy},
+	{"incidents list", listIncidents},
+	{"incidents retry", retryIncidents},
+	{"events replay", replayEvents},
+	{"reconcile", reconcile},
+}
+
+// errUsage marks errors caused by wrong arguments; they exit with status 2.
+var errUsage = errors.New("usage")
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	var g globals
+	fs := flag.NewFlagSet("pflowctl", flag.ContinueOnError)
+	fs.StringVar(&g.config, "config", "", "config `file` for direct access (default $PFLOW_CONFIG)")
+	fs.StringVar(&g.api, "api", os.Getenv("PFLOW_API_URL"), "API base `url`, e.g. http://localhost:8080; direct DB/Camunda access when empty (env PFLOW_API_URL)")
+	fs.StringVar(&g.token, "token", os.Getenv("PFLOW_API_TOKEN"), "bearer `token` sent to the API (env PFLOW_API_TOKEN)")
+	fs.StringVar(&g.tenant, "tenant", "", "act for this `tenant`; all tenants in direct mode when empty")
+	fs.StringVar(&g.user, "user", defaultUser(), "operator `name` recorded with forced changes")
+	fs.StringVar(&g.output, "o", "table", "output `format`: table or json")
+	fs.DurationVar(&g.timeout, "timeout", 5*time.Minute, "abort the command after this `duration`")
+	fs.BoolVar(&g.verbose, "v", false, "show service logs")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usageText)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitCode(err)
+	}
+	if g.output != "table" && g.output != "json" {
+		fmt.Fprintf(os.Stderr, "unknown output format %q\n", g.output)
+		return 2
+	}
+	cmd, rest, ok := lookup(fs.Args())
+	if !ok {
+		fs.Usage()
+		return 2
+	}
+	if !g.verbose {
+		log.SetOutput(io.Discard)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	ctx, cancelTimeout := context.WithTimeout(ctx, g.timeout)
+	defer cancelTimeout()
+
+	var ops operator
+	connect := func() (operator, error) {
+		if g.api != "" {
+			ops = newRemote(g)
+			return ops, nil
+		}
+		d, err := newDirect(g)
+		if err != nil {
+			return nil, err
+		}
+		ops = d
+		return ops, nil
+	}
+	err := cmd.run(ctx, connect, g, rest)
+	if ops != nil {
+		if closeErr := ops.Close(); closeErr != nil {
+			fmt.Fprintf(os.Stderr, "pflowctl: %v\n", closeErr)
+			if err == nil {
+				return 1
+			}
+		}
+	}
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pflowctl %s: %v\n", cmd.name, err)
+		return exitCode(err)
+	}
+	return 0
+}
+
+// lookup finds the command named by the leading arguments.
+func lookup(args []string) (command, []string, bool) {
+	for _, cmd := range commands {
+		words := strings.Fields(cmd.name)
+		if len(args) >= len(words) && strings.Join(args[:len(words)], " ") == cmd.name {
+			return cmd, args[len(words):], true
+		}
+	}
+	return command{}, nil, false
+}
+
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.Is(err, errUsage):
+		return 2
+	}
+	return 1
+}
+
+func defaultUser() string {
+	if user := os.Getenv("USER"); user != "" {
+		return user
+	}
+	return "pflowctl"
+}
+
+// commandFlags returns the flag set of a command; its usage lists the arguments after the flags.
+func commandFlags(name, arguments string) *flag.FlagSet {
+	fs := flag.NewFlagSet("pflowctl "+name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "%s\n", strings.TrimSpace("Usage: pflowctl "+name+" [flags] "+arguments))
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseCommand parses the flags of a command and checks the number of positional arguments.
+func parseCommand(fs *flag.FlagSet, args []string, minArgs, maxArgs int) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	if n := fs.NArg(); n < minArgs || (maxArgs >= 0 && n > maxArgs) {
+		fs.Usage()
+		return fmt.Errorf("%w: wrong number of arguments", errUsage)
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
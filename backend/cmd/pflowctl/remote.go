@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/example/pflow/backend/internal/models"
+	"github.com/example/pflow/backend/internal/repository"
+	"github.com/example/pflow/backend/internal/service"
+	"github.com/example/pflow/backend/internal/workflow"
+)
+
+// remote carries out the commands through the admin endpoints of the API.
+type remote struct {
+	base   string
+	token  string
+	tenant string
+	user   string
+	client *http.Client
+}
+
+func newRemote(g globals) *remote {
+	return &remote{base: strings.TrimRight(g.api, "/") + "/api", token: g.token, tenant: g.tenant, user: g.user, client: &http.Client{}}
+}
+
+// apiError is an error answered by the API.
+type apiError struct {
+	status  int
+	message string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("api answered %d: %s", e.status, e.message)
+}
+
+func (r *remote) do(ctx context.Context, method, path, contentType string, body io.Reader, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, r.base+path, body)
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if r.token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	}
+	if r.tenant != "" {
+		req.Header.Set("X-Tenant-ID", r.tenant)
+	}
+	req.Header.Set("X-User", r.user)
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		var failure struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(data, &failure) != nil || failure.Error == "" {
+			failure.Error = strings.TrimSpace(string(data))
+		}
+		return &apiError{status: resp.StatusCode, message: failure.Error}
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+func (r *remote) doJSON(ctx context.Context, method, path string, payload, out any) error {
+	var body io.Reader
+	contentType := ""
+	if payload != nil {
+		raw, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body, contentType = bytes.NewReader(raw), "application/json"
+	}
+	return r.do(ctx, method, path, contentType, body, out)
+}
+
+// filterQuery encodes a filter the way the admin endpoints read it.
+func filterQuery(filter repository.TicketFilter) string {
+	q := url.Values{}
+	for _, id := range filter.IDs {
+		q.Add("id", id.String())
+	}
+	for _, status := range filter.Statuses {
+		q.Add("status", string(status))
+	}
+	for key, value := range map[string]string{"type": filter.Type, "requester": filter.Requester, "assignee": filter.Assignee} {
+		if value != "" {
+			q.Set(key, value)
+		}
+	}
+	if !filter.CreatedAfter.IsZero() {
+		q.Set("createdAfter", filter.CreatedAfter.Format(time.RFC3339))
+	}
+	if !filter.CreatedBefore.IsZero() {
+		q.Set("createdBefore", filter.CreatedBefore.Format(time.RFC3339))
+	}
+	if filter.Limit > 0 {
+		q.Set("limit", strconv.Itoa(filter.Limit))
+	}
+	if len(q) == 0 {
+		return ""
+	}
+	return "?" + q.Encode()
+}
+
+func (r *remote) Tickets(ctx context.Context, filter repository.TicketFilter) ([]models.Ticket, error) {
+	var tickets []models.Ticket
+	err := r.doJSON(ctx, http.MethodGet, "/admin/tickets"+filterQuery(filter), nil, &tickets)
+	return tickets, err
+}
+
+func (r *remote) Ticket(ctx context.Context, id uuid.UUID) (*models.Ticket, error) {
+	var ticket models.Ticket
+	if err := r.doJSON(ctx, http.MethodGet, "/tickets/"+id.String(), nil, &ticket); err != nil {
+		return nil, err
+	}
+	return &ticket, nil
+}
+
+func (r *remote) ForceStatus(ctx context.Context, id uuid.UUID, status models.TicketStatus, reason string) (*models.Ticket, error) {
+	var ticket models.Ticket
+	payload := map[string]any{"status": status, "reason": reason, "actor": r.user}
+	if err := r.doJSON(ctx, http.MethodPost, "/admin/tickets/"+id.String()+"/status", payload, &ticket); err != nil {
+		return nil, err
+	}
+	return &ticket, nil
+}
+
+func (r *remote) Resubmit(ctx context.Context, id uuid.UUID) (*models.Ticket, error) {
+	var ticket models.Ticket
+	if err := r.doJSON(ctx, http.MethodPost, "/admin/tickets/"+id.String()+"/resubmit", nil, &ticket); err != nil {
+		return nil, err
+	}
+	return &ticket, nil
+}
+
+func (r *remote) Deploy(ctx context.Context, name string, bpmn []byte, opts workflow.DeployOptions) (*workflow.Deployment, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	// The API names the resource after the uploaded file.
+	part, err := form.CreateFormFile("file", name+".bpmn")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write(bpmn); err != nil {
+		return nil, err
+	}
+	fields := map[string]string{
+		"name":                     opts.Name,
+		"enableDuplicateFiltering": strconv.FormatBool(opts.EnableDuplicateFiltering),
+		"deployChangedOnly":        strconv.FormatBool(opts.DeployChangedOnly),
+	}
+	for _, key := range sortedKeys(fields) {
+		if err := form.WriteField(key, fields[key]); err != nil {
+			return nil, err
+		}
+	}
+	if err := form.Close(); err != nil {
+		return nil, err
+	}
+	var deployment workflow.Deployment
+	if err := r.do(ctx, http.MethodPost, "/admin/deployments", form.FormDataContentType(), &body, &deployment); err != nil {
+		return nil, err
+	}
+	return &deployment, nil
+}
+
+func (r *remote) Incidents(ctx context.Context, filter repository.TicketFilter) ([]service.TicketIncident, error) {
+	var incidents []service.TicketIncident
+	err := r.doJSON(ctx, http.MethodGet, "/admin/incidents"+filterQuery(filter), nil, &incidents)
+	return incidents, err
+}
+
+func (r *remote) RetryIncident(ctx context.Context, id string, retries int) (*service.TicketIncident, error) {
+	var incident service.TicketIncident
+	if err := r.doJSON(ctx, http.MethodPost, "/admin/incidents/"+url.PathEscape(id)+"/retry", map[string]int{"retries": retries}, &incident); err != nil {
+		return nil, err
+	}
+	return &incident, nil
+}
+
+func (r *remote) ReplayEvents(ctx context.Context, filter repository.TicketFilter) (int, error) {
+	payload := map[string]any{
+		"ticketIds": filter.IDs,
+		"statuses":  filter.Statuses,
+		"type":      filter.Type,
+		"requester": filter.Requester,
+		"assignee":  filter.Assignee,
+		"limit":     filter.Limit,
+	}
+	if !filter.CreatedAfter.IsZero() {
+		payload["createdAfter"] = filter.CreatedAfter
+	}
+	if !filter.CreatedBefore.IsZero() {
+		payload["createdBefore"] = filter.CreatedBefore
+	}
+	var result struct {
+		Replayed int `json:"replayed"`
+	}
+	err := r.doJSON(ctx, http.MethodPost, "/admin/events/replay", payload, &result)
+	return result.Replayed, err
+}
+
+func (r *remote) Reconcile(ctx context.Context, since time.Time) (int, error) {
+	var payload any
+	if !since.IsZero() {
+		payload = map[string]time.Time{"since": since}
+	}
+	var result struct {
+		Changed int `json:"changed"`
+	}
+	err := r.doJSON(ctx, http.MethodPost, "/admin/reconcile", payload, &result)
+	return result.Changed, err
+}
+
+func (r *remote) Close() error {
+	return nil
+}
@@ -0,0 +1,103 @@
+// Command worker runs the Camunda external task worker and the ticket command subscriber without
+// the HTTP API, so they can be scaled separately. Run it with EMBEDDED_WORKER=false on the API.
+// The status syncer, SLA scheduler and idempotency janitor run here too; when scaling out, set
+// WORKER_SCHEDULERS=false on all replicas but one so each job runs once.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/example/pflow/backend/internal/app"
+	"github.com/example/pflow/backend/internal/lifecycle"
+	"github.com/example/pflow/backend/internal/metrics"
+	"github.com/example/pflow/backend/internal/service"
+	"github.com/example/pflow/backend/internal/worker"
+)
+
+func main() {
+	cfg := app.LoadConfig("worker")
+
+	a, err := app.New(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	id := cfg.Worker.ID
+	if id == "" {
+		id = worker.DefaultID("pflow-worker")
+	}
+	registry := metrics.NewRegistry()
+	workerMetrics := worker.NewMetrics(registry)
+	external := worker.NewExternalWorker(worker.ExternalWorkerConfig{
+		ID:           id,
+		Topics:       service.ExternalTaskTopics,
+		Tenants:      cfg.Worker.Tenants,
+		Interval:     cfg.Worker.PollInterval,
+		LockDuration: cfg.Worker.LockDuration,
+		Metrics:      workerMetrics,
+	}, a.Workflow, a.Camunda)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		health := external.Health(time.Now())
+		status := http.StatusOK
+		if !health.Ready {
+			status = http.StatusServiceUnavailable
+		}
+		if sqlDB, err := a.DB.DB(); err != nil || sqlDB.PingContext(r.Context()) != nil {
+			health.Ready = false
+			status = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(health)
+	})
+	mux.Handle("/metrics", registry)
+
+	lc := lifecycle.New()
+	for _, c := range a.Components() {
+		lc.Add(c)
+	}
+	lc.Add(external.Component(cfg.ShutdownTimeout))
+	commands := lifecycle.Loop("command subscriber", func(ctx context.Context) {
+		app.RunCommandSubscriber(ctx, a.Workflow, cfg, workerMetrics)
+	})
+	commands.StopTimeout = cfg.ShutdownTimeout
+	lc.Add(commands)
+	if cfg.Worker.Schedulers {
+		for _, c := range a.Schedulers(cfg) {
+			lc.Add(c)
+		}
+	} else {
+		log.Println("schedulers disabled, periodic jobs are left to another worker")
+	}
+	lc.Add(lifecycle.HTTPServer("health server", &http.Server{Addr: cfg.Worker.HTTPPort, Handler: mux}))
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	log.Printf("starting worker %s", id)
+	if err := lc.Start(ctx); err != nil {
+		log.Printf("startup failed: %v", err)
+		os.Exit(1)
+	}
+
+	<-ctx.Done()
+	// A second signal now terminates the process right away.
+	cancel()
+	log.Println("shutdown initiated")
+	if err := lc.Stop(); err != nil {
+		log.Printf("shutdown incomplete: %v", err)
+		os.Exit(1)
+	}
+	log.Println("bye")
+}
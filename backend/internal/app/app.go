@@ -0,0 +1,212 @@
+// Package app wires the database, message broker, Camunda client and services shared by the API
+// and the standalone worker.
+package app
+
+import (
+	"context"
+	"log"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/example/pflow/backend/internal/config"
+	"github.com/example/pflow/backend/internal/db"
+	"github.com/example/pflow/backend/internal/directory"
+	"github.com/example/pflow/backend/internal/lifecycle"
+	"github.com/example/pflow/backend/internal/models"
+	"github.com/example/pflow/backend/internal/mq"
+	"github.com/example/pflow/backend/internal/provision"
+	"github.com/example/pflow/backend/internal/repository"
+	"github.com/example/pflow/backend/internal/service"
+	"github.com/example/pflow/backend/internal/storage"
+	"github.com/example/pflow/backend/internal/workflow"
+)
+
+// App holds the collaborators built from a Config.
+type App struct {
+	DB          *gorm.DB
+	Publisher   mq.Publisher
+	Requester   mq.Requester
+	Camunda     *workflow.CamundaClient
+	Tickets     repository.TicketStore
+	Directory   directory.Directory
+	Definitions *service.DefinitionService
+	Workflow    *service.WorkflowService
+	Migrations  *service.MigrationService
+	SLA         *service.SLAService
+	Types       *service.TicketTypeService
+	Comments    *service.CommentService
+	Attachments *service.AttachmentService
+	Routing     *service.RoutingService
+	Assignments *service.AssignmentService
+	Inbox       *service.InboxService
+	Bulk        *service.BulkService
+	Idempotency *service.IdempotencyService
+	StatusSync  *service.StatusSyncService
+	Incidents   *service.IncidentService
+
+	publisher *mq.RabbitPublisher
+	requester *mq.RabbitRequester
+}
+
+// MemoryDSN keeps tickets in process memory and the other tables in an in-memory SQLite database,
+// so a demo or a test runs as a single binary without a database server.
+const MemoryDSN = "memory:"
+
+// New connects to the database and builds the services. An unreachable broker is tolerated:
+// events are then dropped, as before.
+func New(cfg config.Config) (*App, error) {
+	dsn := cfg.DB.DSN()
+	memory := dsn == MemoryDSN
+	if memory {
+		dsn = "sqlite::memory:"
+	}
+	database, err := db.New(dsn, db.Pool{
+		MaxOpenConns:    cfg.DB.MaxOpenConns,
+		MaxIdleConns:    cfg.DB.MaxIdleConns,
+		ConnMaxLifetime: cfg.DB.ConnMaxLifetime,
+		ConnMaxIdleTime: cfg.DB.ConnMaxIdleTime,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "connect database")
+	}
+	dir, err := loadDirectory(cfg)
+	if err != nil {
+		return nil, err
+	}
+	blobs, err := newBlobStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &App{DB: database, Directory: dir}
+	if publisher, err := mq.NewRabbitPublisher(cfg.MQ.URL, cfg.MQ.TicketExchange); err != nil {
+		// Leave the interface nil rather than holding a typed nil pointer.
+		log.Printf("warning: rabbitmq unavailable (%v), continuing without events", err)
+	} else {
+		a.Publisher, a.publisher = publisher, publisher
+	}
+	if requester, err := mq.NewRabbitRequester(cfg.MQ.URL, cfg.MQ.TicketExchange); err != nil {
+		log.Printf("warning: rabbitmq unavailable (%v), mq provision actions disabled", err)
+	} else {
+		a.Requester, a.requester = requester, requester
+	}
+	a.Camunda = workflow.NewCamundaClient(workflow.ClientConfig{
+		BaseURL:          cfg.Camunda.URL,
+		Username:         cfg.Camunda.Username,
+		Password:         cfg.Camunda.Password,
+		BearerToken:      cfg.Camunda.Token,
+		Timeout:          cfg.Camunda.Timeout,
+		MaxRetries:       cfg.Camunda.MaxRetries,
+		RetryBackoff:     cfg.Camunda.RetryBackoff,
+		BreakerThreshold: cfg.Camunda.BreakerThreshold,
+		BreakerCooldown:  cfg.Camunda.BreakerCooldown,
+	})
+
+	if memory {
+		a.Tickets = repository.NewMemoryTicketStore()
+	} else {
+		a.Tickets = repository.NewTicketRepository(database)
+	}
+	a.Definitions = service.NewDefinitionService(repository.NewProcessPinRepository(database), a.Camunda)
+	a.Attachments = service.NewAttachmentService(repository.NewAttachmentRepository(database), a.Tickets, blobs, a.Publisher, service.AttachmentLimits{
+		MaxSize:      cfg.Attachments.MaxSize,
+		AllowedTypes: cfg.Attachments.AllowedTypes,
+	})
+	a.Comments = service.NewCommentService(repository.NewCommentRepository(database), a.Tickets, dir, a.Publisher)
+	a.SLA = service.NewSLAService(repository.NewSLAPolicyRepository(database), a.Tickets, a.Publisher, service.SLADefaults{
+		Approval:        cfg.SLA.Approval,
+		Resolution:      cfg.SLA.Resolution,
+		WarnBefore:      cfg.SLA.WarnBefore,
+		EscalateAfter:   cfg.SLA.EscalateAfter,
+		EscalationGroup: cfg.SLA.EscalationGroup,
+	})
+	provisioner := provision.NewRunner(provision.Config{
+		WorkDir:   cfg.Provision.WorkDir,
+		Commands:  cfg.Provision.Commands,
+		Hosts:     cfg.Provision.Hosts,
+		Requester: a.Requester,
+		Timeout:   cfg.Provision.Timeout,
+	})
+	a.Types = service.NewTicketTypeService(repository.NewTicketTypeRepository(database), a.Tickets, cfg.Camunda.ProcessKey, provisioner)
+	a.Assignments = service.NewAssignmentService(a.Tickets, repository.NewOutOfOfficeRepository(database), a.Camunda, a.Publisher)
+	a.Routing = service.NewRoutingService(repository.NewRoutingRuleRepository(database), a.Tickets, dir, a.Assignments, cfg.Directory.ApproverGroup)
+	a.Workflow = service.NewWorkflowService(a.Tickets, a.Camunda, a.Definitions, a.Types, a.SLA, a.Comments, a.Routing, provisioner, a.Publisher)
+	a.Migrations = service.NewMigrationService(a.Tickets, a.Definitions, a.Camunda, cfg.Camunda.ProcessKey)
+	a.Inbox = service.NewInboxService(a.Camunda, a.Tickets, dir)
+	a.Idempotency = service.NewIdempotencyService(repository.NewIdempotencyRepository(database), cfg.HTTP.IdempotencyTTL)
+	statusMapping, err := service.ParseStatusMapping(cfg.StatusSync.Activities)
+	if err != nil {
+		return nil, errors.Wrap(err, "statusSync.activities")
+	}
+	a.StatusSync = service.NewStatusSyncService(a.Tickets, a.Camunda, repository.NewSyncCursorRepository(database), a.SLA, a.Publisher, statusMapping, cfg.StatusSync.Overlap)
+	a.Incidents = service.NewIncidentService(a.Tickets, a.Camunda)
+	a.Bulk = service.NewBulkService(a.Workflow, a.Assignments, a.Tickets, repository.NewBulkJobRepository(database), cfg.Bulk.Concurrency, cfg.Bulk.SyncLimit)
+	return a, nil
+}
+
+// Migrate creates or updates the tables of the models, after the schema changes AutoMigrate
+// cannot make.
+func (a *App) Migrate() error {
+	if err := migrateProcessPinKey(a.DB); err != nil {
+		return err
+	}
+	return a.DB.AutoMigrate(&models.Ticket{}, &models.ProcessDefinitionPin{}, &models.SLAPolicy{}, &models.TicketType{}, &models.Comment{}, &models.CommentRevision{}, &models.Attachment{}, &models.RoutingRule{}, &models.OutOfOfficeRule{}, &models.IdempotencyRecord{}, &models.SyncCursor{}, &models.BulkJob{})
+}
+
+// Components returns the database pool and the broker connections as lifecycle components. They
+// are already connected, so add them first: they are then stopped after everything using them.
+func (a *App) Components() []lifecycle.Component {
+	return []lifecycle.Component{
+		{Name: "database", Stop: a.closeDB},
+		{Name: "message broker", Stop: a.closeBroker},
+	}
+}
+
+func (a *App) closeDB(context.Context) error {
+	sqlDB, err := a.DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// closeBroker flushes the events still awaiting broker confirmation before closing.
+func (a *App) closeBroker(ctx context.Context) error {
+	if a.requester != nil {
+		if err := a.requester.Close(); err != nil {
+			log.Printf("close mq requester: %v", err)
+		}
+	}
+	if a.publisher == nil {
+		return nil
+	}
+	return errors.Wrap(a.publisher.Shutdown(ctx), "flush events")
+}
+
+func loadDirectory(cfg config.Config) (directory.Directory, error) {
+	if cfg.Directory.File == "" {
+		return directory.Unrestricted{}, nil
+	}
+	dir, err := directory.LoadFile(cfg.Directory.File)
+	if err != nil {
+		return nil, errors.Wrap(err, "load user directory")
+	}
+	return dir, nil
+}
+
+func newBlobStore(cfg config.Config) (storage.BlobStore, error) {
+	if cfg.Attachments.Store == "s3" {
+		store, err := storage.NewS3Store(storage.S3Config{
+			Endpoint:  cfg.Attachments.S3.Endpoint,
+			Region:    cfg.Attachments.S3.Region,
+			Bucket:    cfg.Attachments.S3.Bucket,
+			AccessKey: cfg.Attachments.S3.AccessKey,
+			SecretKey: cfg.Attachments.S3.SecretKey,
+			PathStyle: cfg.Attachments.S3.PathStyle,
+		})
+		return store, errors.Wrap(err, "configure s3 attachment store")
+	}
+	store, err := storage.NewLocalStore(cfg.Attachments.Dir)
+	return store, errors.Wrap(err, "configure local attachment store")
+}
@@ -0,0 +1,55 @@
+package app
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// migrateProcessPinKey moves process_definition_pins from the process_key primary key of
+// untenanted releases to (tenant_id, process_key). AutoMigrate adds columns but never changes an
+// existing primary key, so without this every tenant would share one pin per key. Existing pins
+// become the pins of the default (empty) tenant. SQLite databases are created afresh and skipped.
+func migrateProcessPinKey(db *gorm.DB) error {
+	if db.Dialector.Name() != "postgres" {
+		return nil
+	}
+	var exists bool
+	if err := db.Raw(`SELECT to_regclass('process_definition_pins') IS NOT NULL`).Scan(&exists).Error; err != nil {
+		return errors.Wrap(err, "look up process_definition_pins")
+	}
+	if !exists {
+		return nil
+	}
+	var columns []string
+	err := db.Raw(`SELECT a.attname FROM pg_index i
+		JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
+		WHERE i.indrelid = 'process_definition_pins'::regclass AND i.indisprimary`).Scan(&columns).Error
+	if err != nil {
+		return errors.Wrap(err, "read process_definition_pins primary key")
+	}
+	for _, column := range columns {
+		if column == "tenant_id" {
+			return nil
+		}
+	}
+	var constraint string
+	err = db.Raw(`SELECT conname FROM pg_constraint
+		WHERE conrelid = 'process_definition_pins'::regclass AND contype = 'p'`).Scan(&constraint).Error
+	if err != nil {
+		return errors.Wrap(err, "read process_definition_pins primary key")
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`ALTER TABLE process_definition_pins ADD COLUMN IF NOT EXISTS tenant_id varchar(64) NOT NULL DEFAULT ''`).Error; err != nil {
+			return errors.Wrap(err, "add process_definition_pins.tenant_id")
+		}
+		if constraint != "" {
+			if err := tx.Exec(`ALTER TABLE process_definition_pins DROP CONSTRAINT ` + tx.Statement.Quote(constraint)).Error; err != nil {
+				return errors.Wrap(err, "drop process_definition_pins primary key")
+			}
+		}
+		if err := tx.Exec(`ALTER TABLE process_definition_pins ADD PRIMARY KEY (tenant_id, process_key)`).Error; err != nil {
+			return errors.Wrap(err, "add process_definition_pins primary key")
+		}
+		return nil
+	})
+}
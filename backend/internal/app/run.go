@@ -0,0 +1,77 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"github.com/example/pflow/backend/internal/config"
+	"github.com/example/pflow/backend/internal/lifecycle"
+	"github.com/example/pflow/backend/internal/mq"
+	"github.com/example/pflow/backend/internal/service"
+	"github.com/example/pflow/backend/internal/worker"
+)
+
+// LoadConfig reads the configuration of the named binary from the config file, environment and
+// flags. "<name> config print [flags]" writes the effective configuration with secrets redacted
+// and exits.
+func LoadConfig(name string) config.Config {
+	args := os.Args[1:]
+	printOnly := len(args) >= 2 && args[0] == "config" && args[1] == "print"
+	if printOnly {
+		args = args[2:]
+	}
+	cfg, err := config.Load(name, args)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if printOnly {
+		if perr := cfg.Print(os.Stdout); perr != nil {
+			log.Fatal(perr)
+		}
+	}
+	if err != nil {
+		log.Fatalf("invalid configuration:\n%v", err)
+	}
+	if printOnly {
+		os.Exit(0)
+	}
+	return cfg
+}
+
+// RunCommandSubscriber consumes ticket commands until ctx is cancelled. An unreachable broker
+// disables commands rather than failing the process.
+func RunCommandSubscriber(ctx context.Context, svc *service.WorkflowService, cfg config.Config, m *worker.Metrics) {
+	consumer, err := mq.NewRabbitConsumer(cfg.MQ.URL, cfg.MQ.TicketExchange, cfg.MQ.CommandQueue, worker.CommandBindingKey, worker.TenantCommandBindingKey)
+	if err != nil {
+		log.Printf("warning: rabbitmq unavailable (%v), ticket commands disabled", err)
+		return
+	}
+	if err := consumer.EnableRetries(cfg.MQ.CommandRetryDelay); err != nil {
+		consumer.Close()
+		log.Printf("warning: declare command retry queues failed (%v), ticket commands disabled", err)
+		return
+	}
+	subscriber := worker.NewCommandSubscriber(worker.CommandSubscriberConfig{
+		Tenants:       cfg.Tenancy.Tenants,
+		DefaultTenant: cfg.Tenancy.Default,
+		MaxAttempts:   cfg.MQ.CommandMaxAttempts,
+		Metrics:       m,
+	}, consumer, svc)
+	if err := subscriber.Run(ctx); err != nil {
+		log.Printf("command subscriber stopped: %v", err)
+	}
+}
+
+// Schedulers returns the periodic jobs: the status syncer, the SLA scheduler and the idempotency
+// janitor. One process per deployment runs them, see config.WorkerConfig.Schedulers.
+func (a *App) Schedulers(cfg config.Config) []lifecycle.Component {
+	return []lifecycle.Component{
+		lifecycle.Loop("status syncer", worker.NewStatusSyncer(a.StatusSync, a.Migrations, cfg.StatusSync.Interval).Run),
+		lifecycle.Loop("sla scheduler", worker.NewSLAScheduler(a.SLA, cfg.SLA.CheckInterval).Run),
+		lifecycle.Loop("idempotency janitor", worker.NewIdempotencyJanitor(a.Idempotency, time.Hour).Run),
+	}
+}
@@ -0,0 +1,100 @@
+package bpmn
+
+import (
+	"encoding/xml"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Element is a flow node or sequence flow declared in a BPMN process.
+type Element struct {
+	ID        string
+	Type      string
+	Name      string
+	SourceRef string
+	TargetRef string
+}
+
+// Bounds is the DI rectangle of a shape.
+type Bounds struct {
+	X      float64 `xml:"x,attr"`
+	Y      float64 `xml:"y,attr"`
+	Width  float64 `xml:"width,attr"`
+	Height float64 `xml:"height,attr"`
+}
+
+// Point is a DI waypoint of an edge.
+type Point struct {
+	X float64 `xml:"x,attr"`
+	Y float64 `xml:"y,attr"`
+}
+
+// Shape places a flow node on the diagram plane.
+type Shape struct {
+	ElementID string `xml:"bpmnElement,attr"`
+	Bounds    Bounds `xml:"Bounds"`
+}
+
+// Edge routes a sequence flow on the diagram plane.
+type Edge struct {
+	ElementID string  `xml:"bpmnElement,attr"`
+	Waypoints []Point `xml:"waypoint"`
+}
+
+// Diagram is the parsed process model together with its diagram interchange (DI) layout.
+type Diagram struct {
+	Elements map[string]Element
+	Shapes   []Shape
+	Edges    []Edge
+}
+
+type rawElement struct {
+	XMLName   xml.Name
+	ID        string       `xml:"id,attr"`
+	Name      string       `xml:"name,attr"`
+	SourceRef string       `xml:"sourceRef,attr"`
+	TargetRef string       `xml:"targetRef,attr"`
+	Children  []rawElement `xml:",any"`
+}
+
+type rawDefinitions struct {
+	Processes []rawElement `xml:"process"`
+	Diagrams  []struct {
+		Shapes []Shape `xml:"BPMNPlane>BPMNShape"`
+		Edges  []Edge  `xml:"BPMNPlane>BPMNEdge"`
+	} `xml:"BPMNDiagram"`
+}
+
+// Parse reads a BPMN 2.0 XML document. Namespaces are ignored so models from different modelers parse alike.
+func Parse(data []byte) (*Diagram, error) {
+	var raw rawDefinitions
+	if err := xml.Unmarshal(data, &raw); err != nil {
+		return nil, errors.Wrap(err, "parse bpmn")
+	}
+	d := &Diagram{Elements: map[string]Element{}}
+	for _, process := range raw.Processes {
+		d.collect(process.Children)
+	}
+	for _, diagram := range raw.Diagrams {
+		d.Shapes = append(d.Shapes, diagram.Shapes...)
+		d.Edges = append(d.Edges, diagram.Edges...)
+	}
+	return d, nil
+}
+
+func (d *Diagram) collect(elements []rawElement) {
+	for _, el := range elements {
+		if el.ID != "" && !strings.HasSuffix(el.XMLName.Local, "EventDefinition") {
+			d.Elements[el.ID] = Element{
+				ID:        el.ID,
+				Type:      el.XMLName.Local,
+				Name:      el.Name,
+				SourceRef: el.SourceRef,
+				TargetRef: el.TargetRef,
+			}
+		}
+		// Sub-processes nest their own flow nodes.
+		d.collect(el.Children)
+	}
+}
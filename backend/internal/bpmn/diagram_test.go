@@ -0,0 +1,77 @@
+package bpmn
+
+import (
+	"testing"
+)
+
+const sampleBPMN = `<?xml version="1.0" encoding="UTF-8"?>
+<bpmn:definitions xmlns:bpmn="http://www.omg.org/spec/BPMN/20100524/MODEL" xmlns:bpmndi="http://www.omg.org/spec/BPMN/20100524/DI" xmlns:dc="http://www.omg.org/spec/DD/20100524/DC" xmlns:di="http://www.omg.org/spec/DD/20100524/DI" id="defs">
+  <bpmn:process id="ticket" isExecutable="true">
+    <bpmn:startEvent id="Start" name="Submitted" />
+    <bpmn:sequenceFlow id="Flow_1" sourceRef="Start" targetRef="Approve" />
+    <bpmn:userTask id="Approve" name="Manager &amp; Lead Approval" />
+    <bpmn:boundaryEvent id="Timer" attachedToRef="Approve">
+      <bpmn:timerEventDefinition id="TimerDefinition" />
+    </bpmn:boundaryEvent>
+    <bpmn:sequenceFlow id="Flow_2" sourceRef="Approve" targetRef="Provision" />
+    <bpmn:subProcess id="Provision" name="Provision">
+      <bpmn:serviceTask id="Call" name="Call API" />
+    </bpmn:subProcess>
+    <bpmn:sequenceFlow id="Flow_3" sourceRef="Provision" targetRef="End" />
+    <bpmn:endEvent id="End" />
+  </bpmn:process>
+  <bpmndi:BPMNDiagram id="Diagram">
+    <bpmndi:BPMNPlane id="Plane" bpmnElement="ticket">
+      <bpmndi:BPMNShape id="Start_di" bpmnElement="Start"><dc:Bounds x="100" y="100" width="36" height="36" /></bpmndi:BPMNShape>
+      <bpmndi:BPMNShape id="Approve_di" bpmnElement="Approve"><dc:Bounds x="200" y="78" width="100" height="80" /></bpmndi:BPMNShape>
+      <bpmndi:BPMNShape id="Provision_di" bpmnElement="Provision"><dc:Bounds x="360" y="78" width="100" height="80" /></bpmndi:BPMNShape>
+      <bpmndi:BPMNShape id="End_di" bpmnElement="End"><dc:Bounds x="520" y="100" width="36" height="36" /></bpmndi:BPMNShape>
+      <bpmndi:BPMNEdge id="Flow_1_di" bpmnElement="Flow_1"><di:waypoint x="136" y="118" /><di:waypoint x="200" y="118" /></bpmndi:BPMNEdge>
+      <bpmndi:BPMNEdge id="Flow_2_di" bpmnElement="Flow_2"><di:waypoint x="300" y="118" /><di:waypoint x="360" y="118" /></bpmndi:BPMNEdge>
+    </bpmndi:BPMNPlane>
+  </bpmndi:BPMNDiagram>
+</bpmn:definitions>`
+
+func TestParse(t *testing.T) {
+	d, err := Parse([]byte(sampleBPMN))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	tests := []struct {
+		id   string
+		want Element
+	}{
+		{"Start", Element{ID: "Start", Type: "startEvent", Name: "Submitted"}},
+		{"Approve", Element{ID: "Approve", Type: "userTask", Name: "Manager & Lead Approval"}},
+		{"Timer", Element{ID: "Timer", Type: "boundaryEvent"}},
+		{"Flow_2", Element{ID: "Flow_2", Type: "sequenceFlow", SourceRef: "Approve", TargetRef: "Provision"}},
+		{"Provision", Element{ID: "Provision", Type: "subProcess", Name: "Provision"}},
+		{"Call", Element{ID: "Call", Type: "serviceTask", Name: "Call API"}},
+		{"End", Element{ID: "End", Type: "endEvent"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			if got := d.Elements[tt.id]; got != tt.want {
+				t.Errorf("element = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+	if _, ok := d.Elements["TimerDefinition"]; ok {
+		t.Error("event definitions must not be collected as elements")
+	}
+	if len(d.Elements) != len(tests)+2 {
+		t.Errorf("parsed %d elements, want %d", len(d.Elements), len(tests)+2)
+	}
+	if len(d.Shapes) != 4 || d.Shapes[1].ElementID != "Approve" || d.Shapes[1].Bounds != (Bounds{X: 200, Y: 78, Width: 100, Height: 80}) {
+		t.Errorf("shapes = %+v", d.Shapes)
+	}
+	if len(d.Edges) != 2 || len(d.Edges[0].Waypoints) != 2 || d.Edges[0].Waypoints[1] != (Point{X: 200, Y: 118}) {
+		t.Errorf("edges = %+v", d.Edges)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	if _, err := Parse([]byte("<definitions><process>")); err == nil {
+		t.Error("parse of truncated xml succeeded")
+	}
+}
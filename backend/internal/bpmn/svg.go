@@ -0,0 +1,163 @@
+package bpmn
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Highlight marks activities to emphasise when rendering.
+type Highlight struct {
+	Active    []string
+	Completed []string
+	Incidents []string
+}
+
+const (
+	colorDefault   = "#22242a"
+	colorActive    = "#1a7f37"
+	colorCompleted = "#0969da"
+	colorIncident  = "#cf222e"
+	svgPadding     = 20.0
+)
+
+// RenderSVG draws the diagram shapes and edges as a standalone SVG document.
+func (d *Diagram) RenderSVG(w io.Writer, h Highlight) error {
+	state := map[string]string{}
+	for _, id := range h.Completed {
+		state[id] = colorCompleted
+	}
+	for _, id := range h.Active {
+		state[id] = colorActive
+	}
+	for _, id := range h.Incidents {
+		state[id] = colorIncident
+	}
+
+	shapes := make(map[string]Bounds, len(d.Shapes))
+	minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, shape := range d.Shapes {
+		b := shape.Bounds
+		shapes[shape.ElementID] = b
+		minX, minY = math.Min(minX, b.X), math.Min(minY, b.Y)
+		maxX, maxY = math.Max(maxX, b.X+b.Width), math.Max(maxY, b.Y+b.Height+20)
+	}
+	if len(shapes) == 0 {
+		minX, minY, maxX, maxY = 0, 0, 0, 0
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="%.0f %.0f %.0f %.0f" width="%.0f" height="%.0f" font-family="sans-serif" font-size="12">`,
+		minX-svgPadding, minY-svgPadding, maxX-minX+2*svgPadding, maxY-minY+2*svgPadding, maxX-minX+2*svgPadding, maxY-minY+2*svgPadding)
+	bw.WriteString(`<defs><marker id="arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="8" markerHeight="8" orient="auto-start-reverse"><path d="M0,0 L10,5 L0,10 z" fill="` + colorDefault + `"/></marker></defs>`)
+
+	for _, points := range d.edgePaths(shapes) {
+		coords := make([]string, len(points))
+		for i, p := range points {
+			coords[i] = fmt.Sprintf("%.1f,%.1f", p.X, p.Y)
+		}
+		fmt.Fprintf(bw, `<polyline points="%s" fill="none" stroke="%s" stroke-width="1.5" marker-end="url(#arrow)"/>`, strings.Join(coords, " "), colorDefault)
+	}
+
+	for _, shape := range d.Shapes {
+		el := d.Elements[shape.ElementID]
+		color, highlighted := state[shape.ElementID]
+		if !highlighted {
+			color = colorDefault
+		}
+		d.renderShape(bw, el, shape.Bounds, color, highlighted)
+	}
+	bw.WriteString(`</svg>`)
+	return bw.Flush()
+}
+
+// edgePaths returns DI waypoints for every sequence flow, falling back to a straight line between
+// shape centres when the model carries no BPMNEdge for a flow.
+func (d *Diagram) edgePaths(shapes map[string]Bounds) [][]Point {
+	var paths [][]Point
+	seen := map[string]bool{}
+	for _, edge := range d.Edges {
+		if len(edge.Waypoints) > 1 {
+			paths = append(paths, edge.Waypoints)
+			seen[edge.ElementID] = true
+		}
+	}
+	ids := make([]string, 0, len(d.Elements))
+	for id := range d.Elements {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		el := d.Elements[id]
+		if el.Type != "sequenceFlow" || seen[el.ID] {
+			continue
+		}
+		src, okSrc := shapes[el.SourceRef]
+		dst, okDst := shapes[el.TargetRef]
+		if !okSrc || !okDst {
+			continue
+		}
+		paths = append(paths, []Point{clip(src, center(dst)), clip(dst, center(src))})
+	}
+	return paths
+}
+
+func (d *Diagram) renderShape(w *bufio.Writer, el Element, b Bounds, color string, highlighted bool) {
+	strokeWidth := 1.5
+	fill := "#ffffff"
+	if highlighted {
+		strokeWidth = 3
+		fill = color + "22"
+	}
+	cx, cy := b.X+b.Width/2, b.Y+b.Height/2
+	style := fmt.Sprintf(`fill="%s" stroke="%s" stroke-width="%.1f"`, fill, color, strokeWidth)
+	switch {
+	case strings.HasSuffix(el.Type, "Event"):
+		if el.Type == "endEvent" {
+			style = fmt.Sprintf(`fill="%s" stroke="%s" stroke-width="%.1f"`, fill, color, strokeWidth+2)
+		}
+		fmt.Fprintf(w, `<circle cx="%.1f" cy="%.1f" r="%.1f" %s/>`, cx, cy, b.Width/2, style)
+		writeLabel(w, el.Name, cx, b.Y+b.Height+14)
+	case strings.HasSuffix(el.Type, "Gateway"):
+		fmt.Fprintf(w, `<polygon points="%.1f,%.1f %.1f,%.1f %.1f,%.1f %.1f,%.1f" %s/>`,
+			cx, b.Y, b.X+b.Width, cy, cx, b.Y+b.Height, b.X, cy, style)
+		writeLabel(w, el.Name, cx, b.Y-6)
+	default:
+		fmt.Fprintf(w, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" rx="10" %s/>`, b.X, b.Y, b.Width, b.Height, style)
+		writeLabel(w, el.Name, cx, cy+4)
+	}
+}
+
+func writeLabel(w *bufio.Writer, text string, x, y float64) {
+	if text == "" {
+		return
+	}
+	fmt.Fprintf(w, `<text x="%.1f" y="%.1f" text-anchor="middle" fill="%s">`, x, y, colorDefault)
+	_ = xml.EscapeText(w, []byte(text))
+	w.WriteString(`</text>`)
+}
+
+func center(b Bounds) Point {
+	return Point{X: b.X + b.Width/2, Y: b.Y + b.Height/2}
+}
+
+// clip moves the centre of b towards target until it leaves the bounding box.
+func clip(b Bounds, target Point) Point {
+	c := center(b)
+	dx, dy := target.X-c.X, target.Y-c.Y
+	if dx == 0 && dy == 0 {
+		return c
+	}
+	scale := math.Inf(1)
+	if dx != 0 {
+		scale = math.Min(scale, (b.Width/2)/math.Abs(dx))
+	}
+	if dy != 0 {
+		scale = math.Min(scale, (b.Height/2)/math.Abs(dy))
+	}
+	return Point{X: c.X + dx*scale, Y: c.Y + dy*scale}
+}
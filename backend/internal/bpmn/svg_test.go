@@ -0,0 +1,72 @@
+package bpmn
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderSVG(t *testing.T) {
+	d, err := Parse([]byte(sampleBPMN))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	tests := []struct {
+		name      string
+		highlight Highlight
+		want      []string
+		notWant   []string
+	}{
+		{
+			name: "plain",
+			want: []string{
+				`<circle cx="118.0" cy="118.0" r="18.0" fill="#ffffff" stroke="` + colorDefault + `" stroke-width="1.5"/>`,
+				`<rect x="200.0" y="78.0" width="100.0" height="80.0" rx="10" fill="#ffffff" stroke="` + colorDefault + `" stroke-width="1.5"/>`,
+				`<circle cx="538.0" cy="118.0" r="18.0" fill="#ffffff" stroke="` + colorDefault + `" stroke-width="3.5"/>`,
+				`>Manager &amp; Lead Approval</text>`,
+				`<polyline points="136.0,118.0 200.0,118.0"`,
+				// Flow_3 has no BPMNEdge and is drawn between the shape borders.
+				`<polyline points="460.0,118.0 520.0,118.0"`,
+			},
+			notWant: []string{"stroke-width=\"3.0\""},
+		},
+		{
+			name:      "active and completed",
+			highlight: Highlight{Active: []string{"Approve"}, Completed: []string{"Start"}},
+			want: []string{
+				`<circle cx="118.0" cy="118.0" r="18.0" fill="` + colorCompleted + `22" stroke="` + colorCompleted + `" stroke-width="3.0"/>`,
+				`<rect x="200.0" y="78.0" width="100.0" height="80.0" rx="10" fill="` + colorActive + `22" stroke="` + colorActive + `" stroke-width="3.0"/>`,
+			},
+			notWant: []string{`stroke="` + colorIncident + `"`},
+		},
+		{
+			name:      "incident wins over active",
+			highlight: Highlight{Active: []string{"Provision"}, Completed: []string{"Provision"}, Incidents: []string{"Provision"}},
+			want: []string{
+				`<rect x="360.0" y="78.0" width="100.0" height="80.0" rx="10" fill="` + colorIncident + `22" stroke="` + colorIncident + `" stroke-width="3.0"/>`,
+			},
+			notWant: []string{`stroke="` + colorActive + `"`, `stroke="` + colorCompleted + `"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			if err := d.RenderSVG(&out, tt.highlight); err != nil {
+				t.Fatalf("render: %v", err)
+			}
+			svg := out.String()
+			if !strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="80 58 496 140"`) || !strings.HasSuffix(svg, "</svg>") {
+				t.Errorf("svg envelope = %.120s…", svg)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(svg, want) {
+					t.Errorf("svg lacks %s", want)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(svg, notWant) {
+					t.Errorf("svg contains %s", notWant)
+				}
+			}
+		})
+	}
+}
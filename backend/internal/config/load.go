@@ -0,0 +1,297 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv names the config file when no -config flag is given.
+const FileEnv = "PFLOW_CONFIG"
+
+// fileSuffix marks an environment variable holding the path of a file with the value, as used for
+// Docker and Kubernetes secrets: DB_PASSWORD_FILE=/run/secrets/db-password.
+const fileSuffix = "_FILE"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// field is a single setting of Config.
+type field struct {
+	path   string
+	env    string
+	secret string
+	value  reflect.Value
+}
+
+// Load builds the configuration for a command from its arguments (without the program name). A
+// config file given by -config or PFLOW_CONFIG overrides the defaults, environment variables
+// override the file and flags override both. Every invalid value is reported in the returned
+// error, which also covers Validate; the configuration is returned regardless so it can be
+// inspected. flag.ErrHelp is returned when -h was given.
+func Load(name string, args []string) (Config, error) {
+	cfg := Default()
+	fields := fieldsOf(reflect.ValueOf(&cfg).Elem(), "")
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	file := fs.String("config", os.Getenv(FileEnv), "YAML or TOML config `file` (env "+FileEnv+")")
+	var flagged []setting
+	for _, f := range fields {
+		fs.Var(&flagValue{field: f, set: func(path, raw string) {
+			flagged = append(flagged, setting{path: path, raw: raw})
+		}}, f.path, f.usage())
+	}
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+	if fs.NArg() > 0 {
+		return cfg, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	var errs []error
+	if *file != "" {
+		if err := applyFile(fields, *file); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, f := range fields {
+		raw, ok, err := lookupEnv(f.env)
+		if err == nil && ok && (raw != "" || f.value.Kind() == reflect.String) {
+			err = setString(f.value, raw)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("env %s: %w", f.env, err))
+		}
+	}
+	byPath := indexFields(fields)
+	for _, s := range flagged {
+		if err := setString(byPath[s.path].value, s.raw); err != nil {
+			errs = append(errs, fmt.Errorf("flag -%s: %w", s.path, err))
+		}
+	}
+	if err := cfg.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	return cfg, errors.Join(errs...)
+}
+
+func fieldsOf(v reflect.Value, prefix string) []field {
+	var out []field
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		path := sf.Tag.Get("config")
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+		if sf.Type.Kind() == reflect.Struct {
+			out = append(out, fieldsOf(v.Field(i), path)...)
+			continue
+		}
+		out = append(out, field{path: path, env: sf.Tag.Get("env"), secret: sf.Tag.Get("secret"), value: v.Field(i)})
+	}
+	return out
+}
+
+func indexFields(fields []field) map[string]field {
+	out := make(map[string]field, len(fields))
+	for _, f := range fields {
+		out[f.path] = f
+	}
+	return out
+}
+
+func (f field) usage() string {
+	return fmt.Sprintf("env %s, default %q", f.env, formatValue(f.value))
+}
+
+// lookupEnv reads key, or the file named by key_FILE. Setting both is an error.
+func lookupEnv(key string) (string, bool, error) {
+	v, ok := os.LookupEnv(key)
+	path, fromFile := os.LookupEnv(key + fileSuffix)
+	switch {
+	case !fromFile || path == "":
+		return v, ok, nil
+	case ok:
+		return "", false, fmt.Errorf("set either %s or %s%s, not both", key, key, fileSuffix)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("read %s%s: %w", key, fileSuffix, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), true, nil
+}
+
+// applyFile decodes a YAML or TOML file, chosen by extension, onto the fields. Unknown keys are
+// rejected so typos do not silently fall back to defaults.
+func applyFile(fields []field, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+	doc := map[string]any{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &doc)
+	case ".toml":
+		err = toml.Unmarshal(data, &doc)
+	default:
+		return fmt.Errorf("config file %s: unsupported format %q, use .yaml, .yml or .toml", path, ext)
+	}
+	if err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	var errs []error
+	applyTree(indexFields(fields), doc, "", &errs)
+	for i, err := range errs {
+		errs[i] = fmt.Errorf("config file %s: %w", path, err)
+	}
+	return errors.Join(errs...)
+}
+
+func applyTree(fields map[string]field, doc map[string]any, prefix string, errs *[]error) {
+	for key, raw := range doc {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		f, ok := fields[path]
+		if !ok {
+			if section, isMap := raw.(map[string]any); isMap && isSection(fields, path) {
+				applyTree(fields, section, path, errs)
+			} else {
+				*errs = append(*errs, fmt.Errorf("%s: unknown setting", path))
+			}
+			continue
+		}
+		if err := setAny(f.value, raw); err != nil {
+			*errs = append(*errs, fmt.Errorf("%s: %w", path, err))
+		}
+	}
+}
+
+func isSection(fields map[string]field, path string) bool {
+	for p := range fields {
+		if strings.HasPrefix(p, path+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// setAny assigns a decoded file value, which may already be typed, a list or a table.
+func setAny(v reflect.Value, raw any) error {
+	switch raw := raw.(type) {
+	case nil:
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	case string:
+		return setString(v, raw)
+	case []any:
+		if v.Kind() != reflect.Slice {
+			return errors.New("unexpected list")
+		}
+		items := make([]string, len(raw))
+		for i, item := range raw {
+			items[i] = fmt.Sprint(item)
+		}
+		v.Set(reflect.ValueOf(items))
+		return nil
+	case map[string]any:
+		if v.Kind() != reflect.Map {
+			return errors.New("unexpected table")
+		}
+		pairs := make(map[string]string, len(raw))
+		for k, item := range raw {
+			pairs[k] = fmt.Sprint(item)
+		}
+		v.Set(reflect.ValueOf(pairs))
+		return nil
+	}
+	if v.Type() == durationType {
+		return errors.New(`expected a duration such as "30s"`)
+	}
+	return setString(v, fmt.Sprint(raw))
+}
+
+// setString parses a flag or environment value. Lists are comma separated and maps are comma
+// separated key=value pairs.
+func setString(v reflect.Value, raw string) error {
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(raw)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Int || v.Kind() == reflect.Int64:
+		i, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || v.OverflowInt(i) {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		v.SetInt(i)
+	case v.Kind() == reflect.Slice:
+		v.Set(reflect.ValueOf(splitList(raw)))
+	case v.Kind() == reflect.Map:
+		pairs := map[string]string{}
+		for _, item := range splitList(raw) {
+			k, val, ok := strings.Cut(item, "=")
+			if !ok {
+				return fmt.Errorf("entry %q is not key=value", item)
+			}
+			pairs[strings.TrimSpace(k)] = strings.TrimSpace(val)
+		}
+		v.Set(reflect.ValueOf(pairs))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+func splitList(raw string) []string {
+	var out []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+type setting struct {
+	path, raw string
+}
+
+// flagValue records a flag for parsing once the file and environment have been applied, so that
+// flags win and all parse errors are reported together.
+type flagValue struct {
+	field
+	set func(path, raw string)
+}
+
+func (f *flagValue) String() string { return "" }
+
+func (f *flagValue) Set(raw string) error {
+	f.set(f.path, raw)
+	return nil
+}
+
+func (f *flagValue) IsBoolFlag() bool {
+	return f.value.Kind() == reflect.Bool
+}
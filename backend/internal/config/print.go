@@ -0,0 +1,121 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "REDACTED"
+
+var dsnPassword = regexp.MustCompile(`(password=)\S+`)
+
+// Print writes the configuration as YAML, in the layout accepted by Load, with secrets redacted.
+// Each setting is annotated with its environment variable.
+func (c Config) Print(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(sectionNode(reflect.ValueOf(c))); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func sectionNode(v reflect.Value) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		key := &yaml.Node{Kind: yaml.ScalarNode, Value: sf.Tag.Get("config")}
+		var value *yaml.Node
+		if sf.Type.Kind() == reflect.Struct {
+			value = sectionNode(v.Field(i))
+		} else {
+			value = valueNode(v.Field(i), sf.Tag.Get("secret"))
+			if value.Kind == yaml.MappingNode {
+				// Block mappings start on the next line, keep the comment next to the key.
+				key.LineComment = sf.Tag.Get("env")
+			} else {
+				value.LineComment = sf.Tag.Get("env")
+			}
+		}
+		node.Content = append(node.Content, key, value)
+	}
+	return node
+}
+
+func valueNode(v reflect.Value, secret string) *yaml.Node {
+	switch v.Kind() {
+	case reflect.Slice:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+		for i := 0; i < v.Len(); i++ {
+			node.Content = append(node.Content, scalar("!!str", v.Index(i).String()))
+		}
+		return node
+	case reflect.Map:
+		node := &yaml.Node{Kind: yaml.MappingNode}
+		keys := make([]string, 0, v.Len())
+		for _, k := range v.MapKeys() {
+			keys = append(keys, k.String())
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			node.Content = append(node.Content, scalar("!!str", k), scalar("!!str", v.MapIndex(reflect.ValueOf(k)).String()))
+		}
+		return node
+	case reflect.Bool:
+		return scalar("!!bool", formatValue(v))
+	case reflect.Int, reflect.Int64:
+		if v.Type() != durationType {
+			return scalar("!!int", formatValue(v))
+		}
+	}
+	return scalar("!!str", redact(formatValue(v), secret))
+}
+
+func scalar(tag, value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value}
+}
+
+// formatValue renders a setting the way it is written in flags and environment variables.
+func formatValue(v reflect.Value) string {
+	switch {
+	case v.Type() == durationType:
+		return time.Duration(v.Int()).String()
+	case v.Kind() == reflect.Slice:
+		return strings.Join(v.Interface().([]string), ",")
+	case v.Kind() == reflect.Map:
+		m := v.Interface().(map[string]string)
+		pairs := make([]string, 0, len(m))
+		for k, val := range m {
+			pairs = append(pairs, k+"="+val)
+		}
+		sort.Strings(pairs)
+		return strings.Join(pairs, ",")
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// redact hides secrets; URLs keep everything but their password.
+func redact(value, secret string) string {
+	switch {
+	case value == "" || secret == "":
+		return value
+	case secret == "url":
+		if u, err := url.Parse(value); err == nil && u.Scheme != "" {
+			if _, ok := u.User.Password(); ok {
+				u.User = url.UserPassword(u.User.Username(), redacted)
+			}
+			return u.String()
+		}
+		return dsnPassword.ReplaceAllString(value, "${1}"+redacted)
+	}
+	return redacted
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Validate checks the configuration and reports every problem found, each prefixed with the key
+// of the offending setting.
+func (c Config) Validate() error {
+	v := &validator{}
+
+	v.addr("http.port", c.HTTP.Port)
+	positive(v, "http.idempotencyTTL", c.HTTP.IdempotencyTTL)
+
+	v.required("db.url", c.DB.URL)
+	nonNegative(v, "db.maxOpenConns", c.DB.MaxOpenConns)
+	nonNegative(v, "db.maxIdleConns", c.DB.MaxIdleConns)
+	if c.DB.MaxOpenConns > 0 && c.DB.MaxIdleConns > c.DB.MaxOpenConns {
+		v.fail("db.maxIdleConns", "must not exceed db.maxOpenConns (%d)", c.DB.MaxOpenConns)
+	}
+	nonNegative(v, "db.connMaxLifetime", c.DB.ConnMaxLifetime)
+	nonNegative(v, "db.connMaxIdleTime", c.DB.ConnMaxIdleTime)
+
+	v.url("camunda.url", c.Camunda.URL, "http", "https")
+	v.required("camunda.processKey", c.Camunda.ProcessKey)
+	if c.Camunda.Password != "" && c.Camunda.Username == "" {
+		v.fail("camunda.password", "requires camunda.username")
+	}
+	if c.Camunda.Token != "" && c.Camunda.Username != "" {
+		v.fail("camunda.token", "cannot be combined with camunda.username, use either basic auth or a token")
+	}
+	positive(v, "camunda.timeout", c.Camunda.Timeout)
+	nonNegative(v, "camunda.maxRetries", c.Camunda.MaxRetries)
+	nonNegative(v, "camunda.retryBackoff", c.Camunda.RetryBackoff)
+	nonNegative(v, "camunda.breakerThreshold", c.Camunda.BreakerThreshold)
+	if c.Camunda.BreakerThreshold > 0 {
+		positive(v, "camunda.breakerCooldown", c.Camunda.BreakerCooldown)
+	}
+
+	v.url("mq.url", c.MQ.URL, "amqp", "amqps")
+	v.required("mq.ticketExchange", c.MQ.TicketExchange)
+	v.required("mq.ticketQueue", c.MQ.TicketQueue)
+	v.required("mq.commandQueue", c.MQ.CommandQueue)
+	positive(v, "mq.commandMaxAttempts", c.MQ.CommandMaxAttempts)
+	positive(v, "mq.commandRetryDelay", c.MQ.CommandRetryDelay)
+
+	positive(v, "worker.pollInterval", c.Worker.PollInterval)
+	positive(v, "worker.lockDuration", c.Worker.LockDuration)
+	v.addr("worker.httpPort", c.Worker.HTTPPort)
+	if len(c.Tenancy.Tenants) > 0 {
+		for _, id := range c.Worker.Tenants {
+			if !slices.Contains(c.Tenancy.Tenants, id) {
+				v.fail("worker.tenants", "%q is not listed in tenancy.tenants", id)
+			}
+		}
+	}
+
+	positive(v, "sla.checkInterval", c.SLA.CheckInterval)
+	positive(v, "sla.approval", c.SLA.Approval)
+	positive(v, "sla.resolution", c.SLA.Resolution)
+	nonNegative(v, "sla.warnBefore", c.SLA.WarnBefore)
+	nonNegative(v, "sla.escalateAfter", c.SLA.EscalateAfter)
+
+	switch c.Attachments.Store {
+	case "local":
+		v.required("attachments.dir", c.Attachments.Dir)
+	case "s3":
+		v.required("attachments.s3.bucket", c.Attachments.S3.Bucket)
+		v.required("attachments.s3.region", c.Attachments.S3.Region)
+		if c.Attachments.S3.Endpoint != "" {
+			v.url("attachments.s3.endpoint", c.Attachments.S3.Endpoint, "http", "https")
+		}
+	default:
+		v.fail("attachments.store", "must be local or s3, got %q", c.Attachments.Store)
+	}
+	positive(v, "attachments.maxSize", c.Attachments.MaxSize)
+
+	v.required("provision.workDir", c.Provision.WorkDir)
+	positive(v, "provision.timeout", c.Provision.Timeout)
+
+	positive(v, "statusSync.interval", c.StatusSync.Interval)
+	nonNegative(v, "statusSync.overlap", c.StatusSync.Overlap)
+
+	if c.Tenancy.Default != "" && len(c.Tenancy.Tenants) > 0 && !slices.Contains(c.Tenancy.Tenants, c.Tenancy.Default) {
+		v.fail("tenancy.default", "%q is not listed in tenancy.tenants", c.Tenancy.Default)
+	}
+
+	positive(v, "bulk.concurrency", c.Bulk.Concurrency)
+	nonNegative(v, "bulk.syncLimit", c.Bulk.SyncLimit)
+
+	positive(v, "shutdownTimeout", c.ShutdownTimeout)
+	return errors.Join(v.errs...)
+}
+
+type validator struct {
+	errs []error
+}
+
+func (v *validator) fail(key, format string, args ...any) {
+	v.errs = append(v.errs, fmt.Errorf("%s: "+format, append([]any{key}, args...)...))
+}
+
+func (v *validator) required(key, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.fail(key, "is required")
+	}
+}
+
+func (v *validator) addr(key, value string) {
+	if _, port, err := net.SplitHostPort(value); err != nil || port == "" {
+		v.fail(key, "must be an address such as :8080, got %q", value)
+	}
+}
+
+func (v *validator) url(key, value string, schemes ...string) {
+	u, err := url.Parse(value)
+	switch {
+	case value == "":
+		v.fail(key, "is required")
+	case err != nil:
+		// The parse error quotes the URL, which may carry credentials.
+		v.fail(key, "is not a valid URL")
+	case !slices.Contains(schemes, u.Scheme) || u.Host == "":
+		v.fail(key, "must be a %s URL", strings.Join(schemes, " or "))
+	}
+}
+
+type number interface {
+	~int | ~int64
+}
+
+func positive[T number](v *validator, key string, value T) {
+	if value <= 0 {
+		v.fail(key, "must be positive, got %v", value)
+	}
+}
+
+func nonNegative[T number](v *validator, key string, value T) {
+	if value < 0 {
+		v.fail(key, "must not be negative, got %v", value)
+	}
+}
@@ -2,16 +2,33 @@ package db
 
 import (
 	"log"
+	"strings"
+	"time"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
-// New creates a new GORM database connection using the provided DSN.
-func New(dsn string) (*gorm.DB, error) {
+// Pool sizes the connection pool of a Postgres database. Zero values keep the database/sql
+// defaults.
+type Pool struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+// New creates a new GORM database connection using the provided DSN. DSNs starting with
+// "sqlite:" open a SQLite database, e.g. "sqlite://data/pflow.db" or "sqlite::memory:";
+// anything else is handed to the Postgres driver.
+func New(dsn string, pool Pool) (*gorm.DB, error) {
 	gormLogger := logger.Default.LogMode(logger.Info)
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: gormLogger})
+	dialector, sqlite, err := dialectorFor(dsn)
+	if err != nil {
+		return nil, err
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: gormLogger})
 	if err != nil {
 		return nil, err
 	}
@@ -20,9 +37,29 @@ func New(dsn string) (*gorm.DB, error) {
 	if err != nil {
 		return nil, err
 	}
-	sqlDB.SetMaxOpenConns(20)
-	sqlDB.SetMaxIdleConns(5)
+	if sqlite {
+		// SQLite allows a single writer; one connection also keeps in-memory databases shared.
+		sqlDB.SetMaxOpenConns(1)
+	} else {
+		sqlDB.SetMaxOpenConns(pool.MaxOpenConns)
+		if pool.MaxIdleConns > 0 {
+			sqlDB.SetMaxIdleConns(pool.MaxIdleConns)
+		}
+		sqlDB.SetConnMaxLifetime(pool.ConnMaxLifetime)
+		sqlDB.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
+	}
 
 	log.Println("connected to database")
 	return db, nil
 }
+
+func dialectorFor(dsn string) (gorm.Dialector, bool, error) {
+	if !strings.HasPrefix(dsn, "sqlite:") {
+		return postgres.Open(dsn), false, nil
+	}
+	path := strings.TrimPrefix(strings.TrimPrefix(dsn, "sqlite:"), "//")
+	if path == "" || path == ":memory:" {
+		path = "file::memory:?cache=shared"
+	}
+	return openSQLite(path), true, nil
+}
@@ -0,0 +1,6 @@
+package db
+
+import "github.com/glebarez/sqlite"
+
+// openSQLite opens SQLite with a pure Go driver, so binaries built with CGO_ENABLED=0 support it.
+var openSQLite = sqlite.Open
@@ -0,0 +1,75 @@
+package directory
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// ErrUserNotFound is returned when a username is not known to the directory.
+var ErrUserNotFound = errors.New("user not found")
+
+// User is a person known to PFlow together with the attributes used for routing and access.
+type User struct {
+	Username    string   `json:"username"`
+	DisplayName string   `json:"displayName"`
+	Email       string   `json:"email"`
+	Department  string   `json:"department"`
+	Manager     string   `json:"manager"`
+	Groups      []string `json:"groups"`
+}
+
+// Directory resolves usernames to users.
+type Directory interface {
+	Lookup(ctx context.Context, username string) (*User, error)
+}
+
+// StaticDirectory serves users from an in-memory list, typically loaded from a JSON file.
+type StaticDirectory struct {
+	users map[string]User
+}
+
+// NewStaticDirectory builds a directory from the given users.
+func NewStaticDirectory(users []User) *StaticDirectory {
+	d := &StaticDirectory{users: make(map[string]User, len(users))}
+	for _, u := range users {
+		d.users[u.Username] = u
+	}
+	return d
+}
+
+// LoadFile reads a JSON array of users.
+func LoadFile(path string) (*StaticDirectory, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "read user directory")
+	}
+	var users []User
+	if err := json.Unmarshal(raw, &users); err != nil {
+		return nil, errors.Wrap(err, "parse user directory")
+	}
+	return NewStaticDirectory(users), nil
+}
+
+// Lookup returns the user or ErrUserNotFound.
+func (d *StaticDirectory) Lookup(_ context.Context, username string) (*User, error) {
+	u, ok := d.users[username]
+	if !ok {
+		return nil, errors.Wrap(ErrUserNotFound, username)
+	}
+	return &u, nil
+}
+
+// Unrestricted accepts every username as a user without further attributes. It is used when no
+// directory is configured so local setups keep working.
+type Unrestricted struct{}
+
+// Lookup returns a bare user for any non-empty username.
+func (Unrestricted) Lookup(_ context.Context, username string) (*User, error) {
+	if username == "" {
+		return nil, ErrUserNotFound
+	}
+	return &User{Username: username}, nil
+}
@@ -0,0 +1,162 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/example/pflow/backend/internal/models"
+	"github.com/example/pflow/backend/internal/service"
+)
+
+func (s *Server) assignTicket(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	user := currentUser(c)
+	if user == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "request carries no user"})
+		return
+	}
+	var payload struct {
+		Assignee string `json:"assignee" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ticket, err := s.assignments.Assign(c.Request.Context(), id, payload.Assignee, user)
+	respondAssignment(c, ticket, err)
+}
+
+func (s *Server) claimTicket(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	user := currentUser(c)
+	if user == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "request carries no user"})
+		return
+	}
+	ticket, err := s.assignments.Claim(c.Request.Context(), id, user)
+	respondAssignment(c, ticket, err)
+}
+
+func (s *Server) unclaimTicket(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	user := currentUser(c)
+	if user == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "request carries no user"})
+		return
+	}
+	ticket, err := s.assignments.Unclaim(c.Request.Context(), id, user)
+	respondAssignment(c, ticket, err)
+}
+
+func (s *Server) delegateTicket(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	user := currentUser(c)
+	if user == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "request carries no user"})
+		return
+	}
+	var payload struct {
+		Delegate string `json:"delegate" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ticket, err := s.assignments.Delegate(c.Request.Context(), id, payload.Delegate, user)
+	respondAssignment(c, ticket, err)
+}
+
+func (s *Server) resolveDelegation(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	user := currentUser(c)
+	if user == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "request carries no user"})
+		return
+	}
+	ticket, err := s.assignments.Resolve(c.Request.Context(), id, user)
+	respondAssignment(c, ticket, err)
+}
+
+func respondAssignment(c *gin.Context, ticket *models.Ticket, err error) {
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, ticket)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidTicket):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrNoOpenTask), errors.Is(err, service.ErrTaskConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	}
+}
+
+func (s *Server) listOutOfOffice(c *gin.Context) {
+	rules, err := s.assignments.ListOutOfOffice(c.Request.Context(), c.Query("username"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rules)
+}
+
+func (s *Server) saveOutOfOffice(c *gin.Context) {
+	var rule models.OutOfOfficeRule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	rule.ID = 0
+	if raw := c.Param("ruleId"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+			return
+		}
+		rule.ID = uint(id)
+	}
+	if err := s.assignments.SaveOutOfOffice(c.Request.Context(), &rule); err != nil {
+		c.JSON(ticketTypeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rule)
+}
+
+func (s *Server) deleteOutOfOffice(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("ruleId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	if err := s.assignments.DeleteOutOfOffice(c.Request.Context(), uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
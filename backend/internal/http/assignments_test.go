@@ -0,0 +1,51 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestTaskActionsRequireUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := &Server{}
+	router := gin.New()
+	router.POST("/tickets/:id/assign", s.assignTicket)
+	router.POST("/tickets/:id/claim", s.claimTicket)
+	router.DELETE("/tickets/:id/claim", s.unclaimTicket)
+	router.POST("/tickets/:id/delegate", s.delegateTicket)
+	router.POST("/tickets/:id/delegate/resolve", s.resolveDelegation)
+	router.POST("/tickets/:id/decision", s.decision)
+	router.POST("/tickets/:id/cancel", s.cancelTicket)
+
+	// An identity in the body or query string is not the caller's and must not be trusted.
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+	}{
+		{"assign", http.MethodPost, "/assign", `{"assignee":"bob","actor":"alice"}`},
+		{"claim", http.MethodPost, "/claim", `{"user":"alice"}`},
+		{"unclaim", http.MethodDelete, "/claim?actor=alice", ""},
+		{"delegate", http.MethodPost, "/delegate", `{"delegate":"bob","actor":"alice"}`},
+		{"resolve", http.MethodPost, "/delegate/resolve?actor=alice", ""},
+		{"decision", http.MethodPost, "/decision", `{"approved":true,"decidedBy":"alice"}`},
+		{"cancel", http.MethodPost, "/cancel?actor=alice", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/tickets/"+uuid.NewString()+tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != http.StatusUnauthorized {
+				t.Errorf("status = %d, want 401: %s", rec.Code, rec.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/example/pflow/backend/internal/service"
+	"github.com/example/pflow/backend/internal/storage"
+)
+
+func (s *Server) listAttachments(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	attachments, err := s.attachments.List(c.Request.Context(), id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, attachments)
+}
+
+// multipartOverhead is allowed on top of the attachment size limit for the multipart boundaries,
+// part headers and the other form fields.
+const multipartOverhead = 64 << 10
+
+func (s *Server) uploadAttachment(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	if max := s.attachments.MaxSize(); max > 0 {
+		limit := max + multipartOverhead
+		if c.Request.ContentLength > limit {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file exceeds " + strconv.FormatInt(max, 10) + " bytes"})
+			return
+		}
+		// Chunked bodies carry no length, so the limit is enforced while the form is read as well.
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+	}
+	file, header, err := c.Request.FormFile("file")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file exceeds " + strconv.FormatInt(s.attachments.MaxSize(), 10) + " bytes"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "multipart field \"file\" is required"})
+		return
+	}
+	defer file.Close()
+
+	attachment, err := s.attachments.Upload(c.Request.Context(), id, service.AttachmentUpload{
+		FileName:    header.Filename,
+		ContentType: header.Header.Get("Content-Type"),
+		Size:        header.Size,
+		UploadedBy:  c.PostForm("uploadedBy"),
+		Body:        file,
+	})
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrAttachmentRejected) {
+			status = http.StatusUnprocessableEntity
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, attachment)
+}
+
+func (s *Server) downloadAttachment(c *gin.Context) {
+	id, attachmentID, ok := parseAttachmentIDs(c)
+	if !ok {
+		return
+	}
+	attachment, body, err := s.attachments.Open(c.Request.Context(), id, attachmentID)
+	if err != nil {
+		status := http.StatusBadGateway
+		if errors.Is(err, storage.ErrNotFound) || errors.Is(err, gorm.ErrRecordNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	defer body.Close()
+
+	c.Header("Content-Type", attachment.ContentType)
+	c.Header("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}))
+	c.Header("ETag", `"`+attachment.SHA256+`"`)
+	c.Header("X-Checksum-SHA256", attachment.SHA256)
+	c.Status(http.StatusOK)
+	_, _ = io.Copy(c.Writer, body)
+}
+
+func (s *Server) deleteAttachment(c *gin.Context) {
+	id, attachmentID, ok := parseAttachmentIDs(c)
+	if !ok {
+		return
+	}
+	if err := s.attachments.Delete(c.Request.Context(), id, attachmentID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func parseAttachmentIDs(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	attachmentID, err := uuid.Parse(c.Param("attachmentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid attachment id"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return id, attachmentID, true
+}
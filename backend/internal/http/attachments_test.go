@@ -0,0 +1,55 @@
+package http
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/example/pflow/backend/internal/service"
+)
+
+func TestUploadAttachmentRejectsOversizedBodies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := &Server{attachments: service.NewAttachmentService(nil, nil, nil, nil, service.AttachmentLimits{MaxSize: 1024})}
+	router := gin.New()
+	router.POST("/tickets/:id/attachments", s.uploadAttachment)
+
+	var form bytes.Buffer
+	w := multipart.NewWriter(&form)
+	part, _ := w.CreateFormFile("file", "big.bin")
+	part.Write(bytes.Repeat([]byte("x"), 1024+multipartOverhead))
+	w.Close()
+
+	tests := []struct {
+		name    string
+		chunked bool
+	}{
+		{"declared length", false},
+		{"chunked", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body io.Reader = bytes.NewReader(form.Bytes())
+			if tt.chunked {
+				// Hide the length so the limit has to be enforced while reading.
+				body = io.MultiReader(body)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/tickets/"+uuid.NewString()+"/attachments", body)
+			req.Header.Set("Content-Type", w.FormDataContentType())
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != http.StatusRequestEntityTooLarge {
+				t.Errorf("status = %d, want 413: %s", rec.Code, rec.Body)
+			}
+		})
+	}
+}
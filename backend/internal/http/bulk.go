@@ -0,0 +1,74 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/example/pflow/backend/internal/models"
+	"github.com/example/pflow/backend/internal/repository"
+	"github.com/example/pflow/backend/internal/service"
+)
+
+func (s *Server) bulkTickets(c *gin.Context) {
+	var payload struct {
+		Action    string      `json:"action" binding:"required"`
+		TicketIDs []uuid.UUID `json:"ticketIds"`
+		Filter    struct {
+			Statuses  []models.TicketStatus `json:"statuses"`
+			Type      string                `json:"type"`
+			Requester string                `json:"requester"`
+			Assignee  string                `json:"assignee"`
+			Limit     int                   `json:"limit"`
+		} `json:"filter"`
+		Actor    string `json:"actor"`
+		Comment  string `json:"comment"`
+		Assignee string `json:"assignee"`
+		Async    bool   `json:"async"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	job, async, err := s.bulk.Run(c.Request.Context(), service.BulkRequest{
+		Action: payload.Action,
+		IDs:    payload.TicketIDs,
+		Filter: repository.TicketFilter{
+			Statuses:  payload.Filter.Statuses,
+			Type:      payload.Filter.Type,
+			Requester: payload.Filter.Requester,
+			Assignee:  payload.Filter.Assignee,
+			Limit:     payload.Filter.Limit,
+		},
+		Actor:    payload.Actor,
+		Comment:  payload.Comment,
+		Assignee: payload.Assignee,
+		Async:    payload.Async,
+	})
+	if err != nil {
+		c.JSON(ticketTypeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if async {
+		c.Header("Location", "/api/tickets/bulk/"+job.ID.String())
+		c.JSON(http.StatusAccepted, job)
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+func (s *Server) bulkJob(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("jobId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	job, err := s.bulk.Job(c.Request.Context(), id)
+	if errors.Is(err, service.ErrBulkJobNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
@@ -0,0 +1,144 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/example/pflow/backend/internal/models"
+	"github.com/example/pflow/backend/internal/service"
+)
+
+func (s *Server) listComments(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	// Internal notes are for agents; clients opt in to them explicitly.
+	includeInternal := c.Query("includeInternal") == "true"
+	comments, err := s.comments.List(c.Request.Context(), id, includeInternal)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, comments)
+}
+
+func (s *Server) addComment(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	author := currentUser(c)
+	if author == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "request carries no user"})
+		return
+	}
+	var payload struct {
+		Body       string                   `json:"body" binding:"required"`
+		Visibility models.CommentVisibility `json:"visibility"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	comment := &models.Comment{Author: author, Body: payload.Body, Visibility: payload.Visibility}
+	if err := s.comments.Add(c.Request.Context(), id, comment); err != nil {
+		commentError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, comment)
+}
+
+func (s *Server) editComment(c *gin.Context) {
+	id, commentID, ok := parseCommentIDs(c)
+	if !ok {
+		return
+	}
+	editor := currentUser(c)
+	if editor == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "request carries no user"})
+		return
+	}
+	var payload struct {
+		Body string `json:"body" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	comment, err := s.comments.Edit(c.Request.Context(), id, commentID, editor, payload.Body)
+	if err != nil {
+		commentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, comment)
+}
+
+func (s *Server) deleteComment(c *gin.Context) {
+	id, commentID, ok := parseCommentIDs(c)
+	if !ok {
+		return
+	}
+	actor := currentUser(c)
+	if actor == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "request carries no user"})
+		return
+	}
+	if err := s.comments.Delete(c.Request.Context(), id, commentID, actor); err != nil {
+		commentError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (s *Server) commentHistory(c *gin.Context) {
+	id, commentID, ok := parseCommentIDs(c)
+	if !ok {
+		return
+	}
+	revisions, err := s.comments.History(c.Request.Context(), id, commentID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, revisions)
+}
+
+// commentError answers a failed comment change: unknown tickets and comments are 404, changes by
+// anyone but the author 403.
+func commentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrNotCommentAuthor):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidTicket):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func parseCommentIDs(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	commentID, err := uuid.Parse(c.Param("commentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid comment id"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return id, commentID, true
+}
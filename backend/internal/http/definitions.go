@@ -0,0 +1,128 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/example/pflow/backend/internal/models"
+	"github.com/example/pflow/backend/internal/repository"
+	"github.com/example/pflow/backend/internal/service"
+	"github.com/example/pflow/backend/internal/workflow"
+)
+
+const maxBPMNUploadSize = 5 << 20
+
+func (s *Server) listProcessDefinitions(c *gin.Context) {
+	defs, err := s.definitions.ListDefinitions(c.Request.Context(), c.Query("key"))
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, defs)
+}
+
+func (s *Server) deployProcess(c *gin.Context) {
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "multipart field \"file\" is required"})
+		return
+	}
+	defer file.Close()
+	if header.Size > maxBPMNUploadSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "bpmn file too large"})
+		return
+	}
+	bpmn, err := io.ReadAll(io.LimitReader(file, maxBPMNUploadSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	name := strings.TrimSuffix(filepath.Base(header.Filename), filepath.Ext(header.Filename))
+	opts := workflow.DeployOptions{
+		Name:                     c.PostForm("name"),
+		Source:                   "pflow-admin",
+		EnableDuplicateFiltering: c.DefaultPostForm("enableDuplicateFiltering", "true") == "true",
+		DeployChangedOnly:        c.PostForm("deployChangedOnly") == "true",
+	}
+	deployment, err := s.definitions.Deploy(c.Request.Context(), name, bpmn, opts)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, deployment)
+}
+
+func (s *Server) listProcessPins(c *gin.Context) {
+	pins, err := s.definitions.ListPins(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, pins)
+}
+
+func (s *Server) pinProcessVersion(c *gin.Context) {
+	var payload struct {
+		Version int `json:"version" binding:"required,min=1"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	pin, err := s.definitions.Pin(c.Request.Context(), c.Param("key"), payload.Version)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, pin)
+}
+
+func (s *Server) unpinProcessVersion(c *gin.Context) {
+	if err := s.definitions.Unpin(c.Request.Context(), c.Param("key")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (s *Server) migrateTickets(c *gin.Context) {
+	var payload struct {
+		ProcessKey          string                `json:"processKey"`
+		TargetVersion       int                   `json:"targetVersion"`
+		TicketIDs           []uuid.UUID           `json:"ticketIds"`
+		Statuses            []models.TicketStatus `json:"statuses"`
+		SourceDefinitionID  string                `json:"sourceDefinitionId"`
+		Async               bool                  `json:"async"`
+		DryRun              bool                  `json:"dryRun"`
+		SkipCustomListeners bool                  `json:"skipCustomListeners"`
+		SkipIoMappings      bool                  `json:"skipIoMappings"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	report, err := s.migrations.Migrate(c.Request.Context(), service.MigrationRequest{
+		ProcessKey:    payload.ProcessKey,
+		TargetVersion: payload.TargetVersion,
+		Filter: repository.TicketFilter{
+			IDs:                 payload.TicketIDs,
+			Statuses:            payload.Statuses,
+			ProcessDefinitionID: payload.SourceDefinitionID,
+		},
+		Async:               payload.Async,
+		DryRun:              payload.DryRun,
+		SkipCustomListeners: payload.SkipCustomListeners,
+		SkipIoMappings:      payload.SkipIoMappings,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
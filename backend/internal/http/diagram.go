@@ -0,0 +1,49 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/example/pflow/backend/internal/bpmn"
+)
+
+// ticketDiagram returns the ticket's BPMN model and markers as JSON, or a highlighted SVG when
+// requested with ?format=svg or an Accept header of image/svg+xml.
+func (s *Server) ticketDiagram(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	diagram, err := s.workflow.TicketDiagram(c.Request.Context(), id)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	if c.Query("format") != "svg" && !strings.Contains(c.GetHeader("Accept"), "image/svg+xml") {
+		c.JSON(http.StatusOK, diagram)
+		return
+	}
+
+	model, err := bpmn.Parse([]byte(diagram.BPMNXML))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Content-Type", "image/svg+xml")
+	c.Status(http.StatusOK)
+	_ = model.RenderSVG(c.Writer, bpmn.Highlight{
+		Active:    diagram.ActiveActivityIDs,
+		Completed: diagram.CompletedActivityIDs(),
+		Incidents: diagram.IncidentActivityIDs(),
+	})
+}
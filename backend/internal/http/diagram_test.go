@@ -0,0 +1,26 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/example/pflow/backend/internal/repository"
+	"github.com/example/pflow/backend/internal/service"
+)
+
+func TestTicketDiagramUnknownTicket(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := &Server{workflow: service.NewWorkflowService(repository.NewMemoryTicketStore(), nil, nil, nil, nil, nil, nil, nil, nil)}
+	router := gin.New()
+	router.GET("/tickets/:id/diagram", s.ticketDiagram)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tickets/"+uuid.NewString()+"/diagram", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404: %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,54 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/example/pflow/backend/internal/directory"
+	"github.com/example/pflow/backend/internal/service"
+)
+
+// userClaims are the bearer token claims naming the user, in order of preference.
+var userClaims = []string{"preferred_username", "sub"}
+
+// currentUser returns the user the request acts for: the user named by the bearer token, or the
+// X-User header forwarded by the gateway for opaque tokens. A header never overrides the token.
+func currentUser(c *gin.Context) string {
+	authorization := c.GetHeader("Authorization")
+	for _, claim := range userClaims {
+		if user := tokenClaim(authorization, claim); user != "" {
+			return user
+		}
+	}
+	return c.GetHeader("X-User")
+}
+
+func (s *Server) myTasks(c *gin.Context) {
+	user := currentUser(c)
+	if user == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "request carries no user"})
+		return
+	}
+	offset, _ := strconv.Atoi(c.Query("offset"))
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	page, err := s.inbox.Tasks(c.Request.Context(), service.InboxQuery{
+		User:      user,
+		SortBy:    c.Query("sort"),
+		SortOrder: c.Query("order"),
+		Offset:    offset,
+		Limit:     limit,
+	})
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, page)
+	case errors.Is(err, service.ErrInvalidTicket):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, directory.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	}
+}
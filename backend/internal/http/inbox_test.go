@@ -0,0 +1,44 @@
+package http
+
+import (
+	"encoding/base64"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func bearer(claims string) string {
+	return "Bearer e30." + base64.RawURLEncoding.EncodeToString([]byte(claims)) + ".sig"
+}
+
+func TestCurrentUser(t *testing.T) {
+	tests := []struct {
+		name          string
+		authorization string
+		header        string
+		query         string
+		want          string
+	}{
+		{"preferred username", bearer(`{"preferred_username":"alice","sub":"42"}`), "", "", "alice"},
+		{"subject", bearer(`{"sub":"42"}`), "", "", "42"},
+		{"token wins over header", bearer(`{"sub":"alice"}`), "mallory", "", "alice"},
+		{"opaque token uses header", "Bearer opaque", "alice", "", "alice"},
+		{"query is ignored", "", "", "mallory", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("GET", "/api/me/tasks?user="+tt.query, nil)
+			if tt.authorization != "" {
+				c.Request.Header.Set("Authorization", tt.authorization)
+			}
+			if tt.header != "" {
+				c.Request.Header.Set("X-User", tt.header)
+			}
+			if got := currentUser(c); got != tt.want {
+				t.Errorf("currentUser = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
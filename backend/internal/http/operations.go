@@ -0,0 +1,202 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/example/pflow/backend/internal/models"
+	"github.com/example/pflow/backend/internal/repository"
+	"github.com/example/pflow/backend/internal/tenant"
+	"github.com/example/pflow/backend/internal/workflow"
+)
+
+// ticketFilter selects tickets for operator endpoints, from the query string or a JSON body.
+type ticketFilter struct {
+	IDs           []string              `form:"id" json:"ticketIds"`
+	Statuses      []models.TicketStatus `form:"status" json:"statuses"`
+	Type          string                `form:"type" json:"type"`
+	Requester     string                `form:"requester" json:"requester"`
+	Assignee      string                `form:"assignee" json:"assignee"`
+	CreatedAfter  time.Time             `form:"createdAfter" time_format:"2006-01-02T15:04:05Z07:00" json:"createdAfter"`
+	CreatedBefore time.Time             `form:"createdBefore" time_format:"2006-01-02T15:04:05Z07:00" json:"createdBefore"`
+	Limit         int                   `form:"limit" json:"limit"`
+}
+
+func (f ticketFilter) repository() (repository.TicketFilter, error) {
+	out := repository.TicketFilter{
+		Statuses:      f.Statuses,
+		Type:          f.Type,
+		Requester:     f.Requester,
+		Assignee:      f.Assignee,
+		CreatedAfter:  f.CreatedAfter,
+		CreatedBefore: f.CreatedBefore,
+		Limit:         f.Limit,
+	}
+	for _, raw := range f.IDs {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return out, errors.New("invalid id " + raw)
+		}
+		out.IDs = append(out.IDs, id)
+	}
+	return out, nil
+}
+
+// queryFilter reads a ticketFilter from the query string, answering 400 when it is invalid.
+func queryFilter(c *gin.Context) (repository.TicketFilter, bool) {
+	var f ticketFilter
+	if err := c.ShouldBindQuery(&f); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return repository.TicketFilter{}, false
+	}
+	filter, err := f.repository()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return filter, false
+	}
+	return filter, true
+}
+
+func (s *Server) findTickets(c *gin.Context) {
+	filter, ok := queryFilter(c)
+	if !ok {
+		return
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = 100
+	}
+	tickets, err := s.tickets.Find(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, tickets)
+}
+
+func (s *Server) forceStatus(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	var payload struct {
+		Status models.TicketStatus `json:"status" binding:"required"`
+		Reason string              `json:"reason" binding:"required"`
+		Actor  string              `json:"actor"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if payload.Actor == "" {
+		payload.Actor = currentUser(c)
+	}
+	ticket, err := s.workflow.ForceStatus(c.Request.Context(), id, payload.Status, payload.Reason, payload.Actor)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, ticket)
+}
+
+func (s *Server) resubmitTicket(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	if err := s.workflow.ResubmitTicket(c.Request.Context(), id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ticket, err := s.tickets.FindByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, ticket)
+}
+
+func (s *Server) listIncidents(c *gin.Context) {
+	filter, ok := queryFilter(c)
+	if !ok {
+		return
+	}
+	incidents, err := s.incidents.List(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, incidents)
+}
+
+func (s *Server) retryIncident(c *gin.Context) {
+	payload := struct {
+		Retries int `json:"retries"`
+	}{Retries: 1}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	incident, err := s.incidents.Retry(c.Request.Context(), c.Param("incidentId"), payload.Retries)
+	if errors.Is(err, workflow.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, incident)
+}
+
+func (s *Server) replayEvents(c *gin.Context) {
+	var payload ticketFilter
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter, err := payload.repository()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	replayed, err := s.workflow.ReplayEvents(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(ticketTypeErrorStatus(err), gin.H{"error": err.Error(), "replayed": replayed})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"replayed": replayed})
+}
+
+func (s *Server) reconcile(c *gin.Context) {
+	var payload struct {
+		Since time.Time `json:"since"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	// The sync keeps a single watermark, so it always covers every tenant.
+	ctx := tenant.WithID(c.Request.Context(), "")
+	var changed int
+	var err error
+	if payload.Since.IsZero() {
+		changed, err = s.statusSync.Sync(ctx)
+	} else {
+		changed, err = s.statusSync.SyncSince(ctx, payload.Since)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "changed": changed})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"changed": changed})
+}
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -13,34 +14,145 @@ import (
 
 // Server wraps the gin engine and collaborators needed to handle API requests.
 type Server struct {
-	Engine   *gin.Engine
-	tickets  *repository.TicketRepository
-	workflow *service.WorkflowService
+	Engine      *gin.Engine
+	tickets     repository.TicketStore
+	workflow    *service.WorkflowService
+	definitions *service.DefinitionService
+	migrations  *service.MigrationService
+	sla         *service.SLAService
+	types       *service.TicketTypeService
+	comments    *service.CommentService
+	attachments *service.AttachmentService
+	routing     *service.RoutingService
+	assignments *service.AssignmentService
+	inbox       *service.InboxService
+	bulk        *service.BulkService
+	idempotency *service.IdempotencyService
+	incidents   *service.IncidentService
+	statusSync  *service.StatusSyncService
+	tenancy     TenantOptions
+}
+
+// Services are the collaborators the API handlers call.
+type Services struct {
+	Tickets     repository.TicketStore
+	Workflow    *service.WorkflowService
+	Definitions *service.DefinitionService
+	Migrations  *service.MigrationService
+	SLA         *service.SLAService
+	Types       *service.TicketTypeService
+	Comments    *service.CommentService
+	Attachments *service.AttachmentService
+	Routing     *service.RoutingService
+	Assignments *service.AssignmentService
+	Inbox       *service.InboxService
+	Bulk        *service.BulkService
+	Idempotency *service.IdempotencyService
+	Incidents   *service.IncidentService
+	StatusSync  *service.StatusSyncService
 }
 
 // NewServer constructs a new API server and registers routes.
-func NewServer(repo *repository.TicketRepository, workflow *service.WorkflowService) *Server {
+func NewServer(services Services, tenancy TenantOptions) *Server {
 	router := gin.Default()
-	srv := &Server{Engine: router, tickets: repo, workflow: workflow}
+	srv := &Server{
+		Engine:      router,
+		tickets:     services.Tickets,
+		workflow:    services.Workflow,
+		definitions: services.Definitions,
+		migrations:  services.Migrations,
+		sla:         services.SLA,
+		types:       services.Types,
+		comments:    services.Comments,
+		attachments: services.Attachments,
+		routing:     services.Routing,
+		assignments: services.Assignments,
+		inbox:       services.Inbox,
+		bulk:        services.Bulk,
+		idempotency: services.Idempotency,
+		incidents:   services.Incidents,
+		statusSync:  services.StatusSync,
+		tenancy:     tenancy,
+	}
 	srv.registerRoutes()
 	return srv
 }
 
 func (s *Server) registerRoutes() {
 	api := s.Engine.Group("/api")
+	api.Use(s.resolveTenant(), s.idempotent())
 	api.POST("/tickets", s.createTicket)
 	api.GET("/tickets", s.listTickets)
+	api.POST("/tickets/bulk", s.bulkTickets)
+	api.GET("/tickets/bulk/:jobId", s.bulkJob)
 	api.GET("/tickets/:id", s.getTicket)
 	api.POST("/tickets/:id/submit", s.submitTicket)
 	api.POST("/tickets/:id/decision", s.decision)
+	api.POST("/tickets/:id/cancel", s.cancelTicket)
+	api.POST("/tickets/:id/messages/:name", s.correlateMessage)
+	api.GET("/tickets/:id/diagram", s.ticketDiagram)
+	api.GET("/tickets/:id/comments", s.listComments)
+	api.POST("/tickets/:id/comments", s.addComment)
+	api.PUT("/tickets/:id/comments/:commentId", s.editComment)
+	api.DELETE("/tickets/:id/comments/:commentId", s.deleteComment)
+	api.GET("/tickets/:id/comments/:commentId/history", s.commentHistory)
+	api.GET("/tickets/:id/attachments", s.listAttachments)
+	api.POST("/tickets/:id/attachments", s.uploadAttachment)
+	api.GET("/tickets/:id/attachments/:attachmentId", s.downloadAttachment)
+	api.DELETE("/tickets/:id/attachments/:attachmentId", s.deleteAttachment)
+	api.POST("/tickets/:id/assign", s.assignTicket)
+	api.POST("/tickets/:id/claim", s.claimTicket)
+	api.DELETE("/tickets/:id/claim", s.unclaimTicket)
+	api.POST("/tickets/:id/delegate", s.delegateTicket)
+	api.POST("/tickets/:id/delegate/resolve", s.resolveDelegation)
+
+	api.GET("/me/tasks", s.myTasks)
+
+	api.GET("/out-of-office", s.listOutOfOffice)
+	api.POST("/out-of-office", s.saveOutOfOffice)
+	api.PUT("/out-of-office/:ruleId", s.saveOutOfOffice)
+	api.DELETE("/out-of-office/:ruleId", s.deleteOutOfOffice)
+
+	api.GET("/ticket-types", s.listTicketTypes)
+	api.GET("/ticket-types/:key", s.getTicketType)
+
+	admin := api.Group("/admin")
+	admin.GET("/process-definitions", s.listProcessDefinitions)
+	admin.POST("/deployments", s.deployProcess)
+	admin.GET("/process-pins", s.listProcessPins)
+	admin.PUT("/process-pins/:key", s.pinProcessVersion)
+	admin.DELETE("/process-pins/:key", s.unpinProcessVersion)
+	admin.POST("/ticket-types", s.createTicketType)
+	admin.PUT("/ticket-types/:key", s.updateTicketType)
+	admin.DELETE("/ticket-types/:key", s.deleteTicketType)
+	admin.POST("/migrations", s.migrateTickets)
+	admin.GET("/sla-policies", s.listSLAPolicies)
+	admin.POST("/sla-policies", s.saveSLAPolicy)
+	admin.PUT("/sla-policies/:policyId", s.saveSLAPolicy)
+	admin.DELETE("/sla-policies/:policyId", s.deleteSLAPolicy)
+	admin.GET("/routing-rules", s.listRoutingRules)
+	admin.POST("/routing-rules", s.saveRoutingRule)
+	admin.PUT("/routing-rules/:ruleId", s.saveRoutingRule)
+	admin.DELETE("/routing-rules/:ruleId", s.deleteRoutingRule)
+	admin.GET("/tickets", s.findTickets)
+	admin.POST("/tickets/:id/status", s.forceStatus)
+	admin.POST("/tickets/:id/resubmit", s.resubmitTicket)
+	admin.GET("/incidents", s.listIncidents)
+	admin.POST("/incidents/:incidentId/retry", s.retryIncident)
+	admin.POST("/events/replay", s.replayEvents)
+	admin.POST("/reconcile", s.reconcile)
 }
 
 func (s *Server) createTicket(c *gin.Context) {
 	var payload struct {
-		Title       string `json:"title" binding:"required"`
-		Description string `json:"description"`
-		Requester   string `json:"requester" binding:"required"`
-		Assignee    string `json:"assignee"`
+		Title        string                `json:"title" binding:"required"`
+		Description  string                `json:"description"`
+		Requester    string                `json:"requester" binding:"required"`
+		Assignee     string                `json:"assignee"`
+		Category     string                `json:"category"`
+		Priority     models.TicketPriority `json:"priority" binding:"omitempty,oneof=low normal high urgent"`
+		Type         string                `json:"type"`
+		CustomFields map[string]any        `json:"customFields"`
 	}
 	if err := c.ShouldBindJSON(&payload); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -48,13 +160,21 @@ func (s *Server) createTicket(c *gin.Context) {
 	}
 
 	ticket := &models.Ticket{
-		Title:       payload.Title,
-		Description: payload.Description,
-		Requester:   payload.Requester,
-		Assignee:    payload.Assignee,
+		Title:        payload.Title,
+		Description:  payload.Description,
+		Requester:    payload.Requester,
+		Assignee:     payload.Assignee,
+		Category:     payload.Category,
+		Priority:     payload.Priority,
+		Type:         payload.Type,
+		CustomFields: payload.CustomFields,
 	}
 
 	if err := s.workflow.CreateTicket(c.Request.Context(), ticket); err != nil {
+		if errors.Is(err, service.ErrInvalidTicket) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -103,6 +223,11 @@ func (s *Server) decision(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
 		return
 	}
+	user := currentUser(c)
+	if user == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "request carries no user"})
+		return
+	}
 	var payload struct {
 		Approved bool   `json:"approved"`
 		Comment  string `json:"comment"`
@@ -111,9 +236,55 @@ func (s *Server) decision(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	if err := s.workflow.RecordDecision(c.Request.Context(), id, payload.Approved, payload.Comment); err != nil {
+	if err := s.workflow.RecordDecision(c.Request.Context(), id, user, payload.Approved, payload.Comment); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, service.ErrNoOpenTask) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (s *Server) cancelTicket(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	user := currentUser(c)
+	if user == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "request carries no user"})
+		return
+	}
+	if err := s.workflow.CancelTicket(c.Request.Context(), id, user); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 	c.Status(http.StatusNoContent)
 }
+
+func (s *Server) correlateMessage(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	var payload struct {
+		Variables map[string]any `json:"variables"`
+		All       bool           `json:"all"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	results, err := s.workflow.CorrelateMessage(c.Request.Context(), id, c.Param("name"), payload.Variables, payload.All)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, results)
+}
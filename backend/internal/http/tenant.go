@@ -0,0 +1,110 @@
+package http
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/example/pflow/backend/internal/tenant"
+)
+
+// TenantHeader names the tenant for callers whose token carries no tenant claim.
+const TenantHeader = "X-Tenant-ID"
+
+var (
+	errTenantRequired = errors.New("tenant is required")
+	errTenantMismatch = errors.New("tenant does not match the token")
+	errUnknownTenant  = errors.New("unknown tenant")
+)
+
+// TenantOptions controls how requests are mapped to tenants.
+type TenantOptions struct {
+	// Claim is the bearer token claim holding the tenant ID; it defaults to "tenant". Tokens are
+	// verified by the gateway, as the X-User header is.
+	Claim string
+	// Default applies when neither the token nor the header names a tenant.
+	Default string
+	// Allowed lists the tenants of a multi-tenant deployment. When set, every request must resolve
+	// to one of them; otherwise any valid tenant ID, or none, is accepted.
+	Allowed []string
+}
+
+// resolveTenant makes the rest of the request act for the tenant named by the bearer token's claim,
+// the X-Tenant-ID header or the default, in that order. A header contradicting the token is refused.
+func (s *Server) resolveTenant() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := s.tenancy.resolve(c.Request)
+		switch {
+		case errors.Is(err, errTenantMismatch), errors.Is(err, errUnknownTenant):
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		case err != nil:
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if id != "" {
+			c.Request = c.Request.WithContext(tenant.WithID(c.Request.Context(), id))
+		}
+		c.Next()
+	}
+}
+
+func (o TenantOptions) resolve(r *http.Request) (string, error) {
+	claim := o.Claim
+	if claim == "" {
+		claim = "tenant"
+	}
+	claimed := tokenClaim(r.Header.Get("Authorization"), claim)
+	header := strings.TrimSpace(r.Header.Get(TenantHeader))
+	if claimed != "" && header != "" && header != claimed {
+		return "", errTenantMismatch
+	}
+	id := claimed
+	if id == "" {
+		id = header
+	}
+	if id == "" {
+		id = o.Default
+	}
+	if id == "" {
+		if len(o.Allowed) > 0 {
+			return "", errTenantRequired
+		}
+		return "", nil
+	}
+	if err := tenant.Validate(id); err != nil {
+		return "", err
+	}
+	if len(o.Allowed) > 0 && !slices.Contains(o.Allowed, id) {
+		return "", errUnknownTenant
+	}
+	return id, nil
+}
+
+// tokenClaim reads a string claim from a JWT bearer token without verifying it. Opaque tokens and
+// missing claims read as "".
+func tokenClaim(authorization, claim string) string {
+	token, ok := strings.CutPrefix(authorization, "Bearer ")
+	if !ok {
+		return ""
+	}
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return ""
+	}
+	var claims map[string]any
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return ""
+	}
+	value, _ := claims[claim].(string)
+	return value
+}
@@ -0,0 +1,71 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/example/pflow/backend/internal/models"
+	"github.com/example/pflow/backend/internal/service"
+)
+
+func (s *Server) listTicketTypes(c *gin.Context) {
+	types, err := s.types.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, types)
+}
+
+func (s *Server) getTicketType(c *gin.Context) {
+	ticketType, err := s.types.Get(c.Request.Context(), c.Param("key"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, ticketType)
+}
+
+func (s *Server) createTicketType(c *gin.Context) {
+	var ticketType models.TicketType
+	if err := c.ShouldBindJSON(&ticketType); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := s.types.Create(c.Request.Context(), &ticketType); err != nil {
+		c.JSON(ticketTypeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, ticketType)
+}
+
+func (s *Server) updateTicketType(c *gin.Context) {
+	var ticketType models.TicketType
+	if err := c.ShouldBindJSON(&ticketType); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ticketType.Key = c.Param("key")
+	if err := s.types.Update(c.Request.Context(), &ticketType); err != nil {
+		c.JSON(ticketTypeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, ticketType)
+}
+
+func (s *Server) deleteTicketType(c *gin.Context) {
+	if err := s.types.Delete(c.Request.Context(), c.Param("key")); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func ticketTypeErrorStatus(err error) int {
+	if errors.Is(err, service.ErrInvalidTicket) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
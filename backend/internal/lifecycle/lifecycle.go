@@ -0,0 +1,153 @@
+// Package lifecycle starts the components of a process in dependency order and stops them in
+// reverse, giving each its own shutdown deadline.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+)
+
+// DefaultStopTimeout bounds the shutdown of components that do not set their own timeout.
+const DefaultStopTimeout = 10 * time.Second
+
+// Component is one part of the process. Start and Stop may be nil.
+type Component struct {
+	Name string
+	// Start brings the component up and returns once it runs; loops start their own goroutine.
+	Start func(ctx context.Context) error
+	// Stop shuts the component down before ctx's deadline.
+	Stop        func(ctx context.Context) error
+	StopTimeout time.Duration
+}
+
+// Manager runs components in the order they were added.
+type Manager struct {
+	components []Component
+	started    []Component
+}
+
+// New returns an empty manager.
+func New() *Manager {
+	return &Manager{}
+}
+
+// Add appends a component; it starts after and stops before every component added earlier.
+func (m *Manager) Add(c Component) {
+	m.components = append(m.components, c)
+}
+
+// Start starts the components in order. When one fails, the ones already started are stopped
+// and the start error is returned.
+func (m *Manager) Start(ctx context.Context) error {
+	for _, c := range m.components {
+		if c.Start != nil {
+			if err := c.Start(ctx); err != nil {
+				err = fmt.Errorf("start %s: %w", c.Name, err)
+				if stopErr := m.Stop(); stopErr != nil {
+					err = errors.Join(err, stopErr)
+				}
+				return err
+			}
+		}
+		m.started = append(m.started, c)
+		log.Printf("started %s", c.Name)
+	}
+	return nil
+}
+
+// Stop stops the started components in reverse order and returns the errors of those that did
+// not stop cleanly or in time. Every component is stopped even when an earlier one fails.
+func (m *Manager) Stop() error {
+	var errs []error
+	for i := len(m.started) - 1; i >= 0; i-- {
+		c := m.started[i]
+		if c.Stop == nil {
+			continue
+		}
+		timeout := c.StopTimeout
+		if timeout <= 0 {
+			timeout = DefaultStopTimeout
+		}
+		log.Printf("stopping %s (timeout %s)", c.Name, timeout)
+		begin := time.Now()
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := stopWithin(ctx, c.Stop)
+		cancel()
+		if err != nil {
+			log.Printf("%s did not stop cleanly after %s: %v", c.Name, time.Since(begin).Round(time.Millisecond), err)
+			errs = append(errs, fmt.Errorf("stop %s: %w", c.Name, err))
+			continue
+		}
+		log.Printf("stopped %s in %s", c.Name, time.Since(begin).Round(time.Millisecond))
+	}
+	m.started = nil
+	return errors.Join(errs...)
+}
+
+// stopWithin returns when stop does or when the deadline passes, whichever comes first, so a
+// component ignoring its context cannot hold up the rest of the shutdown.
+func stopWithin(ctx context.Context, stop func(ctx context.Context) error) error {
+	done := make(chan error, 1)
+	go func() { done <- stop(ctx) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Loop wraps a function that runs until its context is cancelled. Stopping cancels the context
+// and waits for the function to return.
+func Loop(name string, run func(ctx context.Context)) Component {
+	var cancel context.CancelFunc
+	done := make(chan struct{})
+	return Component{
+		Name: name,
+		Start: func(ctx context.Context) error {
+			var loopCtx context.Context
+			loopCtx, cancel = context.WithCancel(context.WithoutCancel(ctx))
+			go func() {
+				defer close(done)
+				run(loopCtx)
+			}()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	}
+}
+
+// HTTPServer listens on the server's address when started, so a taken port fails the start, and
+// shuts the server down gracefully when stopped.
+func HTTPServer(name string, srv *http.Server) Component {
+	return Component{
+		Name: name,
+		Start: func(context.Context) error {
+			ln, err := net.Listen("tcp", srv.Addr)
+			if err != nil {
+				return err
+			}
+			log.Printf("%s listening on %s", name, ln.Addr())
+			go func() {
+				if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+					log.Printf("%s stopped serving: %v", name, err)
+				}
+			}()
+			return nil
+		},
+		Stop: srv.Shutdown,
+	}
+}
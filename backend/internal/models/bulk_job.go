@@ -0,0 +1,59 @@
+package models
+
+import (
+	"database/sql/driver"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// BulkJob records the progress of a background bulk action, so any API instance can report it.
+type BulkJob struct {
+	ID         uuid.UUID   `gorm:"type:uuid;primaryKey" json:"id"`
+	Action     string      `json:"action"`
+	TenantID   string      `gorm:"size:64;index" json:"tenantId,omitempty"`
+	Status     string      `gorm:"index" json:"status"`
+	Total      int         `json:"total"`
+	Processed  int         `json:"processed"`
+	Succeeded  int         `json:"succeeded"`
+	Failed     int         `json:"failed"`
+	Results    BulkResults `json:"results"`
+	StartedAt  time.Time   `json:"startedAt"`
+	FinishedAt *time.Time  `gorm:"index" json:"finishedAt,omitempty"`
+	UpdatedAt  time.Time   `json:"-"`
+}
+
+// BulkResult is the outcome of a bulk action for a single ticket.
+type BulkResult struct {
+	TicketID uuid.UUID `json:"ticketId"`
+	OK       bool      `json:"ok"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// BulkResults is a list of bulk results stored in a JSONB column.
+type BulkResults []BulkResult
+
+// Value implements driver.Valuer.
+func (r BulkResults) Value() (driver.Value, error) {
+	if r == nil {
+		return nil, nil
+	}
+	return marshalJSON(r)
+}
+
+// Scan implements sql.Scanner.
+func (r *BulkResults) Scan(src any) error {
+	return unmarshalJSON(src, r)
+}
+
+// GormDataType implements schema.GormDataTypeInterface.
+func (BulkResults) GormDataType() string {
+	return "json"
+}
+
+// GormDBDataType selects the column type for the active dialect.
+func (BulkResults) GormDBDataType(db *gorm.DB, _ *schema.Field) string {
+	return jsonColumnType(db)
+}
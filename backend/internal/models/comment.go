@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CommentVisibility controls who may read a comment.
+type CommentVisibility string
+
+const (
+	// CommentVisibilityPublic comments are visible to the requester.
+	CommentVisibilityPublic CommentVisibility = "public"
+	// CommentVisibilityInternal comments are only visible to approvers and operators.
+	CommentVisibilityInternal CommentVisibility = "internal"
+)
+
+// CommentKind distinguishes free discussion from comments recorded by workflow steps.
+type CommentKind string
+
+const (
+	CommentKindDiscussion CommentKind = "discussion"
+	CommentKindDecision   CommentKind = "decision"
+)
+
+// Comment is a markdown message in a ticket's discussion thread.
+type Comment struct {
+	ID         uuid.UUID         `gorm:"type:uuid;primaryKey" json:"id"`
+	TicketID   uuid.UUID         `gorm:"type:uuid;index" json:"ticketId"`
+	Author     string            `json:"author"`
+	Body       string            `json:"body"`
+	Visibility CommentVisibility `json:"visibility"`
+	Kind       CommentKind       `json:"kind"`
+	// ActivityID links decision comments to the BPMN step they were made in.
+	ActivityID string         `json:"activityId,omitempty"`
+	Approved   *bool          `json:"approved,omitempty"`
+	Mentions   StringList     `json:"mentions"`
+	Edited     bool           `json:"edited"`
+	CreatedAt  time.Time      `json:"createdAt"`
+	UpdatedAt  time.Time      `json:"updatedAt"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// BeforeCreate is a GORM hook that populates the primary key and defaults.
+func (c *Comment) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	if c.Visibility == "" {
+		c.Visibility = CommentVisibilityPublic
+	}
+	if c.Kind == "" {
+		c.Kind = CommentKindDiscussion
+	}
+	return nil
+}
+
+// CommentRevision keeps the previous body of a comment each time it is edited or deleted.
+type CommentRevision struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CommentID uuid.UUID `gorm:"type:uuid;index" json:"commentId"`
+	Action    string    `json:"action"`
+	Body      string    `json:"body"`
+	Actor     string    `json:"actor"`
+	CreatedAt time.Time `json:"createdAt"`
+}
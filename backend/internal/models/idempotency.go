@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// IdempotencyRecord remembers the response to a request sent with an Idempotency-Key header.
+// StatusCode stays zero while the original request is still being processed.
+type IdempotencyRecord struct {
+	Key          string    `gorm:"primaryKey;size:255" json:"key"`
+	RequestHash  string    `json:"requestHash"`
+	StatusCode   int       `json:"statusCode"`
+	ContentType  string    `json:"contentType"`
+	ResponseBody []byte    `json:"-"`
+	CreatedAt    time.Time `json:"createdAt"`
+	ExpiresAt    time.Time `gorm:"index" json:"expiresAt"`
+}
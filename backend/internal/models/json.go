@@ -0,0 +1,116 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// JSONMap is a JSON object stored in a JSONB column.
+type JSONMap map[string]any
+
+// Value implements driver.Valuer.
+func (m JSONMap) Value() (driver.Value, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return marshalJSON(m)
+}
+
+// Scan implements sql.Scanner.
+func (m *JSONMap) Scan(src any) error {
+	return unmarshalJSON(src, m)
+}
+
+// GormDataType implements schema.GormDataTypeInterface.
+func (JSONMap) GormDataType() string {
+	return "json"
+}
+
+// GormDBDataType selects the column type for the active dialect.
+func (JSONMap) GormDBDataType(db *gorm.DB, _ *schema.Field) string {
+	return jsonColumnType(db)
+}
+
+// StringMap is a string-to-string JSON object stored in a JSONB column.
+type StringMap map[string]string
+
+// Value implements driver.Valuer.
+func (m StringMap) Value() (driver.Value, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return marshalJSON(m)
+}
+
+// Scan implements sql.Scanner.
+func (m *StringMap) Scan(src any) error {
+	return unmarshalJSON(src, m)
+}
+
+// GormDataType implements schema.GormDataTypeInterface.
+func (StringMap) GormDataType() string {
+	return "json"
+}
+
+// GormDBDataType selects the column type for the active dialect.
+func (StringMap) GormDBDataType(db *gorm.DB, _ *schema.Field) string {
+	return jsonColumnType(db)
+}
+
+// StringList is a list of strings stored in a JSONB column.
+type StringList []string
+
+// Value implements driver.Valuer.
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return nil, nil
+	}
+	return marshalJSON(l)
+}
+
+// Scan implements sql.Scanner.
+func (l *StringList) Scan(src any) error {
+	return unmarshalJSON(src, l)
+}
+
+// GormDataType implements schema.GormDataTypeInterface.
+func (StringList) GormDataType() string {
+	return "json"
+}
+
+// GormDBDataType selects the column type for the active dialect.
+func (StringList) GormDBDataType(db *gorm.DB, _ *schema.Field) string {
+	return jsonColumnType(db)
+}
+
+func marshalJSON(v any) (driver.Value, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func unmarshalJSON(src any, dst any) error {
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, dst)
+	case string:
+		return json.Unmarshal([]byte(v), dst)
+	default:
+		return fmt.Errorf("unsupported json column value %T", src)
+	}
+}
+
+func jsonColumnType(db *gorm.DB) string {
+	if db.Dialector.Name() == "postgres" {
+		return "jsonb"
+	}
+	return "json"
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// OutOfOfficeRule forwards tasks assigned to Username to Deputy while the rule is in effect.
+type OutOfOfficeRule struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Username  string    `gorm:"index" json:"username"`
+	Deputy    string    `json:"deputy"`
+	StartsAt  time.Time `json:"startsAt"`
+	EndsAt    time.Time `json:"endsAt"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Active reports whether the rule covers the given instant.
+func (r OutOfOfficeRule) Active(at time.Time) bool {
+	return !at.Before(r.StartsAt) && at.Before(r.EndsAt)
+}
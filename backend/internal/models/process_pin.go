@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// ProcessDefinitionPin fixes the process definition version new tickets start with for a process key.
+// Each tenant pins its own definitions; untenanted deployments use an empty TenantID.
+type ProcessDefinitionPin struct {
+	TenantID     string    `gorm:"primaryKey;size:64" json:"tenantId,omitempty"`
+	ProcessKey   string    `gorm:"primaryKey" json:"processKey"`
+	DefinitionID string    `json:"definitionId"`
+	Version      int       `json:"version"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
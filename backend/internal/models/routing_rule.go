@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Routing strategies deciding whether and how a single approver is picked from the candidates.
+const (
+	RoutingStrategyCandidates  = "candidates"
+	RoutingStrategyRoundRobin  = "round_robin"
+	RoutingStrategyLeastLoaded = "least_loaded"
+)
+
+// RoutingRule selects the approvers of tickets matching its conditions. Rules are evaluated in
+// ascending Position and the first enabled match wins; empty conditions match everything.
+type RoutingRule struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	Name       string `json:"name"`
+	Position   int    `gorm:"index" json:"position"`
+	Enabled    bool   `json:"enabled"`
+	TicketType string `json:"ticketType"`
+	Department string `json:"department"`
+	// FieldEquals matches custom fields by value; a list value matches any of its elements.
+	FieldEquals JSONMap `json:"fieldEquals"`
+	// AmountField names the numeric custom field (default "amount") that must lie in [MinAmount, MaxAmount).
+	AmountField      string     `json:"amountField"`
+	MinAmount        *float64   `json:"minAmount"`
+	MaxAmount        *float64   `json:"maxAmount"`
+	CandidateUsers   StringList `json:"candidateUsers"`
+	CandidateGroups  StringList `json:"candidateGroups"`
+	Strategy         string     `json:"strategy"`
+	RoundRobinCursor int        `json:"-"`
+	CreatedAt        time.Time  `json:"createdAt"`
+	UpdatedAt        time.Time  `json:"updatedAt"`
+}
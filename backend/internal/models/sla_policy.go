@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// SLAPolicy defines approval and resolution deadlines for tickets of a category and priority.
+// Empty Category or Priority act as wildcards; the most specific matching policy wins.
+type SLAPolicy struct {
+	ID                   uint           `gorm:"primaryKey" json:"id"`
+	Name                 string         `json:"name"`
+	Category             string         `gorm:"index" json:"category"`
+	Priority             TicketPriority `json:"priority"`
+	ApprovalMinutes      int            `json:"approvalMinutes"`
+	ResolutionMinutes    int            `json:"resolutionMinutes"`
+	WarnBeforeMinutes    int            `json:"warnBeforeMinutes"`
+	EscalateAfterMinutes int            `json:"escalateAfterMinutes"`
+	EscalationGroup      string         `json:"escalationGroup"`
+	EscalationAssignee   string         `json:"escalationAssignee"`
+	CreatedAt            time.Time      `json:"createdAt"`
+	UpdatedAt            time.Time      `json:"updatedAt"`
+}
+
+// Specificity ranks how narrowly the policy matches; higher is more specific.
+func (p SLAPolicy) Specificity() int {
+	score := 0
+	if p.Category != "" {
+		score += 2
+	}
+	if p.Priority != "" {
+		score++
+	}
+	return score
+}
+
+// Matches reports whether the policy applies to the ticket.
+func (p SLAPolicy) Matches(t *Ticket) bool {
+	return (p.Category == "" || p.Category == t.Category) && (p.Priority == "" || p.Priority == t.Priority)
+}
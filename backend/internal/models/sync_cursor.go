@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// SyncCursor remembers how far a background job has read an external feed, e.g. the start time
+// of the last Camunda history activity applied to tickets.
+type SyncCursor struct {
+	Name      string    `gorm:"primaryKey;size:64" json:"name"`
+	Position  time.Time `json:"position"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
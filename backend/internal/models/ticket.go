@@ -17,19 +17,86 @@ const (
 	TicketStatusRejected   TicketStatus = "rejected"
 	TicketStatusProcessing TicketStatus = "processing"
 	TicketStatusCompleted  TicketStatus = "completed"
+	// TicketStatusFailed marks a ticket whose provisioning failed on its last retry; the process
+	// waits on an incident and the ticket returns to processing once the incident is retried.
+	TicketStatusFailed    TicketStatus = "failed"
+	TicketStatusCancelled TicketStatus = "cancelled"
 )
 
+// TicketPriority ranks tickets for SLA policy selection and task ordering.
+type TicketPriority string
+
+const (
+	TicketPriorityLow    TicketPriority = "low"
+	TicketPriorityNormal TicketPriority = "normal"
+	TicketPriorityHigh   TicketPriority = "high"
+	TicketPriorityUrgent TicketPriority = "urgent"
+)
+
+// TaskPriority maps the ticket priority onto the numeric Camunda task priority (default 50).
+func (p TicketPriority) TaskPriority() int {
+	switch p {
+	case TicketPriorityLow:
+		return 25
+	case TicketPriorityHigh:
+		return 75
+	case TicketPriorityUrgent:
+		return 100
+	}
+	return 50
+}
+
+// SLAState tracks a ticket against the deadline of its current SLA phase.
+type SLAState string
+
+const (
+	SLAStateOnTrack  SLAState = "on_track"
+	SLAStateAtRisk   SLAState = "at_risk"
+	SLAStateBreached SLAState = "breached"
+	SLAStateMet      SLAState = "met"
+)
+
+// SLA phases: a ticket is measured against its approval deadline until the decision, then
+// against its resolution deadline.
+const (
+	SLAPhaseApproval   = "approval"
+	SLAPhaseResolution = "resolution"
+)
+
+// ActiveTicketStatuses lists the statuses of tickets with a running process instance.
+var ActiveTicketStatuses = []TicketStatus{TicketStatusSubmitted, TicketStatusApproved, TicketStatusProcessing, TicketStatusFailed}
+
+// Terminal reports whether the status ends the ticket life-cycle.
+func (s TicketStatus) Terminal() bool {
+	return s == TicketStatusRejected || s == TicketStatusCompleted || s == TicketStatusCancelled
+}
+
 // Ticket represents a work order entity persisted in Postgres and mirrored in Camunda.
 type Ticket struct {
-	ID                uuid.UUID    `gorm:"type:uuid;primaryKey" json:"id"`
-	Title             string       `json:"title"`
-	Description       string       `json:"description"`
-	Requester         string       `json:"requester"`
-	Assignee          string       `json:"assignee"`
-	Status            TicketStatus `json:"status"`
-	ProcessInstanceID string       `json:"processInstanceId"`
-	CreatedAt         time.Time    `json:"createdAt"`
-	UpdatedAt         time.Time    `json:"updatedAt"`
+	ID                       uuid.UUID      `gorm:"type:uuid;primaryKey" json:"id"`
+	TenantID                 string         `gorm:"size:64;index" json:"tenantId,omitempty"`
+	Title                    string         `json:"title"`
+	Description              string         `json:"description"`
+	Requester                string         `json:"requester"`
+	Assignee                 string         `json:"assignee"`
+	Type                     string         `gorm:"index" json:"type"`
+	CustomFields             JSONMap        `json:"customFields"`
+	Category                 string         `json:"category"`
+	Priority                 TicketPriority `json:"priority"`
+	Status                   TicketStatus   `json:"status"`
+	ProcessInstanceID        string         `json:"processInstanceId"`
+	ProcessDefinitionID      string         `json:"processDefinitionId"`
+	ProcessDefinitionVersion int            `json:"processDefinitionVersion"`
+	MigrationBatchID         string         `gorm:"size:64;index" json:"migrationBatchId,omitempty"`
+	ApprovalDueAt            *time.Time     `json:"approvalDueAt,omitempty"`
+	ResolutionDueAt          *time.Time     `json:"resolutionDueAt,omitempty"`
+	SLAPhase                 string         `gorm:"size:16" json:"slaPhase,omitempty"`
+	SLAState                 SLAState       `json:"slaState,omitempty"`
+	SLAWarnedAt              *time.Time     `json:"slaWarnedAt,omitempty"`
+	SLABreachedAt            *time.Time     `json:"slaBreachedAt,omitempty"`
+	Attachments              []Attachment   `gorm:"foreignKey:TicketID" json:"attachments,omitempty"`
+	CreatedAt                time.Time      `json:"createdAt"`
+	UpdatedAt                time.Time      `json:"updatedAt"`
 }
 
 // BeforeCreate is a GORM hook that populates the primary key.
@@ -40,5 +107,8 @@ func (t *Ticket) BeforeCreate(tx *gorm.DB) error {
 	if t.Status == "" {
 		t.Status = TicketStatusDraft
 	}
+	if t.Priority == "" {
+		t.Priority = TicketPriorityNormal
+	}
 	return nil
 }
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"database/sql/driver"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// TicketType describes a kind of ticket with its own custom fields and approval process.
+type TicketType struct {
+	Key         string `gorm:"primaryKey" json:"key"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// ProcessKey overrides the default BPMN process started for tickets of this type.
+	ProcessKey string `json:"processKey"`
+	// Schema is a JSON Schema document validating Ticket.CustomFields.
+	Schema JSONMap `json:"schema"`
+	// ProcessVariables maps custom field names to the process variables they are passed as on start.
+	ProcessVariables StringMap `json:"processVariables"`
+	// Provision is what the "Provision Service" step does for tickets of this type; nil completes
+	// the ticket without provisioning anything.
+	Provision *ProvisionAction `json:"provision,omitempty"`
+	CreatedAt time.Time        `json:"createdAt"`
+	UpdatedAt time.Time        `json:"updatedAt"`
+}
+
+// Provision action kinds.
+const (
+	ProvisionKindHTTP    = "http"
+	ProvisionKindCommand = "command"
+	ProvisionKindMQ      = "mq"
+)
+
+// ProvisionAction configures one provisioning step. String fields marked as templates are
+// rendered with text/template against the ticket, its custom fields and the process variables.
+type ProvisionAction struct {
+	// Kind selects the executor: "http", "command" or "mq".
+	Kind string `json:"kind"`
+	// Timeout bounds the action, e.g. "2m"; the configured default applies when empty.
+	Timeout string            `json:"timeout,omitempty"`
+	HTTP    *HTTPProvision    `json:"http,omitempty"`
+	Command *CommandProvision `json:"command,omitempty"`
+	MQ      *MQProvision      `json:"mq,omitempty"`
+	// Outputs maps process variable names to dotted paths in the action result, e.g.
+	// {"accountId": "body.id"}. Without outputs the whole result is stored as provisionResult.
+	Outputs map[string]string `json:"outputs,omitempty"`
+}
+
+// HTTPProvision calls an HTTP endpoint; a non-2xx response fails the action.
+type HTTPProvision struct {
+	Method string `json:"method"`
+	// URL, header values and Body are templates.
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+}
+
+// CommandProvision runs an allow-listed executable in a scratch directory; a non-zero exit fails
+// the action. No shell is involved, so arguments are passed verbatim.
+type CommandProvision struct {
+	Command string `json:"command"`
+	// Args, Env values and Stdin are templates.
+	Args  []string          `json:"args,omitempty"`
+	Env   map[string]string `json:"env,omitempty"`
+	Stdin string            `json:"stdin,omitempty"`
+}
+
+// MQProvision publishes a command to the ticket exchange and waits for the reply.
+type MQProvision struct {
+	RoutingKey string `json:"routingKey"`
+	// Body is a template rendering the JSON message.
+	Body string `json:"body,omitempty"`
+}
+
+// Value implements driver.Valuer.
+func (a ProvisionAction) Value() (driver.Value, error) {
+	return marshalJSON(a)
+}
+
+// Scan implements sql.Scanner.
+func (a *ProvisionAction) Scan(src any) error {
+	return unmarshalJSON(src, a)
+}
+
+// GormDataType implements schema.GormDataTypeInterface.
+func (ProvisionAction) GormDataType() string {
+	return "json"
+}
+
+// GormDBDataType selects the column type for the active dialect.
+func (ProvisionAction) GormDBDataType(db *gorm.DB, _ *schema.Field) string {
+	return jsonColumnType(db)
+}
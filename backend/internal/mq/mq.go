@@ -3,8 +3,13 @@ package mq
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
+	"strconv"
+	"sync"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/rabbitmq/amqp091-go"
 )
 
@@ -16,14 +21,71 @@ type Publisher interface {
 // Consumer defines a minimal interface for subscribing to queue messages.
 type Consumer interface {
 	Consume(handler func(amqp091.Delivery)) error
+	// Retry acknowledges the message and delivers it again after a delay, counting the attempt in
+	// AttemptsHeader.
+	Retry(ctx context.Context, d amqp091.Delivery) error
+	// DeadLetter acknowledges the message and parks it on the dead-letter queue with the reason.
+	DeadLetter(ctx context.Context, d amqp091.Delivery, reason string) error
 	Close() error
 }
 
-// RabbitPublisher publishes JSON events to a RabbitMQ exchange.
+// Headers added to messages handed back by Retry or DeadLetter.
+const (
+	// AttemptsHeader counts the failed attempts to handle a message.
+	AttemptsHeader = "x-pflow-attempts"
+	// RoutingKeyHeader keeps the routing key the message was originally published with.
+	RoutingKeyHeader = "x-pflow-routing-key"
+	// ReasonHeader tells why a message was dead-lettered.
+	ReasonHeader = "x-pflow-dead-letter-reason"
+)
+
+// Suffixes of the queues declared next to a consumer's queue by EnableRetries.
+const (
+	RetryQueueSuffix      = ".retry"
+	DeadLetterQueueSuffix = ".dead"
+)
+
+// Attempts returns how often handling the message failed before, per AttemptsHeader.
+func Attempts(d amqp091.Delivery) int {
+	switch n := d.Headers[AttemptsHeader].(type) {
+	case int:
+		return n
+	case int16:
+		return int(n)
+	case int32:
+		return int(n)
+	case int64:
+		return int(n)
+	}
+	return 0
+}
+
+// OriginalRoutingKey returns the routing key the message was published with. Retried messages
+// reach the queue under its own name, so the key is read from RoutingKeyHeader when present.
+func OriginalRoutingKey(d amqp091.Delivery) string {
+	if key, ok := d.Headers[RoutingKeyHeader].(string); ok && key != "" {
+		return key
+	}
+	return d.RoutingKey
+}
+
+// RoutingKey tags an event routing key with the tenant it belongs to, e.g. "acme.ticket.created",
+// so consumers can bind to one tenant ("acme.ticket.*") or to all of them ("*.ticket.*"). Events
+// without a tenant keep the plain key.
+func RoutingKey(tenantID, key string) string {
+	if tenantID == "" {
+		return key
+	}
+	return tenantID + "." + key
+}
+
+// RabbitPublisher publishes JSON events to a RabbitMQ exchange. The channel runs in confirm mode
+// so Flush can wait until the broker has taken every published event.
 type RabbitPublisher struct {
 	conn     *amqp091.Connection
 	channel  *amqp091.Channel
 	exchange string
+	pending  sync.WaitGroup
 }
 
 // NewRabbitPublisher creates a publisher connecting to RabbitMQ.
@@ -41,6 +103,10 @@ func NewRabbitPublisher(url, exchange string) (*RabbitPublisher, error) {
 		conn.Close()
 		return nil, err
 	}
+	if err := ch.Confirm(false); err != nil {
+		conn.Close()
+		return nil, err
+	}
 	return &RabbitPublisher{conn: conn, channel: ch, exchange: exchange}, nil
 }
 
@@ -53,10 +119,51 @@ func (p *RabbitPublisher) Publish(ctx context.Context, routingKey string, payloa
 	if err != nil {
 		return err
 	}
-	return p.channel.PublishWithContext(ctx, p.exchange, routingKey, false, false, amqp091.Publishing{
+	confirm, err := p.channel.PublishWithDeferredConfirmWithContext(ctx, p.exchange, routingKey, false, false, amqp091.Publishing{
 		ContentType: "application/json",
 		Body:        body,
 	})
+	if err != nil {
+		return err
+	}
+	p.pending.Add(1)
+	go func() {
+		defer p.pending.Done()
+		if !confirm.Wait() {
+			log.Printf("broker did not confirm event %s", routingKey)
+		}
+	}()
+	return nil
+}
+
+// Flush waits until the broker has confirmed or rejected every published event.
+func (p *RabbitPublisher) Flush(ctx context.Context) error {
+	if p == nil {
+		return nil
+	}
+	done := make(chan struct{})
+	go func() {
+		p.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Shutdown flushes pending events and closes the connection.
+func (p *RabbitPublisher) Shutdown(ctx context.Context) error {
+	if p == nil {
+		return nil
+	}
+	flushErr := p.Flush(ctx)
+	if err := p.Close(); err != nil {
+		return err
+	}
+	return flushErr
 }
 
 // Close terminates the connection.
@@ -72,13 +179,20 @@ func (p *RabbitPublisher) Close() error {
 
 // RabbitConsumer consumes messages from queue and acknowledges on success.
 type RabbitConsumer struct {
-	conn    *amqp091.Connection
-	channel *amqp091.Channel
-	queue   string
+	conn       *amqp091.Connection
+	channel    *amqp091.Channel
+	queue      string
+	tag        string
+	done       chan struct{}
+	retryDelay time.Duration
 }
 
-// NewRabbitConsumer sets up queue bindings and returns a consumer.
-func NewRabbitConsumer(url, exchange, queue string) (*RabbitConsumer, error) {
+// NewRabbitConsumer sets up queue bindings and returns a consumer. The queue is bound to
+// "ticket.*" unless explicit binding keys are provided.
+func NewRabbitConsumer(url, exchange, queue string, bindingKeys ...string) (*RabbitConsumer, error) {
+	if len(bindingKeys) == 0 {
+		bindingKeys = []string{"ticket.*"}
+	}
 	conn, err := amqp091.Dial(url)
 	if err != nil {
 		return nil, err
@@ -97,20 +211,97 @@ func NewRabbitConsumer(url, exchange, queue string) (*RabbitConsumer, error) {
 		conn.Close()
 		return nil, err
 	}
-	if err := ch.QueueBind(q.Name, "ticket.*", exchange, false, nil); err != nil {
-		conn.Close()
-		return nil, err
+	for _, key := range bindingKeys {
+		if err := ch.QueueBind(q.Name, key, exchange, false, nil); err != nil {
+			conn.Close()
+			return nil, err
+		}
 	}
-	return &RabbitConsumer{conn: conn, channel: ch, queue: q.Name}, nil
+	return &RabbitConsumer{conn: conn, channel: ch, queue: q.Name, tag: "pflow-" + uuid.New().String()}, nil
+}
+
+// EnableRetries declares the retry and dead-letter queues of the consumer's queue. Messages passed to
+// Retry wait in "<queue>.retry" for the delay and then expire back into the queue; messages passed
+// to DeadLetter stay in "<queue>.dead" until an operator moves or purges them.
+func (c *RabbitConsumer) EnableRetries(delay time.Duration) error {
+	if delay <= 0 {
+		return errors.New("retry delay must be positive")
+	}
+	if _, err := c.channel.QueueDeclare(c.queue+RetryQueueSuffix, true, false, false, false, amqp091.Table{
+		"x-dead-letter-exchange":    "",
+		"x-dead-letter-routing-key": c.queue,
+	}); err != nil {
+		return err
+	}
+	if _, err := c.channel.QueueDeclare(c.queue+DeadLetterQueueSuffix, true, false, false, false, nil); err != nil {
+		return err
+	}
+	if err := c.channel.Confirm(false); err != nil {
+		return err
+	}
+	c.retryDelay = delay
+	return nil
+}
+
+// Retry republishes the message to the retry queue and acknowledges it once the broker confirmed.
+func (c *RabbitConsumer) Retry(ctx context.Context, d amqp091.Delivery) error {
+	if c.retryDelay <= 0 {
+		return errors.New("retries are not enabled")
+	}
+	return c.forward(ctx, d, c.queue+RetryQueueSuffix, strconv.FormatInt(c.retryDelay.Milliseconds(), 10), nil)
+}
+
+// DeadLetter republishes the message to the dead-letter queue and acknowledges it once the broker
+// confirmed.
+func (c *RabbitConsumer) DeadLetter(ctx context.Context, d amqp091.Delivery, reason string) error {
+	if c.retryDelay <= 0 {
+		return errors.New("retries are not enabled")
+	}
+	return c.forward(ctx, d, c.queue+DeadLetterQueueSuffix, "", amqp091.Table{ReasonHeader: reason})
+}
+
+func (c *RabbitConsumer) forward(ctx context.Context, d amqp091.Delivery, queue, expiration string, extra amqp091.Table) error {
+	headers := amqp091.Table{}
+	for k, v := range d.Headers {
+		headers[k] = v
+	}
+	headers[AttemptsHeader] = int32(Attempts(d) + 1)
+	headers[RoutingKeyHeader] = OriginalRoutingKey(d)
+	for k, v := range extra {
+		headers[k] = v
+	}
+	confirm, err := c.channel.PublishWithDeferredConfirmWithContext(ctx, "", queue, false, false, amqp091.Publishing{
+		Headers:       headers,
+		ContentType:   d.ContentType,
+		DeliveryMode:  amqp091.Persistent,
+		MessageId:     d.MessageId,
+		CorrelationId: d.CorrelationId,
+		Timestamp:     d.Timestamp,
+		Expiration:    expiration,
+		Body:          d.Body,
+	})
+	if err != nil {
+		return err
+	}
+	ok, err := confirm.WaitContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("broker rejected the message")
+	}
+	return d.Ack(false)
 }
 
 // Consume begins delivering messages to handler.
 func (c *RabbitConsumer) Consume(handler func(amqp091.Delivery)) error {
-	deliveries, err := c.channel.Consume(c.queue, "", false, false, false, false, nil)
+	deliveries, err := c.channel.Consume(c.queue, c.tag, false, false, false, false, nil)
 	if err != nil {
 		return err
 	}
+	c.done = make(chan struct{})
 	go func() {
+		defer close(c.done)
 		for msg := range deliveries {
 			handler(msg)
 		}
@@ -118,13 +309,125 @@ func (c *RabbitConsumer) Consume(handler func(amqp091.Delivery)) error {
 	return nil
 }
 
-// Close closes the consumer resources.
+// Close stops the delivery of new messages, waits for the handler to finish the message in
+// progress so it can still be acknowledged, and closes the consumer resources. Messages the
+// broker already sent but that were not handled are requeued.
 func (c *RabbitConsumer) Close() error {
 	if c == nil {
 		return nil
 	}
+	if c.done != nil {
+		if err := c.channel.Cancel(c.tag, false); err != nil {
+			log.Printf("cancel consumer: %v", err)
+		}
+		<-c.done
+	}
 	if err := c.channel.Close(); err != nil {
 		log.Printf("close channel: %v", err)
 	}
 	return c.conn.Close()
 }
+
+// Requester sends a message and waits for the reply, RPC style.
+type Requester interface {
+	Request(ctx context.Context, routingKey string, payload any) ([]byte, error)
+}
+
+// directReplyTo is RabbitMQ's pseudo-queue for replies on the requesting channel.
+const directReplyTo = "amq.rabbitmq.reply-to"
+
+// RabbitRequester publishes requests to an exchange and receives replies through RabbitMQ's
+// direct reply-to. Responders publish the reply to the default exchange with the request's
+// reply-to as routing key and copy its correlation ID.
+type RabbitRequester struct {
+	conn     *amqp091.Connection
+	channel  *amqp091.Channel
+	exchange string
+
+	mu      sync.Mutex
+	pending map[string]chan []byte
+}
+
+// NewRabbitRequester creates a requester connecting to RabbitMQ.
+func NewRabbitRequester(url, exchange string) (*RabbitRequester, error) {
+	conn, err := amqp091.Dial(url)
+	if err != nil {
+		return nil, err
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := ch.ExchangeDeclare(exchange, "topic", true, false, false, false, nil); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	replies, err := ch.Consume(directReplyTo, "", true, false, false, false, nil)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	r := &RabbitRequester{conn: conn, channel: ch, exchange: exchange, pending: map[string]chan []byte{}}
+	go r.dispatch(replies)
+	return r, nil
+}
+
+func (r *RabbitRequester) dispatch(replies <-chan amqp091.Delivery) {
+	for msg := range replies {
+		r.mu.Lock()
+		waiter, ok := r.pending[msg.CorrelationId]
+		delete(r.pending, msg.CorrelationId)
+		r.mu.Unlock()
+		if !ok {
+			log.Printf("drop reply with unknown correlation id %q", msg.CorrelationId)
+			continue
+		}
+		waiter <- msg.Body
+	}
+}
+
+// Request publishes the payload as JSON and returns the body of the reply, or the context's error
+// when no reply arrives in time.
+func (r *RabbitRequester) Request(ctx context.Context, routingKey string, payload any) ([]byte, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	id := uuid.New().String()
+	reply := make(chan []byte, 1)
+	r.mu.Lock()
+	r.pending[id] = reply
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		delete(r.pending, id)
+		r.mu.Unlock()
+	}()
+
+	if err := r.channel.PublishWithContext(ctx, r.exchange, routingKey, false, false, amqp091.Publishing{
+		ContentType:   "application/json",
+		CorrelationId: id,
+		ReplyTo:       directReplyTo,
+		Body:          body,
+	}); err != nil {
+		return nil, err
+	}
+	select {
+	case b := <-reply:
+		return b, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Close terminates the connection.
+func (r *RabbitRequester) Close() error {
+	if r == nil {
+		return nil
+	}
+	if err := r.channel.Close(); err != nil {
+		log.Printf("close channel: %v", err)
+	}
+	return r.conn.Close()
+}
@@ -0,0 +1,98 @@
+package provision
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/example/pflow/backend/internal/models"
+)
+
+// runCommand runs the executable in a fresh scratch directory that is removed afterwards, with an
+// environment holding only PATH, HOME, TMPDIR and the action's variables. It returns
+// {"exitCode": 0, "stdout": ..., "stderr": ..., "output": stdout parsed as JSON when it is JSON}.
+func (r *Runner) runCommand(ctx context.Context, spec *models.CommandProvision, data Data) (map[string]any, error) {
+	if !r.commands[spec.Command] {
+		return nil, errors.Errorf("command %s is not allowed", spec.Command)
+	}
+	args := make([]string, len(spec.Args))
+	for i, arg := range spec.Args {
+		rendered, err := render(arg, data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "render argument %d", i)
+		}
+		args[i] = rendered
+	}
+	stdin, err := render(spec.Stdin, data)
+	if err != nil {
+		return nil, errors.Wrap(err, "render stdin")
+	}
+
+	if err := os.MkdirAll(r.workDir, 0o750); err != nil {
+		return nil, errors.Wrap(err, "create work dir")
+	}
+	dir, err := os.MkdirTemp(r.workDir, "ticket-"+data.Ticket.ID.String()+"-")
+	if err != nil {
+		return nil, errors.Wrap(err, "create scratch dir")
+	}
+	defer os.RemoveAll(dir)
+
+	env := []string{"PATH=/usr/local/bin:/usr/bin:/bin", "HOME=" + dir, "TMPDIR=" + dir}
+	names := make([]string, 0, len(spec.Env))
+	for name := range spec.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value, err := render(spec.Env[name], data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "render env %s", name)
+		}
+		env = append(env, name+"="+value)
+	}
+
+	var stdout, stderr limitedBuffer
+	cmd := exec.CommandContext(ctx, spec.Command, args...)
+	cmd.Dir = dir
+	cmd.Env = env
+	cmd.Stdin = strings.NewReader(stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Do not wait forever for output pipes held open by children of a killed command.
+	cmd.WaitDelay = 5 * time.Second
+
+	err = cmd.Run()
+	if ctx.Err() != nil {
+		return nil, errors.Wrap(ctx.Err(), spec.Command)
+	}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, errors.Errorf("%s exited with %d: %s", spec.Command, exitErr.ExitCode(), strings.TrimSpace(stderr.String()))
+	}
+	result := map[string]any{"exitCode": 0, "stdout": stdout.String(), "stderr": stderr.String()}
+	if out, ok := decodeBody(stdout.Bytes()).(map[string]any); ok {
+		result["output"] = out
+	}
+	return result, nil
+}
+
+// limitedBuffer keeps the first maxResponseSize bytes written and discards the rest.
+type limitedBuffer struct {
+	bytes.Buffer
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := maxResponseSize - b.Len(); room > 0 {
+		b.Buffer.Write(p[:min(len(p), room)])
+	}
+	return len(p), nil
+}
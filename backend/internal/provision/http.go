@@ -0,0 +1,102 @@
+package provision
+
+import (
+	"context"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/example/pflow/backend/internal/models"
+)
+
+// maxResponseSize caps what is read from HTTP responses and command output.
+const maxResponseSize = 1 << 20
+
+// runHTTP sends the request and returns {"status": code, "body": response}.
+func (r *Runner) runHTTP(ctx context.Context, spec *models.HTTPProvision, data Data) (map[string]any, error) {
+	url, err := render(spec.URL, data)
+	if err != nil {
+		return nil, errors.Wrap(err, "render url")
+	}
+	body, err := render(spec.Body, data)
+	if err != nil {
+		return nil, errors.Wrap(err, "render body")
+	}
+	method := spec.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(method), url, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	// Check again after rendering: fields and variables end up in the url.
+	if err := r.checkURL(req.URL); err != nil {
+		return nil, err
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range spec.Headers {
+		rendered, err := render(value, data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "render header %s", name)
+		}
+		req.Header.Set(name, rendered)
+	}
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, errors.Wrap(err, "read response")
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, errors.Errorf("%s %s returned %s: %s", req.Method, req.URL.Redacted(), resp.Status, strings.TrimSpace(string(raw)))
+	}
+	return map[string]any{"status": resp.StatusCode, "body": decodeBody(raw)}, nil
+}
+
+// checkURLTemplate checks the url of an action before rendering. Its scheme and host must be
+// literal so that the host can be checked when the ticket type is saved.
+func (r *Runner) checkURLTemplate(text string) error {
+	scheme, rest, ok := strings.Cut(text, "://")
+	if !ok {
+		return errors.Errorf("url %q needs a scheme and host", text)
+	}
+	host := rest
+	if i := strings.IndexAny(rest, "/?#"); i >= 0 {
+		host = rest[:i]
+	}
+	if strings.Contains(scheme, "{{") || strings.Contains(host, "{{") {
+		return errors.Errorf("url %q must not use a template in its scheme or host", text)
+	}
+	u, err := neturl.Parse(scheme + "://" + host)
+	if err != nil {
+		return errors.Wrap(err, "parse url")
+	}
+	return r.checkURL(u)
+}
+
+// checkURL fails unless the url is http or https and its host is allowed.
+func (r *Runner) checkURL(u *neturl.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.Errorf("url scheme %q is not allowed", u.Scheme)
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, allowed := range r.hosts {
+		if host == allowed {
+			return nil
+		}
+		if suffix, ok := strings.CutPrefix(allowed, "*"); ok && strings.HasSuffix(host, suffix) {
+			return nil
+		}
+	}
+	return errors.Errorf("host %q is not allowed", host)
+}
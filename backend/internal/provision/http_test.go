@@ -0,0 +1,75 @@
+package provision
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/example/pflow/backend/internal/models"
+)
+
+func TestValidateHTTPHosts(t *testing.T) {
+	r := NewRunner(Config{Hosts: []string{"provision.internal", "*.example.com"}})
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://provision.internal/accounts/{{.Ticket.ID}}", ""},
+		{"https://Provision.Internal:8443/accounts", ""},
+		{"https://api.example.com/v1?ticket={{.Ticket.ID}}", ""},
+		{"https://example.com/v1", `host "example.com" is not allowed`},
+		{"http://169.254.169.254/latest/meta-data", `host "169.254.169.254" is not allowed`},
+		{"https://{{.Fields.host}}/accounts", "must not use a template"},
+		{"{{.Fields.url}}", "needs a scheme and host"},
+		{"file://provision.internal/etc/passwd", `scheme "file" is not allowed`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			err := r.Validate(models.ProvisionAction{Kind: models.ProvisionKindHTTP, HTTP: &models.HTTPProvision{URL: tt.url}})
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("Validate = %v, want nil", err)
+			case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+				t.Errorf("Validate = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunHTTPChecksRenderedURLAndRedirects(t *testing.T) {
+	var hits int
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits++
+	}))
+	defer target.Close()
+	allowed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Point at the same server under a host name that is not allowed.
+		http.Redirect(w, r, strings.Replace(target.URL, "127.0.0.1", "localhost", 1), http.StatusFound)
+	}))
+	defer allowed.Close()
+	r := NewRunner(Config{Hosts: []string{"127.0.0.1"}})
+
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{"redirect", allowed.URL + "/accounts", `host "localhost" is not allowed`},
+		{"rendered userinfo", "http://127.0.0.1{{.Fields.suffix}}/", `host "localhost" is not allowed`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &models.HTTPProvision{URL: tt.url, Method: http.MethodGet}
+			data := Data{Ticket: &models.Ticket{}, Fields: map[string]any{"suffix": "@localhost:1"}}
+			_, err := r.runHTTP(context.Background(), spec, data)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("runHTTP = %v, want %q", err, tt.want)
+			}
+		})
+	}
+	if hits != 0 {
+		t.Errorf("disallowed host was called %d times", hits)
+	}
+}
@@ -0,0 +1,49 @@
+package provision
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/example/pflow/backend/internal/models"
+	"github.com/example/pflow/backend/internal/mq"
+)
+
+// runMQ publishes the rendered body, or the default request when there is none, and waits for
+// the reply. A reply that is a JSON object with a non-empty "error" string fails the action.
+// It returns {"body": reply}.
+func (r *Runner) runMQ(ctx context.Context, spec *models.MQProvision, data Data) (map[string]any, error) {
+	if r.requester == nil {
+		return nil, errors.New("message broker unavailable")
+	}
+	var payload any = map[string]any{
+		"ticketId":  data.Ticket.ID.String(),
+		"tenantId":  data.Ticket.TenantID,
+		"type":      data.Ticket.Type,
+		"fields":    data.Fields,
+		"variables": data.Variables,
+	}
+	if spec.Body != "" {
+		body, err := render(spec.Body, data)
+		if err != nil {
+			return nil, errors.Wrap(err, "render body")
+		}
+		if !json.Valid([]byte(body)) {
+			return nil, errors.New("rendered body is not JSON")
+		}
+		payload = json.RawMessage(body)
+	}
+
+	raw, err := r.requester.Request(ctx, mq.RoutingKey(data.Ticket.TenantID, spec.RoutingKey), payload)
+	if err != nil {
+		return nil, errors.Wrapf(err, "request %s", spec.RoutingKey)
+	}
+	reply := decodeBody(raw)
+	if obj, ok := reply.(map[string]any); ok {
+		if msg, _ := obj["error"].(string); msg != "" {
+			return nil, errors.Errorf("%s replied with error: %s", spec.RoutingKey, msg)
+		}
+	}
+	return map[string]any{"body": reply}, nil
+}
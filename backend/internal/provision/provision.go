@@ -0,0 +1,201 @@
+// Package provision runs the provisioning action a ticket type declares for the "Provision
+// Service" step: an HTTP call, an allow-listed command or an MQ request/reply. Each action yields
+// a result document from which process variables are picked.
+package provision
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/example/pflow/backend/internal/models"
+	"github.com/example/pflow/backend/internal/mq"
+)
+
+// ErrInvalidAction marks action definitions that can never run.
+var ErrInvalidAction = errors.New("invalid provision action")
+
+// ResultVariable receives the whole result when an action declares no outputs.
+const ResultVariable = "provisionResult"
+
+// Data is what action templates are rendered against, e.g. {{.Ticket.Title}},
+// {{.Fields.costCenter}} or {{json .Variables}}.
+type Data struct {
+	Ticket    *models.Ticket
+	Fields    map[string]any
+	Variables map[string]any
+}
+
+// Config configures a Runner.
+type Config struct {
+	// HTTPClient sends HTTP actions; http.DefaultClient is used when nil.
+	HTTPClient *http.Client
+	// WorkDir is the parent of the scratch directories commands run in.
+	WorkDir string
+	// Commands lists the executables command actions may run; none are allowed when empty.
+	Commands []string
+	// Hosts lists the hosts HTTP actions may call, including redirects. An entry "*.example.com"
+	// allows every subdomain of example.com. No host is allowed when empty.
+	Hosts []string
+	// Requester sends MQ actions; they fail when it is nil.
+	Requester mq.Requester
+	// Timeout bounds actions that do not set their own.
+	Timeout time.Duration
+}
+
+// Runner executes provision actions.
+type Runner struct {
+	http      *http.Client
+	workDir   string
+	commands  map[string]bool
+	hosts     []string
+	requester mq.Requester
+	timeout   time.Duration
+}
+
+// NewRunner creates a runner from the configuration.
+func NewRunner(cfg Config) *Runner {
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = time.Minute
+	}
+	commands := map[string]bool{}
+	for _, c := range cfg.Commands {
+		commands[c] = true
+	}
+	hosts := make([]string, len(cfg.Hosts))
+	for i, h := range cfg.Hosts {
+		hosts[i] = strings.ToLower(h)
+	}
+	r := &Runner{workDir: cfg.WorkDir, commands: commands, hosts: hosts, requester: cfg.Requester, timeout: cfg.Timeout}
+	// A redirect must not lead an allowed host's response to a host that is not.
+	client := *cfg.HTTPClient
+	redirect := client.CheckRedirect
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if err := r.checkURL(req.URL); err != nil {
+			return err
+		}
+		if redirect != nil {
+			return redirect(req, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	r.http = &client
+	return r
+}
+
+// Run executes the action and returns the process variables picked from its result.
+func (r *Runner) Run(ctx context.Context, action models.ProvisionAction, data Data) (map[string]any, error) {
+	if err := r.Validate(action); err != nil {
+		return nil, err
+	}
+	timeout := r.timeout
+	if action.Timeout != "" {
+		timeout, _ = time.ParseDuration(action.Timeout)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var result map[string]any
+	var err error
+	switch action.Kind {
+	case models.ProvisionKindHTTP:
+		result, err = r.runHTTP(ctx, action.HTTP, data)
+	case models.ProvisionKindCommand:
+		result, err = r.runCommand(ctx, action.Command, data)
+	case models.ProvisionKindMQ:
+		result, err = r.runMQ(ctx, action.MQ, data)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s provision action", action.Kind)
+	}
+	return outputs(result, action.Outputs)
+}
+
+// Validate checks that the action is complete, its templates parse and an HTTP action calls an
+// allowed host.
+func (r *Runner) Validate(action models.ProvisionAction) error {
+	invalid := func(format string, args ...any) error {
+		return errors.Wrap(ErrInvalidAction, fmt.Sprintf(format, args...))
+	}
+	if action.Timeout != "" {
+		if d, err := time.ParseDuration(action.Timeout); err != nil || d <= 0 {
+			return invalid("timeout %q is not a positive duration", action.Timeout)
+		}
+	}
+	var templates []string
+	switch action.Kind {
+	case models.ProvisionKindHTTP:
+		h := action.HTTP
+		if h == nil || h.URL == "" {
+			return invalid("http action needs a url")
+		}
+		if err := r.checkURLTemplate(h.URL); err != nil {
+			return invalid("%v", err)
+		}
+		templates = append(templates, h.URL, h.Body)
+		for _, v := range h.Headers {
+			templates = append(templates, v)
+		}
+	case models.ProvisionKindCommand:
+		c := action.Command
+		if c == nil || c.Command == "" {
+			return invalid("command action needs a command")
+		}
+		templates = append(templates, c.Stdin)
+		templates = append(templates, c.Args...)
+		for _, v := range c.Env {
+			templates = append(templates, v)
+		}
+	case models.ProvisionKindMQ:
+		m := action.MQ
+		if m == nil || m.RoutingKey == "" {
+			return invalid("mq action needs a routing key")
+		}
+		templates = append(templates, m.Body)
+	default:
+		return invalid("unknown kind %q", action.Kind)
+	}
+	for _, text := range templates {
+		if _, err := parse(text); err != nil {
+			return invalid("%v", err)
+		}
+	}
+	for name, path := range action.Outputs {
+		if name == "" || path == "" {
+			return invalid("output %q needs a variable name and a result path", name)
+		}
+	}
+	return nil
+}
+
+// outputs picks the mapped paths out of the result, or returns the whole result as one variable.
+func outputs(result map[string]any, mapping map[string]string) (map[string]any, error) {
+	if len(mapping) == 0 {
+		return map[string]any{ResultVariable: result}, nil
+	}
+	out := make(map[string]any, len(mapping))
+	for name, path := range mapping {
+		var cur any = result
+		for _, part := range strings.Split(path, ".") {
+			obj, ok := cur.(map[string]any)
+			if !ok {
+				return nil, errors.Errorf("output %s: result has no %s", name, path)
+			}
+			if cur, ok = obj[part]; !ok {
+				return nil, errors.Errorf("output %s: result has no %s", name, path)
+			}
+		}
+		out[name] = cur
+	}
+	return out, nil
+}
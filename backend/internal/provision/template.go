@@ -0,0 +1,43 @@
+package provision
+
+import (
+	"encoding/json"
+	"strings"
+	"text/template"
+)
+
+var funcs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+func parse(text string) (*template.Template, error) {
+	return template.New("provision").Funcs(funcs).Option("missingkey=error").Parse(text)
+}
+
+// render executes a template; the empty template renders as "".
+func render(text string, data Data) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+	t, err := parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// decodeBody returns a JSON body as a value and anything else as a string.
+func decodeBody(b []byte) any {
+	var v any
+	if err := json.Unmarshal(b, &v); err == nil {
+		return v
+	}
+	return string(b)
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/example/pflow/backend/internal/models"
+)
+
+// AttachmentRepository persists attachment metadata.
+type AttachmentRepository struct {
+	db *gorm.DB
+}
+
+// NewAttachmentRepository constructs a repository using the provided gorm DB.
+func NewAttachmentRepository(db *gorm.DB) *AttachmentRepository {
+	return &AttachmentRepository{db: db}
+}
+
+// Create persists the attachment metadata.
+func (r *AttachmentRepository) Create(ctx context.Context, attachment *models.Attachment) error {
+	return errors.WithStack(conn(ctx, r.db).Create(attachment).Error)
+}
+
+// FindByID returns an attachment of the ticket by id.
+func (r *AttachmentRepository) FindByID(ctx context.Context, ticketID, id uuid.UUID) (*models.Attachment, error) {
+	var attachment models.Attachment
+	if err := conn(ctx, r.db).First(&attachment, "id = ? AND ticket_id = ?", id, ticketID).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &attachment, nil
+}
+
+// ListByTicket returns the ticket's attachments oldest first.
+func (r *AttachmentRepository) ListByTicket(ctx context.Context, ticketID uuid.UUID) ([]models.Attachment, error) {
+	var attachments []models.Attachment
+	err := conn(ctx, r.db).Where("ticket_id = ?", ticketID).Order("created_at asc").Find(&attachments).Error
+	return attachments, errors.WithStack(err)
+}
+
+// Delete removes the attachment metadata.
+func (r *AttachmentRepository) Delete(ctx context.Context, attachment *models.Attachment) error {
+	return errors.WithStack(conn(ctx, r.db).Delete(attachment).Error)
+}
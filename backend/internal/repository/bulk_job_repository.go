@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/example/pflow/backend/internal/models"
+)
+
+// BulkJobRepository persists background bulk jobs.
+type BulkJobRepository struct {
+	db *gorm.DB
+}
+
+// NewBulkJobRepository constructs a repository using the provided gorm DB.
+func NewBulkJobRepository(db *gorm.DB) *BulkJobRepository {
+	return &BulkJobRepository{db: db}
+}
+
+// Save inserts or updates the job.
+func (r *BulkJobRepository) Save(ctx context.Context, job *models.BulkJob) error {
+	return errors.WithStack(conn(ctx, r.db).Save(job).Error)
+}
+
+// FindByID loads a job of the tenant carried by ctx.
+func (r *BulkJobRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.BulkJob, error) {
+	var job models.BulkJob
+	if err := conn(ctx, r.db).Scopes(tenantScope(ctx)).First(&job, "id = ?", id).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &job, nil
+}
+
+// DeleteFinishedBefore removes jobs that finished before the cutoff and returns how many were removed.
+func (r *BulkJobRepository) DeleteFinishedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := conn(ctx, r.db).Where("finished_at < ?", cutoff).Delete(&models.BulkJob{})
+	return result.RowsAffected, errors.WithStack(result.Error)
+}
+
+// Abandon finishes running jobs without progress since the cutoff with the given status; their
+// instance stopped before completing them. It returns how many jobs were changed.
+func (r *BulkJobRepository) Abandon(ctx context.Context, cutoff time.Time, status string) (int64, error) {
+	result := conn(ctx, r.db).Model(&models.BulkJob{}).
+		Where("finished_at IS NULL AND updated_at < ?", cutoff).
+		Updates(map[string]any{"status": status, "finished_at": time.Now().UTC()})
+	return result.RowsAffected, errors.WithStack(result.Error)
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/example/pflow/backend/internal/models"
+)
+
+// CommentRepository persists ticket comments and their revision history.
+type CommentRepository struct {
+	db *gorm.DB
+}
+
+// NewCommentRepository constructs a repository using the provided gorm DB.
+func NewCommentRepository(db *gorm.DB) *CommentRepository {
+	return &CommentRepository{db: db}
+}
+
+// Create persists the comment.
+func (r *CommentRepository) Create(ctx context.Context, comment *models.Comment) error {
+	return errors.WithStack(conn(ctx, r.db).Create(comment).Error)
+}
+
+// Update stores the edited comment together with a revision holding its previous body.
+func (r *CommentRepository) Update(ctx context.Context, comment *models.Comment, revision *models.CommentRevision) error {
+	return errors.WithStack(conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(revision).Error; err != nil {
+			return err
+		}
+		return tx.Save(comment).Error
+	}))
+}
+
+// Delete soft-deletes the comment and records its last body as a revision.
+func (r *CommentRepository) Delete(ctx context.Context, comment *models.Comment, revision *models.CommentRevision) error {
+	return errors.WithStack(conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(revision).Error; err != nil {
+			return err
+		}
+		return tx.Delete(comment).Error
+	}))
+}
+
+// FindByID returns a comment of the ticket by id, including soft-deleted ones when withDeleted is set.
+func (r *CommentRepository) FindByID(ctx context.Context, ticketID, id uuid.UUID, withDeleted bool) (*models.Comment, error) {
+	query := conn(ctx, r.db)
+	if withDeleted {
+		query = query.Unscoped()
+	}
+	var comment models.Comment
+	if err := query.First(&comment, "id = ? AND ticket_id = ?", id, ticketID).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &comment, nil
+}
+
+// ListByTicket returns the ticket's comments oldest first, optionally hiding internal ones.
+func (r *CommentRepository) ListByTicket(ctx context.Context, ticketID uuid.UUID, includeInternal bool) ([]models.Comment, error) {
+	query := conn(ctx, r.db).Where("ticket_id = ?", ticketID).Order("created_at asc")
+	if !includeInternal {
+		query = query.Where("visibility <> ?", models.CommentVisibilityInternal)
+	}
+	var comments []models.Comment
+	err := query.Find(&comments).Error
+	return comments, errors.WithStack(err)
+}
+
+// ListRevisions returns the edit history of a comment, oldest first.
+func (r *CommentRepository) ListRevisions(ctx context.Context, commentID uuid.UUID) ([]models.CommentRevision, error) {
+	var revisions []models.CommentRevision
+	err := conn(ctx, r.db).Where("comment_id = ?", commentID).Order("id asc").Find(&revisions).Error
+	return revisions, errors.WithStack(err)
+}
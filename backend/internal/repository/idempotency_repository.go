@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/example/pflow/backend/internal/models"
+)
+
+// IdempotencyRepository persists idempotency keys and the responses stored for them.
+type IdempotencyRepository struct {
+	db *gorm.DB
+}
+
+// NewIdempotencyRepository constructs a repository using the provided gorm DB.
+func NewIdempotencyRepository(db *gorm.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// Reserve inserts the record unless its key exists. It reports whether the record was inserted
+// and otherwise returns the stored record, replacing it first when it has expired.
+func (r *IdempotencyRepository) Reserve(ctx context.Context, record *models.IdempotencyRecord) (*models.IdempotencyRecord, bool, error) {
+	db := conn(ctx, r.db)
+	err := db.Where("key = ? AND expires_at <= ?", record.Key, time.Now()).Delete(&models.IdempotencyRecord{}).Error
+	if err != nil {
+		return nil, false, errors.WithStack(err)
+	}
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if result.Error != nil {
+		return nil, false, errors.WithStack(result.Error)
+	}
+	if result.RowsAffected == 1 {
+		return record, true, nil
+	}
+	var existing models.IdempotencyRecord
+	if err := db.First(&existing, "key = ?", record.Key).Error; err != nil {
+		return nil, false, errors.WithStack(err)
+	}
+	return &existing, false, nil
+}
+
+// Complete stores the response of the request that reserved the key.
+func (r *IdempotencyRepository) Complete(ctx context.Context, key string, status int, contentType string, body []byte) error {
+	err := conn(ctx, r.db).Model(&models.IdempotencyRecord{}).Where("key = ?", key).Updates(map[string]any{
+		"status_code":   status,
+		"content_type":  contentType,
+		"response_body": body,
+	}).Error
+	return errors.WithStack(err)
+}
+
+// Delete forgets the key so the request can be retried.
+func (r *IdempotencyRepository) Delete(ctx context.Context, key string) error {
+	return errors.WithStack(conn(ctx, r.db).Delete(&models.IdempotencyRecord{}, "key = ?", key).Error)
+}
+
+// DeleteExpired removes records that expired before now and returns how many were removed.
+func (r *IdempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := conn(ctx, r.db).Where("expires_at <= ?", now).Delete(&models.IdempotencyRecord{})
+	return result.RowsAffected, errors.WithStack(result.Error)
+}
@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/example/pflow/backend/internal/models"
+	"github.com/example/pflow/backend/internal/tenant"
+)
+
+type memoryTxKey struct{}
+
+// MemoryTicketStore keeps tickets in process memory. Transactions are serialised and roll back by
+// restoring a snapshot, which is enough for demos and tests but not for concurrent production use.
+type MemoryTicketStore struct {
+	mu      sync.RWMutex
+	tickets map[uuid.UUID]models.Ticket
+	txMu    sync.Mutex
+}
+
+// NewMemoryTicketStore returns an empty store.
+func NewMemoryTicketStore() *MemoryTicketStore {
+	return &MemoryTicketStore{tickets: map[uuid.UUID]models.Ticket{}}
+}
+
+// Transaction runs fn while holding the store's transaction lock and restores the previous
+// contents when fn fails. Nested calls join the outer transaction.
+func (m *MemoryTicketStore) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(memoryTxKey{}) == m {
+		return fn(ctx)
+	}
+	m.txMu.Lock()
+	defer m.txMu.Unlock()
+
+	m.mu.RLock()
+	snapshot := make(map[uuid.UUID]models.Ticket, len(m.tickets))
+	for id, t := range m.tickets {
+		snapshot[id] = t
+	}
+	m.mu.RUnlock()
+
+	if err := fn(context.WithValue(ctx, memoryTxKey{}, m)); err != nil {
+		m.mu.Lock()
+		m.tickets = snapshot
+		m.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Create stores a new ticket, filling in the defaults GORM hooks would set.
+func (m *MemoryTicketStore) Create(ctx context.Context, ticket *models.Ticket) error {
+	if err := claimTenant(ctx, ticket); err != nil {
+		return err
+	}
+	if err := ticket.BeforeCreate(nil); err != nil {
+		return err
+	}
+	now := time.Now()
+	ticket.CreatedAt, ticket.UpdatedAt = now, now
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, exists := m.tickets[ticket.ID]; exists {
+		return errors.Errorf("ticket %s already exists", ticket.ID)
+	}
+	m.tickets[ticket.ID] = copyTicket(ticket)
+	return nil
+}
+
+// Update replaces the stored ticket.
+func (m *MemoryTicketStore) Update(ctx context.Context, ticket *models.Ticket) error {
+	if err := claimTenant(ctx, ticket); err != nil {
+		return err
+	}
+	ticket.UpdatedAt = time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tickets[ticket.ID] = copyTicket(ticket)
+	return nil
+}
+
+// FindByID returns a copy of the ticket or gorm.ErrRecordNotFound.
+func (m *MemoryTicketStore) FindByID(ctx context.Context, id uuid.UUID) (*models.Ticket, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	ticket, ok := m.tickets[id]
+	if !ok || !visible(ctx, &ticket) {
+		return nil, errors.WithStack(gorm.ErrRecordNotFound)
+	}
+	copied := copyTicket(&ticket)
+	return &copied, nil
+}
+
+// FindByIDForUpdate is FindByID; transactions are already serialised.
+func (m *MemoryTicketStore) FindByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Ticket, error) {
+	return m.FindByID(ctx, id)
+}
+
+// List returns the newest tickets first.
+func (m *MemoryTicketStore) List(ctx context.Context, limit int) ([]models.Ticket, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	return m.Find(ctx, TicketFilter{Limit: limit})
+}
+
+// Find returns the tickets matching the filter ordered like TicketRepository.Find.
+func (m *MemoryTicketStore) Find(ctx context.Context, filter TicketFilter) ([]models.Ticket, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var out []models.Ticket
+	for _, t := range m.tickets {
+		if visible(ctx, &t) && filter.matches(&t) {
+			out = append(out, copyTicket(&t))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if filter.OldestFirst {
+			return out[i].CreatedAt.Before(out[j].CreatedAt)
+		}
+		return out[i].CreatedAt.After(out[j].CreatedAt)
+	})
+	if filter.Limit > 0 && len(out) > filter.Limit {
+		out = out[:filter.Limit]
+	}
+	return out, nil
+}
+
+// CountOpenByAssignee returns how many non-terminal tickets each of the users is assigned to.
+func (m *MemoryTicketStore) CountOpenByAssignee(ctx context.Context, users []string) (map[string]int64, error) {
+	wanted := make(map[string]bool, len(users))
+	for _, u := range users {
+		wanted[u] = true
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	counts := map[string]int64{}
+	for _, t := range m.tickets {
+		if visible(ctx, &t) && wanted[t.Assignee] && slices.Contains(models.ActiveTicketStatuses, t.Status) {
+			counts[t.Assignee]++
+		}
+	}
+	return counts, nil
+}
+
+// visible applies the tenant scoping TicketRepository adds to its queries.
+func visible(ctx context.Context, t *models.Ticket) bool {
+	id := tenant.FromContext(ctx)
+	return id == "" || t.TenantID == id
+}
+
+// matches evaluates the filter the way TicketRepository.Find translates it to SQL.
+func (f TicketFilter) matches(t *models.Ticket) bool {
+	if len(f.IDs) > 0 && !slices.Contains(f.IDs, t.ID) {
+		return false
+	}
+	if len(f.Statuses) > 0 && !slices.Contains(f.Statuses, t.Status) {
+		return false
+	}
+	if len(f.ProcessInstanceIDs) > 0 && !slices.Contains(f.ProcessInstanceIDs, t.ProcessInstanceID) {
+		return false
+	}
+	if f.MigrationPending && t.MigrationBatchID == "" {
+		return false
+	}
+	if !f.CreatedAfter.IsZero() && t.CreatedAt.Before(f.CreatedAfter) {
+		return false
+	}
+	if !f.CreatedBefore.IsZero() && !t.CreatedAt.Before(f.CreatedBefore) {
+		return false
+	}
+	return (f.Type == "" || f.Type == t.Type) &&
+		(f.Requester == "" || f.Requester == t.Requester) &&
+		(f.Assignee == "" || f.Assignee == t.Assignee) &&
+		(f.ProcessDefinitionID == "" || f.ProcessDefinitionID == t.ProcessDefinitionID)
+}
+
+// copyTicket detaches the maps and slices of a ticket so callers cannot change stored state.
+func copyTicket(t *models.Ticket) models.Ticket {
+	copied := *t
+	if t.CustomFields != nil {
+		copied.CustomFields = make(models.JSONMap, len(t.CustomFields))
+		for k, v := range t.CustomFields {
+			copied.CustomFields[k] = v
+		}
+	}
+	copied.Attachments = append([]models.Attachment(nil), t.Attachments...)
+	return copied
+}
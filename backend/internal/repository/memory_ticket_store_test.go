@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/example/pflow/backend/internal/models"
+)
+
+func TestMemoryTicketStoreFindCreatedRangeAndOrder(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryTicketStore()
+	base := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	var tickets []*models.Ticket
+	for day := 0; day < 4; day++ {
+		ticket := &models.Ticket{Title: "t"}
+		if err := store.Create(ctx, ticket); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		ticket.CreatedAt = base.AddDate(0, 0, day)
+		if err := store.Update(ctx, ticket); err != nil {
+			t.Fatalf("update: %v", err)
+		}
+		tickets = append(tickets, ticket)
+	}
+
+	tests := []struct {
+		name   string
+		filter TicketFilter
+		want   []int
+	}{
+		{"newest first", TicketFilter{}, []int{3, 2, 1, 0}},
+		{"oldest first", TicketFilter{OldestFirst: true}, []int{0, 1, 2, 3}},
+		{"after is inclusive", TicketFilter{CreatedAfter: base.AddDate(0, 0, 2)}, []int{3, 2}},
+		{"before is exclusive", TicketFilter{CreatedBefore: base.AddDate(0, 0, 2)}, []int{1, 0}},
+		{"range", TicketFilter{CreatedAfter: base.AddDate(0, 0, 1), CreatedBefore: base.AddDate(0, 0, 3)}, []int{2, 1}},
+		{"oldest with limit", TicketFilter{OldestFirst: true, Limit: 2}, []int{0, 1}},
+		{"newest with limit", TicketFilter{Limit: 2}, []int{3, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.Find(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("find: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d tickets, want %d", len(got), len(tt.want))
+			}
+			for i, idx := range tt.want {
+				if got[i].ID != tickets[idx].ID {
+					t.Errorf("ticket %d is created on day %d, want day %d", i, got[i].CreatedAt.Day()-1, idx)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/example/pflow/backend/internal/models"
+)
+
+// OutOfOfficeRepository persists out-of-office delegation rules.
+type OutOfOfficeRepository struct {
+	db *gorm.DB
+}
+
+// NewOutOfOfficeRepository constructs a repository using the provided gorm DB.
+func NewOutOfOfficeRepository(db *gorm.DB) *OutOfOfficeRepository {
+	return &OutOfOfficeRepository{db: db}
+}
+
+// Save creates the rule or updates it when it already has an id.
+func (r *OutOfOfficeRepository) Save(ctx context.Context, rule *models.OutOfOfficeRule) error {
+	return errors.WithStack(conn(ctx, r.db).Save(rule).Error)
+}
+
+// Delete removes the rule by id.
+func (r *OutOfOfficeRepository) Delete(ctx context.Context, id uint) error {
+	return errors.WithStack(conn(ctx, r.db).Delete(&models.OutOfOfficeRule{}, id).Error)
+}
+
+// List returns the rules of the user, or of everyone when username is empty, that have not ended yet.
+func (r *OutOfOfficeRepository) List(ctx context.Context, username string, now time.Time) ([]models.OutOfOfficeRule, error) {
+	query := conn(ctx, r.db).Where("ends_at > ?", now)
+	if username != "" {
+		query = query.Where("username = ?", username)
+	}
+	var rules []models.OutOfOfficeRule
+	err := query.Order("starts_at, id").Find(&rules).Error
+	return rules, errors.WithStack(err)
+}
+
+// FindActive returns the rule covering the instant for the user, or nil when the user is present.
+func (r *OutOfOfficeRepository) FindActive(ctx context.Context, username string, at time.Time) (*models.OutOfOfficeRule, error) {
+	var rules []models.OutOfOfficeRule
+	err := conn(ctx, r.db).
+		Where("username = ? AND starts_at <= ? AND ends_at > ?", username, at, at).
+		Order("starts_at DESC, id DESC").
+		Limit(1).
+		Find(&rules).Error
+	if err != nil || len(rules) == 0 {
+		return nil, errors.WithStack(err)
+	}
+	return &rules[0], nil
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/example/pflow/backend/internal/models"
+	"github.com/example/pflow/backend/internal/tenant"
+)
+
+// ProcessPinRepository persists process definition version pins.
+type ProcessPinRepository struct {
+	db *gorm.DB
+}
+
+// NewProcessPinRepository constructs a repository using the provided gorm DB.
+func NewProcessPinRepository(db *gorm.DB) *ProcessPinRepository {
+	return &ProcessPinRepository{db: db}
+}
+
+// Save creates or replaces the pin for its process key in the tenant carried by ctx.
+func (r *ProcessPinRepository) Save(ctx context.Context, pin *models.ProcessDefinitionPin) error {
+	pin.TenantID = tenant.FromContext(ctx)
+	return errors.WithStack(conn(ctx, r.db).Save(pin).Error)
+}
+
+// Find returns the pin for the process key, or nil when the key is not pinned.
+func (r *ProcessPinRepository) Find(ctx context.Context, processKey string) (*models.ProcessDefinitionPin, error) {
+	var pin models.ProcessDefinitionPin
+	err := conn(ctx, r.db).First(&pin, "tenant_id = ? AND process_key = ?", tenant.FromContext(ctx), processKey).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &pin, nil
+}
+
+// Delete removes the pin so new tickets start with the latest version again.
+func (r *ProcessPinRepository) Delete(ctx context.Context, processKey string) error {
+	return errors.WithStack(conn(ctx, r.db).Delete(&models.ProcessDefinitionPin{}, "tenant_id = ? AND process_key = ?", tenant.FromContext(ctx), processKey).Error)
+}
+
+// List returns the pins of the tenant carried by ctx ordered by process key.
+func (r *ProcessPinRepository) List(ctx context.Context) ([]models.ProcessDefinitionPin, error) {
+	var pins []models.ProcessDefinitionPin
+	err := conn(ctx, r.db).Where("tenant_id = ?", tenant.FromContext(ctx)).Order("process_key").Find(&pins).Error
+	return pins, errors.WithStack(err)
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/example/pflow/backend/internal/models"
+)
+
+// RoutingRuleRepository persists approver routing rules.
+type RoutingRuleRepository struct {
+	db *gorm.DB
+}
+
+// NewRoutingRuleRepository constructs a repository using the provided gorm DB.
+func NewRoutingRuleRepository(db *gorm.DB) *RoutingRuleRepository {
+	return &RoutingRuleRepository{db: db}
+}
+
+// Save creates the rule or updates it when it already has an id. The round-robin cursor is kept.
+func (r *RoutingRuleRepository) Save(ctx context.Context, rule *models.RoutingRule) error {
+	if rule.ID == 0 {
+		return errors.WithStack(conn(ctx, r.db).Create(rule).Error)
+	}
+	return errors.WithStack(conn(ctx, r.db).Omit("round_robin_cursor").Save(rule).Error)
+}
+
+// Delete removes the rule by id.
+func (r *RoutingRuleRepository) Delete(ctx context.Context, id uint) error {
+	return errors.WithStack(conn(ctx, r.db).Delete(&models.RoutingRule{}, id).Error)
+}
+
+// List returns all rules in evaluation order.
+func (r *RoutingRuleRepository) List(ctx context.Context) ([]models.RoutingRule, error) {
+	var rules []models.RoutingRule
+	err := conn(ctx, r.db).Order("position, id").Find(&rules).Error
+	return rules, errors.WithStack(err)
+}
+
+// NextCursor atomically advances the round-robin cursor of the rule and returns the new value.
+func (r *RoutingRuleRepository) NextCursor(ctx context.Context, id uint) (int, error) {
+	var rule models.RoutingRule
+	err := conn(ctx, r.db).Model(&rule).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "round_robin_cursor"}}}).
+		Where("id = ?", id).
+		UpdateColumn("round_robin_cursor", gorm.Expr("round_robin_cursor + 1")).Error
+	return rule.RoundRobinCursor, errors.WithStack(err)
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/example/pflow/backend/internal/models"
+)
+
+// SLAPolicyRepository persists SLA policies.
+type SLAPolicyRepository struct {
+	db *gorm.DB
+}
+
+// NewSLAPolicyRepository constructs a repository using the provided gorm DB.
+func NewSLAPolicyRepository(db *gorm.DB) *SLAPolicyRepository {
+	return &SLAPolicyRepository{db: db}
+}
+
+// Save creates the policy or updates it when it already has an id.
+func (r *SLAPolicyRepository) Save(ctx context.Context, policy *models.SLAPolicy) error {
+	return errors.WithStack(conn(ctx, r.db).Save(policy).Error)
+}
+
+// Delete removes the policy by id.
+func (r *SLAPolicyRepository) Delete(ctx context.Context, id uint) error {
+	return errors.WithStack(conn(ctx, r.db).Delete(&models.SLAPolicy{}, id).Error)
+}
+
+// List returns all policies ordered by id.
+func (r *SLAPolicyRepository) List(ctx context.Context) ([]models.SLAPolicy, error) {
+	var policies []models.SLAPolicy
+	err := conn(ctx, r.db).Order("id").Find(&policies).Error
+	return policies, errors.WithStack(err)
+}
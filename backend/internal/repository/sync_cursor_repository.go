@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/example/pflow/backend/internal/models"
+)
+
+// SyncCursorRepository persists the positions of background sync jobs.
+type SyncCursorRepository struct {
+	db *gorm.DB
+}
+
+// NewSyncCursorRepository constructs a repository using the provided gorm DB.
+func NewSyncCursorRepository(db *gorm.DB) *SyncCursorRepository {
+	return &SyncCursorRepository{db: db}
+}
+
+// Position returns the stored position of the named cursor, or the zero time when it has none.
+func (r *SyncCursorRepository) Position(ctx context.Context, name string) (time.Time, error) {
+	var cursor models.SyncCursor
+	err := conn(ctx, r.db).First(&cursor, "name = ?", name).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, errors.WithStack(err)
+	}
+	return cursor.Position, nil
+}
+
+// Save stores the position of the named cursor.
+func (r *SyncCursorRepository) Save(ctx context.Context, name string, position time.Time) error {
+	cursor := models.SyncCursor{Name: name, Position: position}
+	err := conn(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"position", "updated_at"}),
+	}).Create(&cursor).Error
+	return errors.WithStack(err)
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/example/pflow/backend/internal/models"
+	"github.com/example/pflow/backend/internal/tenant"
+)
+
+// tenantScope restricts a ticket query to the tenant carried by ctx. Without a tenant the query is
+// unrestricted, which background jobs acting for every tenant rely on.
+func tenantScope(ctx context.Context) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if id := tenant.FromContext(ctx); id != "" {
+			return db.Where("tenant_id = ?", id)
+		}
+		return db
+	}
+}
+
+// claimTenant assigns a new ticket to the tenant carried by ctx and rejects writes of tickets that
+// belong to another tenant.
+func claimTenant(ctx context.Context, ticket *models.Ticket) error {
+	id := tenant.FromContext(ctx)
+	switch {
+	case id == "":
+		return nil
+	case ticket.TenantID == "":
+		ticket.TenantID = id
+	case ticket.TenantID != id:
+		return errors.WithStack(gorm.ErrRecordNotFound)
+	}
+	return nil
+}
@@ -2,10 +2,12 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/example/pflow/backend/internal/models"
 )
@@ -20,20 +22,46 @@ func NewTicketRepository(db *gorm.DB) *TicketRepository {
 	return &TicketRepository{db: db}
 }
 
+// Transaction runs fn in a database transaction that every GORM repository called with the
+// context passed to fn joins.
+func (r *TicketRepository) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return Transaction(ctx, r.db, fn)
+}
+
 // Create persists the ticket instance.
 func (r *TicketRepository) Create(ctx context.Context, ticket *models.Ticket) error {
-	return errors.WithStack(r.db.WithContext(ctx).Create(ticket).Error)
+	if err := claimTenant(ctx, ticket); err != nil {
+		return err
+	}
+	return errors.WithStack(conn(ctx, r.db).Create(ticket).Error)
 }
 
-// Update persists the modified ticket.
+// Update persists the modified ticket. Associations such as attachments are managed by their own repositories.
 func (r *TicketRepository) Update(ctx context.Context, ticket *models.Ticket) error {
-	return errors.WithStack(r.db.WithContext(ctx).Save(ticket).Error)
+	if err := claimTenant(ctx, ticket); err != nil {
+		return err
+	}
+	return errors.WithStack(conn(ctx, r.db).Omit(clause.Associations).Save(ticket).Error)
 }
 
-// FindByID returns the ticket by id.
+// FindByID returns the ticket by id. Like every query of the repository it only sees the tickets of
+// the tenant carried by ctx.
 func (r *TicketRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.Ticket, error) {
 	var ticket models.Ticket
-	if err := r.db.WithContext(ctx).First(&ticket, "id = ?", id).Error; err != nil {
+	if err := conn(ctx, r.db).Scopes(tenantScope(ctx)).Preload("Attachments").First(&ticket, "id = ?", id).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &ticket, nil
+}
+
+// FindByIDForUpdate loads the ticket and locks its row until the surrounding transaction ends.
+func (r *TicketRepository) FindByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Ticket, error) {
+	var ticket models.Ticket
+	err := conn(ctx, r.db).Scopes(tenantScope(ctx)).Clauses(clause.Locking{Strength: "UPDATE"}).First(&ticket, "id = ?", id).Error
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := conn(ctx, r.db).Model(&ticket).Association("Attachments").Find(&ticket.Attachments); err != nil {
 		return nil, errors.WithStack(err)
 	}
 	return &ticket, nil
@@ -45,6 +73,93 @@ func (r *TicketRepository) List(ctx context.Context, limit int) ([]models.Ticket
 		limit = 50
 	}
 	var tickets []models.Ticket
-	err := r.db.WithContext(ctx).Order("created_at desc").Limit(limit).Find(&tickets).Error
+	err := conn(ctx, r.db).Scopes(tenantScope(ctx)).Preload("Attachments").Order("created_at desc").Limit(limit).Find(&tickets).Error
+	return tickets, errors.WithStack(err)
+}
+
+// TicketFilter narrows ticket queries; zero-valued fields are ignored.
+type TicketFilter struct {
+	IDs                 []uuid.UUID
+	Statuses            []models.TicketStatus
+	Type                string
+	Requester           string
+	Assignee            string
+	ProcessDefinitionID string
+	ProcessInstanceIDs  []string
+	// MigrationPending selects tickets whose process instance awaits a migration batch.
+	MigrationPending bool
+	// CreatedAfter and CreatedBefore bound the creation time, inclusive and exclusive.
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	// OldestFirst orders by creation time ascending instead of newest first.
+	OldestFirst bool
+	// Limit caps the number of results; zero means unlimited.
+	Limit int
+}
+
+// Find returns the tickets matching the filter ordered by creation time, newest first unless
+// filter.OldestFirst is set.
+func (r *TicketRepository) Find(ctx context.Context, filter TicketFilter) ([]models.Ticket, error) {
+	order := "created_at desc"
+	if filter.OldestFirst {
+		order = "created_at asc"
+	}
+	query := conn(ctx, r.db).Scopes(tenantScope(ctx)).Order(order)
+	if len(filter.IDs) > 0 {
+		query = query.Where("id IN ?", filter.IDs)
+	}
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.Requester != "" {
+		query = query.Where("requester = ?", filter.Requester)
+	}
+	if filter.Assignee != "" {
+		query = query.Where("assignee = ?", filter.Assignee)
+	}
+	if filter.ProcessDefinitionID != "" {
+		query = query.Where("process_definition_id = ?", filter.ProcessDefinitionID)
+	}
+	if len(filter.ProcessInstanceIDs) > 0 {
+		query = query.Where("process_instance_id IN ?", filter.ProcessInstanceIDs)
+	}
+	if filter.MigrationPending {
+		query = query.Where("migration_batch_id <> ''")
+	}
+	if !filter.CreatedAfter.IsZero() {
+		query = query.Where("created_at >= ?", filter.CreatedAfter)
+	}
+	if !filter.CreatedBefore.IsZero() {
+		query = query.Where("created_at < ?", filter.CreatedBefore)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	var tickets []models.Ticket
+	err := query.Find(&tickets).Error
 	return tickets, errors.WithStack(err)
 }
+
+// CountOpenByAssignee returns how many non-terminal tickets each of the users is assigned to.
+func (r *TicketRepository) CountOpenByAssignee(ctx context.Context, users []string) (map[string]int64, error) {
+	var rows []struct {
+		Assignee string
+		Count    int64
+	}
+	err := conn(ctx, r.db).Model(&models.Ticket{}).Scopes(tenantScope(ctx)).
+		Select("assignee, count(*) as count").
+		Where("assignee IN ? AND status IN ?", users, models.ActiveTicketStatuses).
+		Group("assignee").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Assignee] = row.Count
+	}
+	return counts, nil
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/example/pflow/backend/internal/models"
+)
+
+// TicketStore persists tickets. TicketRepository implements it on top of GORM and MemoryTicketStore
+// keeps tickets in process memory for demos and tests.
+type TicketStore interface {
+	Create(ctx context.Context, ticket *models.Ticket) error
+	Update(ctx context.Context, ticket *models.Ticket) error
+	FindByID(ctx context.Context, id uuid.UUID) (*models.Ticket, error)
+	// FindByIDForUpdate loads the ticket and keeps other transactions from changing it until the
+	// transaction carried by ctx ends.
+	FindByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Ticket, error)
+	List(ctx context.Context, limit int) ([]models.Ticket, error)
+	Find(ctx context.Context, filter TicketFilter) ([]models.Ticket, error)
+	CountOpenByAssignee(ctx context.Context, users []string) (map[string]int64, error)
+	// Transaction runs fn atomically; stores called with the context passed to fn take part in it.
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+var (
+	_ TicketStore = (*TicketRepository)(nil)
+	_ TicketStore = (*MemoryTicketStore)(nil)
+)
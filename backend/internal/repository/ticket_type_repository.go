@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/example/pflow/backend/internal/models"
+)
+
+// TicketTypeRepository persists ticket type definitions.
+type TicketTypeRepository struct {
+	db *gorm.DB
+}
+
+// NewTicketTypeRepository constructs a repository using the provided gorm DB.
+func NewTicketTypeRepository(db *gorm.DB) *TicketTypeRepository {
+	return &TicketTypeRepository{db: db}
+}
+
+// Create persists a new ticket type.
+func (r *TicketTypeRepository) Create(ctx context.Context, ticketType *models.TicketType) error {
+	return errors.WithStack(conn(ctx, r.db).Create(ticketType).Error)
+}
+
+// Update persists the modified ticket type.
+func (r *TicketTypeRepository) Update(ctx context.Context, ticketType *models.TicketType) error {
+	return errors.WithStack(conn(ctx, r.db).Save(ticketType).Error)
+}
+
+// FindByKey returns the ticket type by key.
+func (r *TicketTypeRepository) FindByKey(ctx context.Context, key string) (*models.TicketType, error) {
+	var ticketType models.TicketType
+	if err := conn(ctx, r.db).First(&ticketType, "key = ?", key).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &ticketType, nil
+}
+
+// Delete removes the ticket type by key.
+func (r *TicketTypeRepository) Delete(ctx context.Context, key string) error {
+	return errors.WithStack(conn(ctx, r.db).Delete(&models.TicketType{}, "key = ?", key).Error)
+}
+
+// List returns all ticket types ordered by key.
+func (r *TicketTypeRepository) List(ctx context.Context) ([]models.TicketType, error) {
+	var types []models.TicketType
+	err := conn(ctx, r.db).Order("key").Find(&types).Error
+	return types, errors.WithStack(err)
+}
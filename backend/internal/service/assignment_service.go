@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/example/pflow/backend/internal/models"
+	"github.com/example/pflow/backend/internal/mq"
+	"github.com/example/pflow/backend/internal/repository"
+	"github.com/example/pflow/backend/internal/workflow"
+)
+
+// maxDeputyHops bounds how far out-of-office forwarding follows deputies who are away themselves.
+const maxDeputyHops = 5
+
+var (
+	// ErrNoOpenTask is returned when the ticket has no user task waiting in Camunda.
+	ErrNoOpenTask = errors.New("ticket has no open task")
+	// ErrTaskConflict is returned when the task's current assignee does not allow the change.
+	ErrTaskConflict = errors.New("task assignment conflict")
+)
+
+// AssignmentService changes who works on a ticket's open task, keeping Camunda and the ticket in sync.
+type AssignmentService struct {
+	tickets     repository.TicketStore
+	outOfOffice *repository.OutOfOfficeRepository
+	camunda     *workflow.CamundaClient
+	mq          mq.Publisher
+}
+
+// NewAssignmentService builds a service with dependencies.
+func NewAssignmentService(tickets repository.TicketStore, outOfOffice *repository.OutOfOfficeRepository, camunda *workflow.CamundaClient, mq mq.Publisher) *AssignmentService {
+	return &AssignmentService{tickets: tickets, outOfOffice: outOfOffice, camunda: camunda, mq: mq}
+}
+
+// Assign gives the ticket's open task to the user, or to their deputy when the user is out of office.
+func (s *AssignmentService) Assign(ctx context.Context, ticketID uuid.UUID, assignee, actor string) (*models.Ticket, error) {
+	if assignee == "" {
+		return nil, errors.Wrap(ErrInvalidTicket, "assignee is required")
+	}
+	ticket, task, err := s.openTask(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.Deputy(ctx, assignee, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.camunda.SetTaskAssignee(ctx, task.ID, target); err != nil {
+		return nil, err
+	}
+	extra := map[string]any{"forwardedFrom": ""}
+	if target != assignee {
+		extra["forwardedFrom"] = assignee
+	}
+	return ticket, s.record(ctx, "ticket.assigned", ticket, target, actor, extra)
+}
+
+// Claim makes the user the assignee of the ticket's open task if nobody else holds it.
+func (s *AssignmentService) Claim(ctx context.Context, ticketID uuid.UUID, user string) (*models.Ticket, error) {
+	if user == "" {
+		return nil, errors.Wrap(ErrInvalidTicket, "user is required")
+	}
+	ticket, task, err := s.openTask(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	if task.Assignee != "" && task.Assignee != user {
+		return nil, errors.Wrapf(ErrTaskConflict, "task is already claimed by %s", task.Assignee)
+	}
+	if task.Assignee != user {
+		if err := s.camunda.ClaimTask(ctx, task.ID, user); err != nil {
+			return nil, err
+		}
+	}
+	return ticket, s.record(ctx, "ticket.claimed", ticket, user, user, nil)
+}
+
+// Unclaim returns the ticket's open task to its candidate users and groups.
+func (s *AssignmentService) Unclaim(ctx context.Context, ticketID uuid.UUID, actor string) (*models.Ticket, error) {
+	ticket, task, err := s.openTask(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	if task.DelegationState == "PENDING" {
+		return nil, errors.Wrap(ErrTaskConflict, "a delegated task must be resolved before it is released")
+	}
+	if err := s.camunda.UnclaimTask(ctx, task.ID); err != nil {
+		return nil, err
+	}
+	return ticket, s.record(ctx, "ticket.unclaimed", ticket, "", actor, nil)
+}
+
+// Delegate hands the assigned task to another user, who resolves it back to the current assignee.
+func (s *AssignmentService) Delegate(ctx context.Context, ticketID uuid.UUID, delegate, actor string) (*models.Ticket, error) {
+	if delegate == "" {
+		return nil, errors.Wrap(ErrInvalidTicket, "delegate is required")
+	}
+	ticket, task, err := s.openTask(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	if task.Assignee == "" {
+		return nil, errors.Wrap(ErrTaskConflict, "only an assigned task can be delegated")
+	}
+	target, err := s.Deputy(ctx, delegate, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.camunda.DelegateTask(ctx, task.ID, target); err != nil {
+		return nil, err
+	}
+	return ticket, s.record(ctx, "ticket.delegated", ticket, target, actor, map[string]any{"owner": task.Assignee})
+}
+
+// Resolve returns a delegated task to the user who delegated it.
+func (s *AssignmentService) Resolve(ctx context.Context, ticketID uuid.UUID, actor string) (*models.Ticket, error) {
+	ticket, task, err := s.openTask(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	if task.DelegationState != "PENDING" {
+		return nil, errors.Wrap(ErrTaskConflict, "task is not delegated")
+	}
+	if err := s.camunda.ResolveTask(ctx, task.ID, nil); err != nil {
+		return nil, err
+	}
+	return ticket, s.record(ctx, "ticket.delegation_resolved", ticket, task.Owner, actor, nil)
+}
+
+// Deputy returns who should receive tasks for the user at the given instant, following
+// out-of-office rules through deputies who are away themselves.
+func (s *AssignmentService) Deputy(ctx context.Context, user string, at time.Time) (string, error) {
+	seen := map[string]bool{user: true}
+	current := user
+	for i := 0; i < maxDeputyHops; i++ {
+		rule, err := s.outOfOffice.FindActive(ctx, current, at)
+		if err != nil {
+			return "", err
+		}
+		if rule == nil || seen[rule.Deputy] {
+			return current, nil
+		}
+		seen[rule.Deputy] = true
+		current = rule.Deputy
+	}
+	return current, nil
+}
+
+// ListOutOfOffice returns the rules that have not ended yet, optionally for a single user.
+func (s *AssignmentService) ListOutOfOffice(ctx context.Context, username string) ([]models.OutOfOfficeRule, error) {
+	return s.outOfOffice.List(ctx, username, time.Now())
+}
+
+// SaveOutOfOffice validates and stores an out-of-office rule.
+func (s *AssignmentService) SaveOutOfOffice(ctx context.Context, rule *models.OutOfOfficeRule) error {
+	if rule.Username == "" || rule.Deputy == "" {
+		return errors.Wrap(ErrInvalidTicket, "username and deputy are required")
+	}
+	if rule.Username == rule.Deputy {
+		return errors.Wrap(ErrInvalidTicket, "a user cannot be their own deputy")
+	}
+	if !rule.EndsAt.After(rule.StartsAt) {
+		return errors.Wrap(ErrInvalidTicket, "endsAt must be after startsAt")
+	}
+	return s.outOfOffice.Save(ctx, rule)
+}
+
+// DeleteOutOfOffice removes an out-of-office rule.
+func (s *AssignmentService) DeleteOutOfOffice(ctx context.Context, id uint) error {
+	return s.outOfOffice.Delete(ctx, id)
+}
+
+// openTask returns the ticket with its open approval task.
+func (s *AssignmentService) openTask(ctx context.Context, ticketID uuid.UUID) (*models.Ticket, *workflow.Task, error) {
+	ticket, err := s.tickets.FindByID(ctx, ticketID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if ticket.ProcessInstanceID == "" || ticket.Status.Terminal() {
+		return nil, nil, errors.Wrapf(ErrNoOpenTask, "ticket %s", ticket.ID)
+	}
+	task, err := approvalTask(ctx, s.camunda, ticket.ProcessInstanceID)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "ticket %s", ticket.ID)
+	}
+	return ticket, task, nil
+}
+
+// record stores the new assignee on the ticket and publishes the change.
+func (s *AssignmentService) record(ctx context.Context, event string, ticket *models.Ticket, assignee, actor string, extra map[string]any) error {
+	previous := ticket.Assignee
+	ticket.Assignee = assignee
+	if err := s.tickets.Update(ctx, ticket); err != nil {
+		return err
+	}
+	if s.mq == nil {
+		return nil
+	}
+	payload := ticketEvent(event, ticket)
+	payload["previousAssignee"] = previous
+	payload["actor"] = actor
+	for k, v := range extra {
+		payload[k] = v
+	}
+	if err := s.mq.Publish(ctx, mq.RoutingKey(ticket.TenantID, event), payload); err != nil {
+		log.Printf("publish %s failed: %v", event, err)
+	}
+	return nil
+}
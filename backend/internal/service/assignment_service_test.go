@@ -0,0 +1,64 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/example/pflow/backend/internal/models"
+	"github.com/example/pflow/backend/internal/service"
+	"github.com/example/pflow/backend/internal/workflow"
+	"github.com/example/pflow/backend/internal/workflow/camundatest"
+)
+
+// submitWithTasks submits a ticket whose process instance opens user tasks with the given keys.
+func submitWithTasks(t *testing.T, keys ...string) (*service.AssignmentService, *camundatest.Server, *models.Ticket) {
+	t.Helper()
+	a, engine := newTestApp(t)
+	engine.OnStart(func(i camundatest.Instance) {
+		for _, key := range keys {
+			engine.AddUserTask(i.ID, camundatest.UserTask{Task: workflow.Task{TaskDefinitionKey: key}})
+		}
+	})
+	ctx := context.Background()
+	ticket := &models.Ticket{Title: "new laptop", Requester: "alice"}
+	if err := a.Workflow.CreateTicket(ctx, ticket); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := a.Workflow.SubmitTicket(ctx, ticket.ID); err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	return a.Assignments, engine, ticket
+}
+
+func TestClaimTakesTheApprovalTask(t *testing.T) {
+	assignments, engine, ticket := submitWithTasks(t, "UserTask_Other", service.ApprovalActivityID)
+	if _, err := assignments.Claim(context.Background(), ticket.ID, "bob"); err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	for _, task := range engine.UserTasks() {
+		want := ""
+		if task.TaskDefinitionKey == service.ApprovalActivityID {
+			want = "bob"
+		}
+		if task.Assignee != want {
+			t.Errorf("task %s assignee = %q, want %q", task.TaskDefinitionKey, task.Assignee, want)
+		}
+	}
+}
+
+func TestClaimRefusesAmbiguousApprovalTasks(t *testing.T) {
+	assignments, _, ticket := submitWithTasks(t, service.ApprovalActivityID, service.ApprovalActivityID)
+	_, err := assignments.Claim(context.Background(), ticket.ID, "bob")
+	if !errors.Is(err, service.ErrTaskConflict) {
+		t.Fatalf("err = %v, want ErrTaskConflict", err)
+	}
+}
+
+func TestClaimWithoutApprovalTask(t *testing.T) {
+	assignments, _, ticket := submitWithTasks(t, "UserTask_Other")
+	_, err := assignments.Claim(context.Background(), ticket.ID, "bob")
+	if !errors.Is(err, service.ErrNoOpenTask) {
+		t.Fatalf("err = %v, want ErrNoOpenTask", err)
+	}
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/example/pflow/backend/internal/models"
+	"github.com/example/pflow/backend/internal/mq"
+	"github.com/example/pflow/backend/internal/repository"
+	"github.com/example/pflow/backend/internal/storage"
+)
+
+// ErrAttachmentRejected marks uploads refused by the size or type limits.
+var ErrAttachmentRejected = errors.New("attachment rejected")
+
+// AttachmentLimits restricts what may be uploaded.
+type AttachmentLimits struct {
+	MaxSize int64
+	// AllowedTypes lists media types such as "application/pdf" or wildcards such as "image/*".
+	AllowedTypes []string
+}
+
+// AttachmentUpload is a file being attached to a ticket.
+type AttachmentUpload struct {
+	FileName    string
+	ContentType string
+	Size        int64
+	UploadedBy  string
+	Body        io.Reader
+}
+
+// AttachmentService stores ticket attachments in a blob store and tracks their metadata.
+type AttachmentService struct {
+	attachments *repository.AttachmentRepository
+	tickets     repository.TicketStore
+	blobs       storage.BlobStore
+	mq          mq.Publisher
+	limits      AttachmentLimits
+}
+
+// NewAttachmentService builds a service with dependencies.
+func NewAttachmentService(attachments *repository.AttachmentRepository, tickets repository.TicketStore, blobs storage.BlobStore, mq mq.Publisher, limits AttachmentLimits) *AttachmentService {
+	return &AttachmentService{attachments: attachments, tickets: tickets, blobs: blobs, mq: mq, limits: limits}
+}
+
+// MaxSize returns the largest file accepted; zero means unlimited.
+func (s *AttachmentService) MaxSize() int64 {
+	return s.limits.MaxSize
+}
+
+// Upload validates and streams the file into the blob store, recording its SHA-256 checksum.
+func (s *AttachmentService) Upload(ctx context.Context, ticketID uuid.UUID, upload AttachmentUpload) (*models.Attachment, error) {
+	ticket, err := s.tickets.FindByID(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	if upload.Size <= 0 {
+		return nil, errors.Wrap(ErrAttachmentRejected, "file is empty")
+	}
+	if s.limits.MaxSize > 0 && upload.Size > s.limits.MaxSize {
+		return nil, errors.Wrapf(ErrAttachmentRejected, "file exceeds %d bytes", s.limits.MaxSize)
+	}
+
+	body := bufio.NewReader(upload.Body)
+	head, _ := body.Peek(512)
+	contentType := effectiveContentType(http.DetectContentType(head), upload.ContentType)
+	if !s.allowed(contentType) {
+		return nil, errors.Wrapf(ErrAttachmentRejected, "content type %s is not allowed", contentType)
+	}
+
+	attachment := &models.Attachment{
+		ID:          uuid.New(),
+		TicketID:    ticket.ID,
+		FileName:    path.Base(strings.ReplaceAll(upload.FileName, "\\", "/")),
+		ContentType: contentType,
+		Size:        upload.Size,
+		UploadedBy:  upload.UploadedBy,
+	}
+	attachment.StorageKey = "tickets/" + ticket.ID.String() + "/" + attachment.ID.String()
+
+	hash := sha256.New()
+	if err := s.blobs.Put(ctx, attachment.StorageKey, io.TeeReader(io.LimitReader(body, upload.Size), hash), upload.Size, contentType); err != nil {
+		return nil, errors.Wrap(err, "store attachment")
+	}
+	attachment.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	if err := s.attachments.Create(ctx, attachment); err != nil {
+		if delErr := s.blobs.Delete(ctx, attachment.StorageKey); delErr != nil {
+			log.Printf("remove orphaned blob %s failed: %v", attachment.StorageKey, delErr)
+		}
+		return nil, err
+	}
+
+	if s.mq != nil {
+		payload := ticketEvent("ticket.attachment_added", ticket)
+		payload["attachmentId"] = attachment.ID.String()
+		payload["fileName"] = attachment.FileName
+		payload["sha256"] = attachment.SHA256
+		if err := s.mq.Publish(ctx, mq.RoutingKey(ticket.TenantID, "ticket.attachment_added"), payload); err != nil {
+			log.Printf("publish ticket.attachment_added failed: %v", err)
+		}
+	}
+	return attachment, nil
+}
+
+// List returns the metadata of the ticket's attachments.
+func (s *AttachmentService) List(ctx context.Context, ticketID uuid.UUID) ([]models.Attachment, error) {
+	if _, err := s.tickets.FindByID(ctx, ticketID); err != nil {
+		return nil, err
+	}
+	return s.attachments.ListByTicket(ctx, ticketID)
+}
+
+// Open returns the attachment metadata and a reader over its content.
+func (s *AttachmentService) Open(ctx context.Context, ticketID, id uuid.UUID) (*models.Attachment, io.ReadCloser, error) {
+	if _, err := s.tickets.FindByID(ctx, ticketID); err != nil {
+		return nil, nil, err
+	}
+	attachment, err := s.attachments.FindByID(ctx, ticketID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	body, err := s.blobs.Get(ctx, attachment.StorageKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return attachment, body, nil
+}
+
+// Delete removes the attachment metadata and its blob.
+func (s *AttachmentService) Delete(ctx context.Context, ticketID, id uuid.UUID) error {
+	if _, err := s.tickets.FindByID(ctx, ticketID); err != nil {
+		return err
+	}
+	attachment, err := s.attachments.FindByID(ctx, ticketID, id)
+	if err != nil {
+		return err
+	}
+	if err := s.attachments.Delete(ctx, attachment); err != nil {
+		return err
+	}
+	if err := s.blobs.Delete(ctx, attachment.StorageKey); err != nil {
+		log.Printf("remove blob %s failed: %v", attachment.StorageKey, err)
+	}
+	return nil
+}
+
+func (s *AttachmentService) allowed(contentType string) bool {
+	if len(s.limits.AllowedTypes) == 0 {
+		return true
+	}
+	for _, allowed := range s.limits.AllowedTypes {
+		if allowed == contentType {
+			return true
+		}
+		if strings.HasSuffix(allowed, "/*") && strings.HasPrefix(contentType, strings.TrimSuffix(allowed, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// effectiveContentType prefers the sniffed media type. The client's declared type is only used when
+// sniffing yields a generic answer and the declared type is not a media format sniffing would have
+// recognised, so a renamed executable cannot pass as an image.
+func effectiveContentType(sniffed, declared string) string {
+	sniffedBase, _, _ := mime.ParseMediaType(sniffed)
+	declaredBase, _, _ := mime.ParseMediaType(declared)
+	generic := sniffedBase == "application/octet-stream" || sniffedBase == "text/plain"
+	media := strings.HasPrefix(declaredBase, "image/") || strings.HasPrefix(declaredBase, "audio/") || strings.HasPrefix(declaredBase, "video/")
+	if declaredBase != "" && generic && !media {
+		return declaredBase
+	}
+	return sniffedBase
+}
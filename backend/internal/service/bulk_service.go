@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/example/pflow/backend/internal/models"
+	"github.com/example/pflow/backend/internal/repository"
+	"github.com/example/pflow/backend/internal/tenant"
+)
+
+// Bulk actions accepted by BulkService.
+const (
+	BulkActionSubmit   = "submit"
+	BulkActionApprove  = "approve"
+	BulkActionReject   = "reject"
+	BulkActionCancel   = "cancel"
+	BulkActionReassign = "reassign"
+)
+
+// Bulk job states. A job is interrupted when the instance running it stopped before finishing.
+const (
+	BulkJobRunning     = "running"
+	BulkJobCompleted   = "completed"
+	BulkJobInterrupted = "interrupted"
+)
+
+// bulkJobRetention is how long finished jobs stay available for polling; a running job that made
+// no progress for bulkJobStaleAfter is taken as interrupted.
+const (
+	bulkJobRetention  = time.Hour
+	bulkJobStaleAfter = 15 * time.Minute
+)
+
+// ErrBulkJobNotFound is returned for unknown or expired bulk job ids.
+var ErrBulkJobNotFound = errors.New("bulk job not found")
+
+// BulkRequest applies one action to the tickets listed in IDs, or to those matching Filter.
+type BulkRequest struct {
+	Action   string
+	IDs      []uuid.UUID
+	Filter   repository.TicketFilter
+	Actor    string
+	Comment  string
+	Assignee string
+	// Async forces a background job even for small selections.
+	Async bool
+}
+
+// BulkResult is the outcome of the action for a single ticket.
+type BulkResult = models.BulkResult
+
+// BulkJob reports the progress of a bulk action.
+type BulkJob = models.BulkJob
+
+// BulkService runs ticket actions over many tickets with bounded concurrency.
+type BulkService struct {
+	workflow    *WorkflowService
+	assignments *AssignmentService
+	tickets     repository.TicketStore
+	jobs        *repository.BulkJobRepository
+	concurrency int
+	syncLimit   int
+
+	// mu guards the progress of running jobs; saveMu orders their writes to jobs.
+	mu     sync.Mutex
+	saveMu sync.Mutex
+}
+
+// NewBulkService builds a service with dependencies. Selections larger than syncLimit run as
+// background jobs, whose progress is stored in jobs; at most concurrency tickets are processed at once.
+func NewBulkService(workflow *WorkflowService, assignments *AssignmentService, tickets repository.TicketStore, jobs *repository.BulkJobRepository, concurrency, syncLimit int) *BulkService {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	return &BulkService{
+		workflow:    workflow,
+		assignments: assignments,
+		tickets:     tickets,
+		jobs:        jobs,
+		concurrency: concurrency,
+		syncLimit:   syncLimit,
+	}
+}
+
+// Run executes the request. The returned job is finished unless async is true, in which case the
+// caller polls Job for progress.
+func (s *BulkService) Run(ctx context.Context, req BulkRequest) (job *BulkJob, async bool, err error) {
+	apply, err := s.action(req)
+	if err != nil {
+		return nil, false, err
+	}
+	ids := req.IDs
+	if len(ids) == 0 {
+		if filterIsEmpty(req.Filter) {
+			return nil, false, errors.Wrap(ErrInvalidTicket, "ids or a filter are required")
+		}
+		tickets, err := s.tickets.Find(ctx, req.Filter)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, t := range tickets {
+			ids = append(ids, t.ID)
+		}
+	}
+	job = &BulkJob{ID: uuid.New(), Action: req.Action, TenantID: tenant.FromContext(ctx), Status: BulkJobRunning, Total: len(ids), StartedAt: time.Now().UTC()}
+	if !req.Async && len(ids) <= s.syncLimit {
+		s.execute(ctx, job, ids, apply, false)
+		return job, false, nil
+	}
+	s.expireJobs(ctx)
+	if err := s.jobs.Save(ctx, job); err != nil {
+		return nil, false, err
+	}
+	// The job outlives the request, so it must not inherit the request's cancellation.
+	go s.execute(context.WithoutCancel(ctx), job, ids, apply, true)
+	return s.snapshot(job), true, nil
+}
+
+// Job returns the progress of a background job started for the tenant carried by ctx. Jobs are
+// stored, so any instance can answer for a job another one runs.
+func (s *BulkService) Job(ctx context.Context, id uuid.UUID) (*BulkJob, error) {
+	job, err := s.jobs.FindByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.Wrap(ErrBulkJobNotFound, id.String())
+	}
+	return job, err
+}
+
+func (s *BulkService) action(req BulkRequest) (func(context.Context, uuid.UUID) error, error) {
+	switch req.Action {
+	case BulkActionSubmit:
+		return s.workflow.SubmitTicket, nil
+	case BulkActionApprove, BulkActionReject:
+		approved := req.Action == BulkActionApprove
+		return func(ctx context.Context, id uuid.UUID) error {
+			return s.workflow.RecordDecision(ctx, id, req.Actor, approved, req.Comment)
+		}, nil
+	case BulkActionCancel:
+		return func(ctx context.Context, id uuid.UUID) error {
+			return s.workflow.CancelTicket(ctx, id, req.Actor)
+		}, nil
+	case BulkActionReassign:
+		if req.Assignee == "" {
+			return nil, errors.Wrap(ErrInvalidTicket, "reassign requires an assignee")
+		}
+		return func(ctx context.Context, id uuid.UUID) error {
+			_, err := s.assignments.Assign(ctx, id, req.Assignee, req.Actor)
+			return err
+		}, nil
+	}
+	return nil, errors.Wrapf(ErrInvalidTicket, "unknown bulk action %q", req.Action)
+}
+
+// execute applies the action to every ticket. Background jobs store their progress after each
+// ticket.
+func (s *BulkService) execute(ctx context.Context, job *BulkJob, ids []uuid.UUID, apply func(context.Context, uuid.UUID) error, persist bool) {
+	sem := make(chan struct{}, s.concurrency)
+	var wg sync.WaitGroup
+	for _, id := range ids {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(id uuid.UUID) {
+			defer func() { <-sem; wg.Done() }()
+			result := BulkResult{TicketID: id, OK: true}
+			if err := apply(ctx, id); err != nil {
+				result.OK, result.Error = false, err.Error()
+			}
+			s.mu.Lock()
+			job.Processed++
+			if result.OK {
+				job.Succeeded++
+			} else {
+				job.Failed++
+			}
+			job.Results = append(job.Results, result)
+			s.mu.Unlock()
+			if persist {
+				s.save(ctx, job)
+			}
+		}(id)
+	}
+	wg.Wait()
+	s.mu.Lock()
+	now := time.Now().UTC()
+	job.Status, job.FinishedAt = BulkJobCompleted, &now
+	s.mu.Unlock()
+	if persist {
+		s.save(ctx, job)
+	}
+	log.Printf("bulk %s job %s finished: %d succeeded, %d failed", job.Action, job.ID, job.Succeeded, job.Failed)
+}
+
+// save stores a snapshot of the job; snapshots are taken and written in order, so a slower write
+// never replaces newer progress.
+func (s *BulkService) save(ctx context.Context, job *BulkJob) {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+	if err := s.jobs.Save(ctx, s.snapshot(job)); err != nil {
+		log.Printf("store progress of bulk job %s failed: %v", job.ID, err)
+	}
+}
+
+func (s *BulkService) snapshot(job *BulkJob) *BulkJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.snapshotLocked(job)
+}
+
+func (s *BulkService) snapshotLocked(job *BulkJob) *BulkJob {
+	copied := *job
+	copied.Results = append([]BulkResult(nil), job.Results...)
+	return &copied
+}
+
+// expireJobs drops finished jobs past their retention and finishes those abandoned by a stopped
+// instance, so they do not poll as running forever.
+func (s *BulkService) expireJobs(ctx context.Context) {
+	now := time.Now().UTC()
+	if _, err := s.jobs.Abandon(ctx, now.Add(-bulkJobStaleAfter), BulkJobInterrupted); err != nil {
+		log.Printf("mark interrupted bulk jobs failed: %v", err)
+	}
+	if _, err := s.jobs.DeleteFinishedBefore(ctx, now.Add(-bulkJobRetention)); err != nil {
+		log.Printf("delete expired bulk jobs failed: %v", err)
+	}
+}
+
+func filterIsEmpty(f repository.TicketFilter) bool {
+	return len(f.IDs) == 0 && len(f.Statuses) == 0 && f.Type == "" && f.Requester == "" &&
+		f.Assignee == "" && f.ProcessDefinitionID == "" && len(f.ProcessInstanceIDs) == 0 &&
+		f.CreatedAfter.IsZero() && f.CreatedBefore.IsZero()
+}
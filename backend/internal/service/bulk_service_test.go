@@ -0,0 +1,55 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/example/pflow/backend/internal/models"
+	"github.com/example/pflow/backend/internal/repository"
+	"github.com/example/pflow/backend/internal/service"
+)
+
+func TestBulkJobPollsFromAnotherInstance(t *testing.T) {
+	a, _ := newTestApp(t)
+	ctx := context.Background()
+	var ids []uuid.UUID
+	for _, title := range []string{"laptop", "monitor"} {
+		ticket := &models.Ticket{Title: title, Requester: "alice"}
+		if err := a.Workflow.CreateTicket(ctx, ticket); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		ids = append(ids, ticket.ID)
+	}
+	job, async, err := a.Bulk.Run(ctx, service.BulkRequest{Action: service.BulkActionSubmit, IDs: ids, Async: true})
+	if err != nil || !async {
+		t.Fatalf("run = %v, async %v", err, async)
+	}
+
+	// Another API instance shares only the database.
+	other := service.NewBulkService(a.Workflow, a.Assignments, a.Tickets, repository.NewBulkJobRepository(a.DB), 1, 0)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		got, err := other.Job(ctx, job.ID)
+		if err != nil {
+			t.Fatalf("poll: %v", err)
+		}
+		if got.Status == service.BulkJobCompleted {
+			if got.Succeeded != 2 || len(got.Results) != 2 {
+				t.Errorf("job = %+v, want two successes", got)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job still %s after 5s: %+v", got.Status, got)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if _, err := other.Job(ctx, uuid.New()); !errors.Is(err, service.ErrBulkJobNotFound) {
+		t.Errorf("unknown job: err = %v, want ErrBulkJobNotFound", err)
+	}
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/example/pflow/backend/internal/directory"
+	"github.com/example/pflow/backend/internal/models"
+	"github.com/example/pflow/backend/internal/mq"
+	"github.com/example/pflow/backend/internal/repository"
+)
+
+// ApprovalActivityID is the BPMN user task decisions are recorded against.
+const ApprovalActivityID = "UserTask_ManagerApproval"
+
+// ErrNotCommentAuthor is returned when someone other than its author changes a comment.
+var ErrNotCommentAuthor = errors.New("only the author may change a comment")
+
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([A-Za-z0-9][A-Za-z0-9._-]*)`)
+
+// CommentService manages the discussion thread of tickets.
+type CommentService struct {
+	comments  *repository.CommentRepository
+	tickets   repository.TicketStore
+	directory directory.Directory
+	mq        mq.Publisher
+}
+
+// NewCommentService builds a service with dependencies.
+func NewCommentService(comments *repository.CommentRepository, tickets repository.TicketStore, dir directory.Directory, mq mq.Publisher) *CommentService {
+	return &CommentService{comments: comments, tickets: tickets, directory: dir, mq: mq}
+}
+
+// Add posts a comment to the ticket and notifies mentioned users.
+func (s *CommentService) Add(ctx context.Context, ticketID uuid.UUID, comment *models.Comment) error {
+	ticket, err := s.tickets.FindByID(ctx, ticketID)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(comment.Body) == "" {
+		return errors.Wrap(ErrInvalidTicket, "comment body is required")
+	}
+	if comment.Visibility != "" && comment.Visibility != models.CommentVisibilityPublic && comment.Visibility != models.CommentVisibilityInternal {
+		return errors.Wrapf(ErrInvalidTicket, "unknown visibility %s", comment.Visibility)
+	}
+	comment.ID = uuid.Nil
+	comment.TicketID = ticket.ID
+	comment.Mentions = s.resolveMentions(ctx, comment.Body)
+	if err := s.comments.Create(ctx, comment); err != nil {
+		return err
+	}
+	s.notifyMentions(ctx, ticket, comment, comment.Mentions)
+	return nil
+}
+
+// AddDecision stores the approver's decision comment linked to the approval step.
+func (s *CommentService) AddDecision(ctx context.Context, ticket *models.Ticket, author string, approved bool, body string) error {
+	if strings.TrimSpace(body) == "" {
+		return nil
+	}
+	comment := &models.Comment{
+		TicketID:   ticket.ID,
+		Author:     author,
+		Body:       body,
+		Visibility: models.CommentVisibilityPublic,
+		Kind:       models.CommentKindDecision,
+		ActivityID: ApprovalActivityID,
+		Approved:   &approved,
+		Mentions:   s.resolveMentions(ctx, body),
+	}
+	if err := s.comments.Create(ctx, comment); err != nil {
+		return err
+	}
+	s.notifyMentions(ctx, ticket, comment, comment.Mentions)
+	return nil
+}
+
+// List returns the ticket's comments, hiding internal ones unless requested.
+func (s *CommentService) List(ctx context.Context, ticketID uuid.UUID, includeInternal bool) ([]models.Comment, error) {
+	if _, err := s.tickets.FindByID(ctx, ticketID); err != nil {
+		return nil, err
+	}
+	return s.comments.ListByTicket(ctx, ticketID, includeInternal)
+}
+
+// Edit replaces a comment body on behalf of editor, who must be its author, keeping the previous
+// body in the revision history. Only newly added mentions are notified.
+func (s *CommentService) Edit(ctx context.Context, ticketID, commentID uuid.UUID, editor, body string) (*models.Comment, error) {
+	if strings.TrimSpace(body) == "" {
+		return nil, errors.Wrap(ErrInvalidTicket, "comment body is required")
+	}
+	ticket, err := s.tickets.FindByID(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	comment, err := s.comments.FindByID(ctx, ticketID, commentID, false)
+	if err != nil {
+		return nil, err
+	}
+	if editor != comment.Author {
+		return nil, ErrNotCommentAuthor
+	}
+	revision := &models.CommentRevision{CommentID: comment.ID, Action: "edit", Body: comment.Body, Actor: editor}
+	previous := map[string]bool{}
+	for _, m := range comment.Mentions {
+		previous[m] = true
+	}
+	comment.Body = body
+	comment.Edited = true
+	comment.Mentions = s.resolveMentions(ctx, body)
+	if err := s.comments.Update(ctx, comment, revision); err != nil {
+		return nil, err
+	}
+	var added []string
+	for _, m := range comment.Mentions {
+		if !previous[m] {
+			added = append(added, m)
+		}
+	}
+	s.notifyMentions(ctx, ticket, comment, added)
+	return comment, nil
+}
+
+// Delete removes a comment on behalf of actor, who must be its author, keeping its last body in
+// the revision history.
+func (s *CommentService) Delete(ctx context.Context, ticketID, commentID uuid.UUID, actor string) error {
+	if _, err := s.tickets.FindByID(ctx, ticketID); err != nil {
+		return err
+	}
+	comment, err := s.comments.FindByID(ctx, ticketID, commentID, false)
+	if err != nil {
+		return err
+	}
+	if actor != comment.Author {
+		return ErrNotCommentAuthor
+	}
+	return s.comments.Delete(ctx, comment, &models.CommentRevision{CommentID: comment.ID, Action: "delete", Body: comment.Body, Actor: actor})
+}
+
+// History returns the revisions of a comment, including deleted comments.
+func (s *CommentService) History(ctx context.Context, ticketID, commentID uuid.UUID) ([]models.CommentRevision, error) {
+	if _, err := s.tickets.FindByID(ctx, ticketID); err != nil {
+		return nil, err
+	}
+	if _, err := s.comments.FindByID(ctx, ticketID, commentID, true); err != nil {
+		return nil, err
+	}
+	return s.comments.ListRevisions(ctx, commentID)
+}
+
+// resolveMentions extracts @username mentions that resolve to known users.
+func (s *CommentService) resolveMentions(ctx context.Context, body string) models.StringList {
+	mentions := models.StringList{}
+	seen := map[string]bool{}
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		name := strings.TrimRight(match[1], ".-")
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		user, err := s.directory.Lookup(ctx, name)
+		if err != nil {
+			continue
+		}
+		mentions = append(mentions, user.Username)
+	}
+	return mentions
+}
+
+func (s *CommentService) notifyMentions(ctx context.Context, ticket *models.Ticket, comment *models.Comment, users []string) {
+	if s.mq == nil {
+		return
+	}
+	for _, user := range users {
+		payload := ticketEvent("ticket.mentioned", ticket)
+		payload["mentionedUser"] = user
+		payload["commentId"] = comment.ID.String()
+		payload["author"] = comment.Author
+		payload["visibility"] = comment.Visibility
+		if err := s.mq.Publish(ctx, mq.RoutingKey(ticket.TenantID, "ticket.mentioned"), payload); err != nil {
+			log.Printf("publish ticket.mentioned failed: %v", err)
+		}
+	}
+}
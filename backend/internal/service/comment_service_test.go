@@ -0,0 +1,73 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/example/pflow/backend/internal/models"
+	"github.com/example/pflow/backend/internal/service"
+)
+
+func TestCommentChangesRequireTheAuthor(t *testing.T) {
+	a, _ := newTestApp(t)
+	ctx := context.Background()
+	ticket := &models.Ticket{Title: "new laptop", Requester: "alice"}
+	if err := a.Workflow.CreateTicket(ctx, ticket); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	comment := &models.Comment{Author: "alice", Body: "please hurry"}
+	if err := a.Comments.Add(ctx, ticket.ID, comment); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	if _, err := a.Comments.Edit(ctx, ticket.ID, comment.ID, "mallory", "changed"); !errors.Is(err, service.ErrNotCommentAuthor) {
+		t.Errorf("edit by another user: err = %v, want ErrNotCommentAuthor", err)
+	}
+	if err := a.Comments.Delete(ctx, ticket.ID, comment.ID, "mallory"); !errors.Is(err, service.ErrNotCommentAuthor) {
+		t.Errorf("delete by another user: err = %v, want ErrNotCommentAuthor", err)
+	}
+	if _, err := a.Comments.Edit(ctx, ticket.ID, uuid.New(), "alice", "changed"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("edit of unknown comment: err = %v, want not found", err)
+	}
+	if _, err := a.Comments.Edit(ctx, ticket.ID, comment.ID, "alice", "changed"); err != nil {
+		t.Fatalf("edit by the author: %v", err)
+	}
+	if err := a.Comments.Delete(ctx, ticket.ID, comment.ID, "alice"); err != nil {
+		t.Fatalf("delete by the author: %v", err)
+	}
+}
+
+func TestCommentsHideInternalNotes(t *testing.T) {
+	a, _ := newTestApp(t)
+	ctx := context.Background()
+	ticket := &models.Ticket{Title: "new laptop", Requester: "alice"}
+	if err := a.Workflow.CreateTicket(ctx, ticket); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	for _, c := range []*models.Comment{
+		{Author: "alice", Body: "any news?"},
+		{Author: "bob", Body: "budget is tight", Visibility: models.CommentVisibilityInternal},
+	} {
+		if err := a.Comments.Add(ctx, ticket.ID, c); err != nil {
+			t.Fatalf("add: %v", err)
+		}
+	}
+	public, err := a.Comments.List(ctx, ticket.ID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(public) != 1 || public[0].Author != "alice" {
+		t.Errorf("public comments = %+v, want only alice's", public)
+	}
+	all, err := a.Comments.List(ctx, ticket.ID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 {
+		t.Errorf("comments with internal notes = %d, want 2", len(all))
+	}
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/example/pflow/backend/internal/models"
+	"github.com/example/pflow/backend/internal/repository"
+	"github.com/example/pflow/backend/internal/tenant"
+	"github.com/example/pflow/backend/internal/workflow"
+)
+
+// DefinitionService manages BPMN deployments and the process definition versions new tickets start with.
+type DefinitionService struct {
+	pins    *repository.ProcessPinRepository
+	camunda *workflow.CamundaClient
+
+	mu       sync.RWMutex
+	versions map[string]int
+}
+
+// NewDefinitionService builds a service with dependencies.
+func NewDefinitionService(pins *repository.ProcessPinRepository, camunda *workflow.CamundaClient) *DefinitionService {
+	return &DefinitionService{pins: pins, camunda: camunda, versions: map[string]int{}}
+}
+
+// Deploy uploads a BPMN model to Camunda and caches the versions of the definitions it created.
+// Unless the options name a tenant, the definitions belong to the tenant carried by ctx.
+func (s *DefinitionService) Deploy(ctx context.Context, name string, bpmn []byte, opts workflow.DeployOptions) (*workflow.Deployment, error) {
+	if len(bpmn) == 0 {
+		return nil, errors.New("bpmn model is empty")
+	}
+	if opts.TenantID == "" {
+		opts.TenantID = tenant.FromContext(ctx)
+	}
+	deployment, err := s.camunda.DeployProcess(ctx, name, bpmn, opts)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	for id, def := range deployment.DeployedProcessDefinitions {
+		s.versions[id] = def.Version
+	}
+	s.mu.Unlock()
+	if ids := deployment.ProcessDefinitionIDs(); len(ids) > 0 {
+		log.Printf("deployment %s created process definitions %v", deployment.ID, ids)
+	} else {
+		log.Printf("deployment %s contained no changed process definitions", deployment.ID)
+	}
+	return deployment, nil
+}
+
+// ListDefinitions returns every deployed version of the process key visible to the tenant carried
+// by ctx, newest first.
+func (s *DefinitionService) ListDefinitions(ctx context.Context, processKey string) ([]workflow.ProcessDefinition, error) {
+	return s.camunda.ListProcessDefinitions(ctx, processKey, tenant.FromContext(ctx))
+}
+
+// ListPins returns the currently pinned versions.
+func (s *DefinitionService) ListPins(ctx context.Context) ([]models.ProcessDefinitionPin, error) {
+	return s.pins.List(ctx)
+}
+
+// Pin makes new tickets for the process key start with the given definition version.
+func (s *DefinitionService) Pin(ctx context.Context, processKey string, version int) (*models.ProcessDefinitionPin, error) {
+	defs, err := s.ListDefinitions(ctx, processKey)
+	if err != nil {
+		return nil, err
+	}
+	for _, def := range defs {
+		if def.Version != version {
+			continue
+		}
+		pin := &models.ProcessDefinitionPin{ProcessKey: processKey, DefinitionID: def.ID, Version: def.Version}
+		if err := s.pins.Save(ctx, pin); err != nil {
+			return nil, err
+		}
+		return pin, nil
+	}
+	return nil, errors.Errorf("process %s has no version %d", processKey, version)
+}
+
+// Unpin lets new tickets for the process key start with the latest deployed version again.
+func (s *DefinitionService) Unpin(ctx context.Context, processKey string) error {
+	return s.pins.Delete(ctx, processKey)
+}
+
+// PinnedDefinitionID returns the pinned definition for the process key, or an empty string for latest.
+func (s *DefinitionService) PinnedDefinitionID(ctx context.Context, processKey string) (string, error) {
+	pin, err := s.pins.Find(ctx, processKey)
+	if err != nil || pin == nil {
+		return "", err
+	}
+	return pin.DefinitionID, nil
+}
+
+// Version resolves the version number of a process definition id, caching the answer.
+func (s *DefinitionService) Version(ctx context.Context, definitionID string) (int, error) {
+	s.mu.RLock()
+	v, ok := s.versions[definitionID]
+	s.mu.RUnlock()
+	if ok {
+		return v, nil
+	}
+	def, err := s.camunda.GetProcessDefinition(ctx, definitionID)
+	if err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	s.versions[definitionID] = def.Version
+	s.mu.Unlock()
+	return def.Version, nil
+}
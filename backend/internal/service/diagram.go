@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/example/pflow/backend/internal/workflow"
+)
+
+// TicketDiagram describes where a ticket currently is in its BPMN process.
+type TicketDiagram struct {
+	TicketID            uuid.UUID                           `json:"ticketId"`
+	ProcessInstanceID   string                              `json:"processInstanceId"`
+	ProcessDefinitionID string                              `json:"processDefinitionId"`
+	BPMNXML             string                              `json:"bpmnXml"`
+	Ended               bool                                `json:"ended"`
+	ActiveActivityIDs   []string                            `json:"activeActivityIds"`
+	CompletedActivities []workflow.HistoricActivityInstance `json:"completedActivities"`
+	Incidents           []workflow.Incident                 `json:"incidents"`
+}
+
+// CompletedActivityIDs returns the ids of finished activities in execution order.
+func (d *TicketDiagram) CompletedActivityIDs() []string {
+	ids := make([]string, 0, len(d.CompletedActivities))
+	for _, a := range d.CompletedActivities {
+		ids = append(ids, a.ActivityID)
+	}
+	return ids
+}
+
+// IncidentActivityIDs returns the activities that currently have an open incident.
+func (d *TicketDiagram) IncidentActivityIDs() []string {
+	ids := make([]string, 0, len(d.Incidents))
+	for _, i := range d.Incidents {
+		ids = append(ids, i.ActivityID)
+	}
+	return ids
+}
+
+// TicketDiagram loads the BPMN model of the ticket's process definition with runtime and history markers.
+func (s *WorkflowService) TicketDiagram(ctx context.Context, ticketID uuid.UUID) (*TicketDiagram, error) {
+	ticket, err := s.tickets.FindByID(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	if ticket.ProcessInstanceID == "" {
+		return nil, errors.Errorf("ticket %s has not been submitted", ticket.ID)
+	}
+	diagram := &TicketDiagram{
+		TicketID:            ticket.ID,
+		ProcessInstanceID:   ticket.ProcessInstanceID,
+		ProcessDefinitionID: ticket.ProcessDefinitionID,
+		ActiveActivityIDs:   []string{},
+		CompletedActivities: []workflow.HistoricActivityInstance{},
+		Incidents:           []workflow.Incident{},
+	}
+	if diagram.ProcessDefinitionID == "" {
+		instance, err := s.camunda.GetHistoricProcessInstance(ctx, ticket.ProcessInstanceID)
+		if err != nil {
+			return nil, errors.Wrap(err, "load process instance")
+		}
+		diagram.ProcessDefinitionID = instance.ProcessDefinitionID
+	}
+
+	xml, err := s.camunda.GetProcessDefinitionXML(ctx, diagram.ProcessDefinitionID)
+	if err != nil {
+		return nil, errors.Wrap(err, "load process definition xml")
+	}
+	diagram.BPMNXML = string(xml)
+
+	tree, err := s.camunda.GetActivityInstanceTree(ctx, ticket.ProcessInstanceID)
+	if err != nil {
+		return nil, errors.Wrap(err, "load activity instances")
+	}
+	if tree == nil {
+		diagram.Ended = true
+	} else {
+		diagram.ActiveActivityIDs = append(diagram.ActiveActivityIDs, tree.ActiveActivityIDs()...)
+	}
+
+	history, err := s.camunda.ListHistoricActivityInstances(ctx, ticket.ProcessInstanceID)
+	if err != nil {
+		return nil, errors.Wrap(err, "load activity history")
+	}
+	for _, h := range history {
+		if h.Completed() {
+			diagram.CompletedActivities = append(diagram.CompletedActivities, h)
+		}
+	}
+
+	if !diagram.Ended {
+		incidents, err := s.camunda.ListIncidents(ctx, ticket.ProcessInstanceID)
+		if err != nil {
+			return nil, errors.Wrap(err, "load incidents")
+		}
+		diagram.Incidents = append(diagram.Incidents, incidents...)
+	}
+	return diagram, nil
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/example/pflow/backend/internal/models"
+	"github.com/example/pflow/backend/internal/repository"
+)
+
+var (
+	// ErrIdempotencyKeyReused is returned when a key is sent again with a different request.
+	ErrIdempotencyKeyReused = errors.New("idempotency key was used for a different request")
+	// ErrIdempotencyInProgress is returned while the request that first used a key is still running.
+	ErrIdempotencyInProgress = errors.New("a request with this idempotency key is still in progress")
+)
+
+// IdempotencyService lets clients retry mutating requests without repeating their effects.
+type IdempotencyService struct {
+	records *repository.IdempotencyRepository
+	ttl     time.Duration
+}
+
+// NewIdempotencyService builds a service keeping keys for ttl.
+func NewIdempotencyService(records *repository.IdempotencyRepository, ttl time.Duration) *IdempotencyService {
+	return &IdempotencyService{records: records, ttl: ttl}
+}
+
+// Begin reserves the key for a request with the given hash. It returns the stored record when the
+// same request already completed, in which case the caller replays the stored response.
+func (s *IdempotencyService) Begin(ctx context.Context, key, requestHash string) (*models.IdempotencyRecord, error) {
+	now := time.Now()
+	record, created, err := s.records.Reserve(ctx, &models.IdempotencyRecord{
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.ttl),
+	})
+	switch {
+	case err != nil:
+		return nil, err
+	case created:
+		return nil, nil
+	case record.RequestHash != requestHash:
+		return nil, errors.Wrap(ErrIdempotencyKeyReused, key)
+	case record.StatusCode == 0:
+		return nil, errors.Wrap(ErrIdempotencyInProgress, key)
+	}
+	return record, nil
+}
+
+// Complete stores the response for replays.
+func (s *IdempotencyService) Complete(ctx context.Context, key string, status int, contentType string, body []byte) error {
+	return s.records.Complete(ctx, key, status, contentType, body)
+}
+
+// Abandon releases the key after a failure that the client should be able to retry.
+func (s *IdempotencyService) Abandon(ctx context.Context, key string) error {
+	return s.records.Delete(ctx, key)
+}
+
+// Purge removes expired keys.
+func (s *IdempotencyService) Purge(ctx context.Context, now time.Time) (int64, error) {
+	return s.records.DeleteExpired(ctx, now)
+}
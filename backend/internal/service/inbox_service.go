@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/example/pflow/backend/internal/directory"
+	"github.com/example/pflow/backend/internal/models"
+	"github.com/example/pflow/backend/internal/repository"
+	"github.com/example/pflow/backend/internal/tenant"
+	"github.com/example/pflow/backend/internal/workflow"
+)
+
+// Inbox buckets reported in InboxPage.Counts.
+const (
+	InboxAssigned  = "assigned"
+	InboxCandidate = "candidate"
+	InboxDelegated = "delegated"
+	InboxOverdue   = "overdue"
+)
+
+// inboxSortFields maps the API sort keys to Camunda task sort properties.
+var inboxSortFields = map[string]string{
+	"due":      "dueDate",
+	"priority": "priority",
+	"created":  "created",
+}
+
+// InboxQuery selects one page of a user's tasks.
+type InboxQuery struct {
+	User string
+	// SortBy is one of "due", "priority" or "created"; SortOrder is "asc" or "desc".
+	SortBy    string
+	SortOrder string
+	Offset    int
+	Limit     int
+}
+
+// InboxItem is an open user task together with the ticket it belongs to.
+type InboxItem struct {
+	Task   workflow.Task  `json:"task"`
+	Ticket *models.Ticket `json:"ticket"`
+}
+
+// InboxPage is one page of the inbox plus totals for the whole inbox.
+type InboxPage struct {
+	Items  []InboxItem      `json:"items"`
+	Total  int64            `json:"total"`
+	Offset int              `json:"offset"`
+	Limit  int              `json:"limit"`
+	Counts map[string]int64 `json:"counts"`
+}
+
+// InboxService lists the Camunda tasks a user can work on, joined with their tickets.
+type InboxService struct {
+	camunda   *workflow.CamundaClient
+	tickets   repository.TicketStore
+	directory directory.Directory
+}
+
+// NewInboxService builds a service with dependencies.
+func NewInboxService(camunda *workflow.CamundaClient, tickets repository.TicketStore, dir directory.Directory) *InboxService {
+	return &InboxService{camunda: camunda, tickets: tickets, directory: dir}
+}
+
+// Tasks returns the tasks assigned to the user or offered to them directly or through their groups.
+func (s *InboxService) Tasks(ctx context.Context, q InboxQuery) (*InboxPage, error) {
+	if q.User == "" {
+		return nil, errors.Wrap(ErrInvalidTicket, "user is required")
+	}
+	if q.Limit <= 0 || q.Limit > 200 {
+		q.Limit = 50
+	}
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+	sortBy, ok := inboxSortFields[q.SortBy]
+	if q.SortBy == "" {
+		sortBy, ok = "dueDate", true
+	}
+	if !ok {
+		return nil, errors.Wrapf(ErrInvalidTicket, "unsupported sort %s", q.SortBy)
+	}
+	if q.SortOrder != "desc" {
+		q.SortOrder = "asc"
+	}
+
+	user, err := s.directory.Lookup(ctx, q.User)
+	if err != nil {
+		return nil, err
+	}
+	// Camunda only accepts candidateUser together with candidateGroups inside an OR query.
+	tenants := tenantIDs(ctx)
+	candidate := workflow.TaskQuery{TenantIDIn: tenants, OrQueries: []workflow.TaskQuery{{CandidateUser: user.Username, CandidateGroups: user.Groups}}}
+	inbox := workflow.TaskQuery{TenantIDIn: tenants, OrQueries: []workflow.TaskQuery{{Assignee: user.Username, CandidateUser: user.Username, CandidateGroups: user.Groups}}}
+
+	query := inbox
+	query.Sorting = []workflow.TaskSorting{{SortBy: sortBy, SortOrder: q.SortOrder}}
+	tasks, err := s.camunda.QueryTasks(ctx, query, q.Offset, q.Limit)
+	if err != nil {
+		return nil, err
+	}
+	page := &InboxPage{Items: make([]InboxItem, 0, len(tasks)), Offset: q.Offset, Limit: q.Limit, Counts: map[string]int64{}}
+	if page.Total, err = s.camunda.CountTasks(ctx, inbox); err != nil {
+		return nil, err
+	}
+	if err := s.count(ctx, page, user.Username, candidate, inbox); err != nil {
+		return nil, err
+	}
+	if err := s.join(ctx, page, tasks); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+func (s *InboxService) count(ctx context.Context, page *InboxPage, username string, candidate, inbox workflow.TaskQuery) error {
+	overdue := inbox
+	overdue.DueBefore = workflow.FormatTime(time.Now())
+	queries := map[string]workflow.TaskQuery{
+		InboxAssigned:  {Assignee: username, TenantIDIn: inbox.TenantIDIn},
+		InboxCandidate: candidate,
+		InboxDelegated: {Assignee: username, DelegationState: "PENDING", TenantIDIn: inbox.TenantIDIn},
+		InboxOverdue:   overdue,
+	}
+	for bucket, query := range queries {
+		n, err := s.camunda.CountTasks(ctx, query)
+		if err != nil {
+			return err
+		}
+		page.Counts[bucket] = n
+	}
+	return nil
+}
+
+// tenantIDs limits task queries to the tenant carried by ctx.
+func tenantIDs(ctx context.Context) []string {
+	if id := tenant.FromContext(ctx); id != "" {
+		return []string{id}
+	}
+	return nil
+}
+
+// join attaches the ticket of each task, matching them by process instance.
+func (s *InboxService) join(ctx context.Context, page *InboxPage, tasks []workflow.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(tasks))
+	for _, t := range tasks {
+		ids = append(ids, t.ProcessInstanceID)
+	}
+	tickets, err := s.tickets.Find(ctx, repository.TicketFilter{ProcessInstanceIDs: ids})
+	if err != nil {
+		return err
+	}
+	byInstance := make(map[string]*models.Ticket, len(tickets))
+	for i := range tickets {
+		byInstance[tickets[i].ProcessInstanceID] = &tickets[i]
+	}
+	for _, t := range tasks {
+		page.Items = append(page.Items, InboxItem{Task: t, Ticket: byInstance[t.ProcessInstanceID]})
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/example/pflow/backend/internal/models"
+	"github.com/example/pflow/backend/internal/repository"
+	"github.com/example/pflow/backend/internal/workflow"
+)
+
+// TicketIncident is an open Camunda incident in the process instance of a ticket.
+type TicketIncident struct {
+	workflow.Incident
+	TicketID     uuid.UUID           `json:"ticketId"`
+	TicketTitle  string              `json:"ticketTitle"`
+	TicketStatus models.TicketStatus `json:"ticketStatus"`
+}
+
+// IncidentService lists and retries the incidents of ticket processes.
+type IncidentService struct {
+	tickets repository.TicketStore
+	camunda *workflow.CamundaClient
+}
+
+// NewIncidentService builds a service with dependencies.
+func NewIncidentService(tickets repository.TicketStore, camunda *workflow.CamundaClient) *IncidentService {
+	return &IncidentService{tickets: tickets, camunda: camunda}
+}
+
+// List returns the open incidents of the tickets matching the filter. Incidents of process
+// instances that belong to no ticket of the caller's tenant are left out.
+func (s *IncidentService) List(ctx context.Context, filter repository.TicketFilter) ([]TicketIncident, error) {
+	incidents, err := s.camunda.ListIncidents(ctx, "")
+	if err != nil {
+		return nil, errors.Wrap(err, "list incidents")
+	}
+	out := []TicketIncident{}
+	if len(incidents) == 0 {
+		return out, nil
+	}
+	if len(filter.ProcessInstanceIDs) == 0 {
+		for _, incident := range incidents {
+			filter.ProcessInstanceIDs = append(filter.ProcessInstanceIDs, incident.ProcessInstanceID)
+		}
+	}
+	tickets, err := s.tickets.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	byInstance := make(map[string]*models.Ticket, len(tickets))
+	for i := range tickets {
+		byInstance[tickets[i].ProcessInstanceID] = &tickets[i]
+	}
+	for _, incident := range incidents {
+		if ticket := byInstance[incident.ProcessInstanceID]; ticket != nil {
+			out = append(out, ticketIncident(incident, ticket))
+		}
+	}
+	return out, nil
+}
+
+// Retry gives the failed activity behind an incident new retries so the engine runs it again.
+func (s *IncidentService) Retry(ctx context.Context, incidentID string, retries int) (*TicketIncident, error) {
+	if retries < 1 {
+		return nil, errors.Wrap(ErrInvalidTicket, "retries must be at least 1")
+	}
+	incident, err := s.camunda.GetIncident(ctx, incidentID)
+	if err != nil {
+		return nil, err
+	}
+	tickets, err := s.tickets.Find(ctx, repository.TicketFilter{ProcessInstanceIDs: []string{incident.ProcessInstanceID}, Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+	if len(tickets) == 0 {
+		return nil, errors.Errorf("incident %s does not belong to a ticket", incidentID)
+	}
+	if err := s.camunda.RetryIncident(ctx, *incident, retries); err != nil {
+		return nil, errors.Wrapf(err, "retry incident %s", incidentID)
+	}
+	result := ticketIncident(*incident, &tickets[0])
+	return &result, nil
+}
+
+func ticketIncident(incident workflow.Incident, ticket *models.Ticket) TicketIncident {
+	return TicketIncident{Incident: incident, TicketID: ticket.ID, TicketTitle: ticket.Title, TicketStatus: ticket.Status}
+}
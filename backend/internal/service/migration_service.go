@@ -0,0 +1,348 @@
+package service
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/example/pflow/backend/internal/models"
+	"github.com/example/pflow/backend/internal/repository"
+	"github.com/example/pflow/backend/internal/tenant"
+	"github.com/example/pflow/backend/internal/workflow"
+)
+
+// Migration result states reported per process instance.
+const (
+	MigrationStatusMigrated  = "migrated"
+	MigrationStatusScheduled = "scheduled"
+	MigrationStatusValid     = "valid"
+	MigrationStatusSkipped   = "skipped"
+	MigrationStatusFailed    = "failed"
+)
+
+// MigrationRequest selects the tickets to migrate and the target definition version.
+type MigrationRequest struct {
+	ProcessKey string
+	// TargetVersion defaults to the pinned version, or the latest one when the key is not pinned.
+	TargetVersion       int
+	Filter              repository.TicketFilter
+	Async               bool
+	DryRun              bool
+	SkipCustomListeners bool
+	SkipIoMappings      bool
+}
+
+// MigrationResult reports the outcome for a single ticket.
+type MigrationResult struct {
+	TicketID           uuid.UUID `json:"ticketId"`
+	ProcessInstanceID  string    `json:"processInstanceId"`
+	SourceDefinitionID string    `json:"sourceDefinitionId"`
+	Status             string    `json:"status"`
+	Error              string    `json:"error,omitempty"`
+}
+
+// MigrationReport summarises a migration run.
+type MigrationReport struct {
+	TargetDefinitionID string            `json:"targetDefinitionId"`
+	TargetVersion      int               `json:"targetVersion"`
+	DryRun             bool              `json:"dryRun"`
+	BatchIDs           []string          `json:"batchIds,omitempty"`
+	Results            []MigrationResult `json:"results"`
+	Succeeded          int               `json:"succeeded"`
+	Failed             int               `json:"failed"`
+}
+
+func (r *MigrationReport) add(result MigrationResult) {
+	switch result.Status {
+	case MigrationStatusFailed:
+		r.Failed++
+	case MigrationStatusSkipped:
+	default:
+		r.Succeeded++
+	}
+	r.Results = append(r.Results, result)
+}
+
+// MigrationService moves running ticket process instances between BPMN versions.
+type MigrationService struct {
+	tickets     repository.TicketStore
+	definitions *DefinitionService
+	camunda     *workflow.CamundaClient
+	processKey  string
+}
+
+// NewMigrationService builds a service with dependencies.
+func NewMigrationService(repo repository.TicketStore, definitions *DefinitionService, camunda *workflow.CamundaClient, processKey string) *MigrationService {
+	return &MigrationService{tickets: repo, definitions: definitions, camunda: camunda, processKey: processKey}
+}
+
+// Migrate moves the process instances of all non-terminal tickets matching the request to the target version.
+func (s *MigrationService) Migrate(ctx context.Context, req MigrationRequest) (*MigrationReport, error) {
+	if req.ProcessKey == "" {
+		req.ProcessKey = s.processKey
+	}
+	target, err := s.resolveTarget(ctx, req.ProcessKey, req.TargetVersion)
+	if err != nil {
+		return nil, err
+	}
+	report := &MigrationReport{TargetDefinitionID: target.ID, TargetVersion: target.Version, DryRun: req.DryRun}
+
+	filter := req.Filter
+	if len(filter.Statuses) == 0 {
+		filter.Statuses = models.ActiveTicketStatuses
+	}
+	tickets, err := s.tickets.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Camunda plans are per source definition, so group the instances by the version they run on.
+	groups := map[string][]*models.Ticket{}
+	var order []string
+	for i := range tickets {
+		ticket := &tickets[i]
+		result := MigrationResult{TicketID: ticket.ID, ProcessInstanceID: ticket.ProcessInstanceID}
+		if ticket.Status.Terminal() || ticket.ProcessInstanceID == "" {
+			result.Status, result.Error = MigrationStatusSkipped, "no running process instance"
+			report.add(result)
+			continue
+		}
+		if ticket.MigrationBatchID != "" {
+			result.SourceDefinitionID = ticket.ProcessDefinitionID
+			result.Status, result.Error = MigrationStatusSkipped, "migration batch "+ticket.MigrationBatchID+" is pending"
+			report.add(result)
+			continue
+		}
+		source := ticket.ProcessDefinitionID
+		if source == "" {
+			instance, err := s.camunda.GetProcessInstance(ctx, ticket.ProcessInstanceID)
+			if err != nil {
+				result.Status, result.Error = MigrationStatusFailed, err.Error()
+				report.add(result)
+				continue
+			}
+			source = instance.DefinitionID
+			ticket.ProcessDefinitionID = source
+		}
+		if source == target.ID {
+			result.SourceDefinitionID = source
+			result.Status, result.Error = MigrationStatusSkipped, "already on target version"
+			report.add(result)
+			continue
+		}
+		if _, ok := groups[source]; !ok {
+			order = append(order, source)
+		}
+		groups[source] = append(groups[source], ticket)
+	}
+
+	// The status filter also selects tickets of types running another process; leave those alone.
+	for _, source := range order {
+		definition, err := s.camunda.GetProcessDefinition(ctx, source)
+		switch {
+		case err != nil:
+			s.addGroup(report, source, groups[source], MigrationStatusFailed, errors.Wrap(err, "load source definition"))
+		case definition.Key != target.Key:
+			s.addGroup(report, source, groups[source], MigrationStatusSkipped, errors.Errorf("runs process %s, not %s", definition.Key, target.Key))
+		default:
+			s.migrateGroup(ctx, req, source, target, groups[source], report)
+		}
+	}
+	return report, nil
+}
+
+func (s *MigrationService) addGroup(report *MigrationReport, source string, tickets []*models.Ticket, status string, err error) {
+	for _, ticket := range tickets {
+		report.add(s.result(ticket, source, status, err))
+	}
+}
+
+func (s *MigrationService) migrateGroup(ctx context.Context, req MigrationRequest, source string, target *workflow.ProcessDefinition, tickets []*models.Ticket, report *MigrationReport) {
+	fail := func(err error) {
+		for _, ticket := range tickets {
+			report.add(MigrationResult{
+				TicketID:           ticket.ID,
+				ProcessInstanceID:  ticket.ProcessInstanceID,
+				SourceDefinitionID: source,
+				Status:             MigrationStatusFailed,
+				Error:              err.Error(),
+			})
+		}
+	}
+
+	plan, err := s.camunda.GenerateMigrationPlan(ctx, source, target.ID, true)
+	if err != nil {
+		fail(errors.Wrap(err, "generate migration plan"))
+		return
+	}
+	validation, err := s.camunda.ValidateMigrationPlan(ctx, plan)
+	if err != nil {
+		fail(errors.Wrap(err, "validate migration plan"))
+		return
+	}
+	if failures := validation.Failures(); len(failures) > 0 {
+		fail(errors.Errorf("invalid migration plan: %s", strings.Join(failures, "; ")))
+		return
+	}
+
+	exec := workflow.MigrationExecution{
+		Plan:                plan,
+		SkipCustomListeners: req.SkipCustomListeners,
+		SkipIoMappings:      req.SkipIoMappings,
+	}
+	if req.DryRun {
+		for _, ticket := range tickets {
+			report.add(s.result(ticket, source, MigrationStatusValid, nil))
+		}
+		return
+	}
+	if req.Async {
+		for _, ticket := range tickets {
+			exec.ProcessInstanceIDs = append(exec.ProcessInstanceIDs, ticket.ProcessInstanceID)
+		}
+		batch, err := s.camunda.ExecuteMigrationAsync(ctx, exec)
+		if err != nil {
+			fail(errors.Wrap(err, "execute migration batch"))
+			return
+		}
+		report.BatchIDs = append(report.BatchIDs, batch.ID)
+		// The tickets keep the source definition until SettleMigrations sees the batch move them.
+		for _, ticket := range tickets {
+			report.add(s.result(ticket, source, MigrationStatusScheduled, s.markPending(ctx, ticket, batch.ID)))
+		}
+		return
+	}
+
+	// Migrate one instance per call so a single incompatible instance does not fail the whole group.
+	for _, ticket := range tickets {
+		exec.ProcessInstanceIDs = []string{ticket.ProcessInstanceID}
+		if err := s.camunda.ExecuteMigration(ctx, exec); err != nil {
+			report.add(s.result(ticket, source, MigrationStatusFailed, err))
+			continue
+		}
+		report.add(s.result(ticket, source, MigrationStatusMigrated, s.recordTarget(ctx, ticket, target)))
+	}
+}
+
+func (s *MigrationService) result(ticket *models.Ticket, source, status string, err error) MigrationResult {
+	result := MigrationResult{
+		TicketID:           ticket.ID,
+		ProcessInstanceID:  ticket.ProcessInstanceID,
+		SourceDefinitionID: source,
+		Status:             status,
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// markPending records the batch migrating the ticket's process instance.
+func (s *MigrationService) markPending(ctx context.Context, ticket *models.Ticket, batchID string) error {
+	ticket.MigrationBatchID = batchID
+	if err := s.tickets.Update(ctx, ticket); err != nil {
+		log.Printf("record migration batch %s for ticket %s failed: %v", batchID, ticket.ID, err)
+		return errors.Wrap(err, "migration scheduled in camunda but ticket not updated")
+	}
+	return nil
+}
+
+// SettleMigrations records the outcome of pending migration batches: a ticket whose process
+// instance runs on another definition now takes that definition, and one whose batch finished
+// without moving it keeps its source definition. It returns how many tickets were settled.
+func (s *MigrationService) SettleMigrations(ctx context.Context) (int, error) {
+	tickets, err := s.tickets.Find(ctx, repository.TicketFilter{MigrationPending: true, OldestFirst: true})
+	if err != nil {
+		return 0, err
+	}
+	settled := 0
+	for i := range tickets {
+		ticket := &tickets[i]
+		done, err := s.settle(ctx, ticket)
+		if err != nil {
+			log.Printf("settle migration batch %s of ticket %s failed: %v", ticket.MigrationBatchID, ticket.ID, err)
+			continue
+		}
+		if done {
+			settled++
+		}
+	}
+	return settled, nil
+}
+
+func (s *MigrationService) settle(ctx context.Context, ticket *models.Ticket) (bool, error) {
+	ctx = tenant.WithID(ctx, ticket.TenantID)
+	// Look at the batch first: once it has finished, the instance it leaves behind is final.
+	_, err := s.camunda.GetBatch(ctx, ticket.MigrationBatchID)
+	running := err == nil
+	if err != nil && !workflow.IsNotFound(err) {
+		return false, err
+	}
+	instance, err := s.camunda.GetProcessInstance(ctx, ticket.ProcessInstanceID)
+	switch {
+	case workflow.IsNotFound(err):
+		// The instance ended; the status sync takes the ticket from here.
+		ticket.MigrationBatchID = ""
+		return true, s.tickets.Update(ctx, ticket)
+	case err != nil:
+		return false, err
+	case instance.DefinitionID != ticket.ProcessDefinitionID:
+		target, err := s.camunda.GetProcessDefinition(ctx, instance.DefinitionID)
+		if err != nil {
+			return false, err
+		}
+		return true, s.recordTarget(ctx, ticket, target)
+	case running:
+		return false, nil
+	}
+	log.Printf("migration batch %s finished without moving process instance %s of ticket %s", ticket.MigrationBatchID, ticket.ProcessInstanceID, ticket.ID)
+	ticket.MigrationBatchID = ""
+	return true, s.tickets.Update(ctx, ticket)
+}
+
+// recordTarget stores the new definition on the ticket and clears its pending batch. A failure here
+// is reported but does not undo the migration, which has already happened in Camunda.
+func (s *MigrationService) recordTarget(ctx context.Context, ticket *models.Ticket, target *workflow.ProcessDefinition) error {
+	ticket.ProcessDefinitionID = target.ID
+	ticket.ProcessDefinitionVersion = target.Version
+	ticket.MigrationBatchID = ""
+	if err := s.tickets.Update(ctx, ticket); err != nil {
+		log.Printf("record migrated definition for ticket %s failed: %v", ticket.ID, err)
+		return errors.Wrap(err, "migrated in camunda but ticket not updated")
+	}
+	return nil
+}
+
+func (s *MigrationService) resolveTarget(ctx context.Context, processKey string, version int) (*workflow.ProcessDefinition, error) {
+	defs, err := s.definitions.ListDefinitions(ctx, processKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(defs) == 0 {
+		return nil, errors.Errorf("process %s has no deployed definitions", processKey)
+	}
+	if version == 0 {
+		pinned, err := s.definitions.PinnedDefinitionID(ctx, processKey)
+		if err != nil {
+			return nil, err
+		}
+		if pinned == "" {
+			return &defs[0], nil
+		}
+		for i := range defs {
+			if defs[i].ID == pinned {
+				return &defs[i], nil
+			}
+		}
+		return nil, errors.Errorf("pinned definition %s no longer exists", pinned)
+	}
+	for i := range defs {
+		if defs[i].Version == version {
+			return &defs[i], nil
+		}
+	}
+	return nil, errors.Errorf("process %s has no version %d", processKey, version)
+}
@@ -0,0 +1,120 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/example/pflow/backend/internal/config"
+	"github.com/example/pflow/backend/internal/models"
+	"github.com/example/pflow/backend/internal/service"
+)
+
+func TestAsyncMigrationRecordsTargetOnceSettled(t *testing.T) {
+	a, engine := newTestApp(t)
+	ctx := context.Background()
+	ticket := &models.Ticket{Title: "new laptop", Requester: "alice"}
+	if err := a.Workflow.CreateTicket(ctx, ticket); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := a.Workflow.SubmitTicket(ctx, ticket.ID); err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	source, _ := a.Tickets.FindByID(ctx, ticket.ID)
+	target := engine.AddDefinition(config.Default().Camunda.ProcessKey)
+
+	report, err := a.Migrations.Migrate(ctx, service.MigrationRequest{TargetVersion: target.Version, Async: true})
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if len(report.BatchIDs) != 1 || len(report.Results) != 1 || report.Results[0].Status != service.MigrationStatusScheduled {
+		t.Fatalf("report = %+v, want one scheduled batch", report)
+	}
+	got, _ := a.Tickets.FindByID(ctx, ticket.ID)
+	if got.ProcessDefinitionID != source.ProcessDefinitionID || got.MigrationBatchID != report.BatchIDs[0] {
+		t.Fatalf("ticket on %s with batch %q, want the source definition with the batch pending", got.ProcessDefinitionID, got.MigrationBatchID)
+	}
+
+	again, err := a.Migrations.Migrate(ctx, service.MigrationRequest{TargetVersion: target.Version, Async: true})
+	if err != nil {
+		t.Fatalf("second migrate: %v", err)
+	}
+	if len(again.BatchIDs) != 0 || again.Results[0].Status != service.MigrationStatusSkipped {
+		t.Errorf("second run = %+v, want the pending ticket skipped", again)
+	}
+
+	settled, err := a.Migrations.SettleMigrations(ctx)
+	if err != nil || settled != 1 {
+		t.Fatalf("settle = %d, %v, want 1", settled, err)
+	}
+	got, _ = a.Tickets.FindByID(ctx, ticket.ID)
+	if got.ProcessDefinitionID != target.ID || got.ProcessDefinitionVersion != target.Version || got.MigrationBatchID != "" {
+		t.Errorf("ticket = %s v%d batch %q, want %s v%d settled", got.ProcessDefinitionID, got.ProcessDefinitionVersion, got.MigrationBatchID, target.ID, target.Version)
+	}
+}
+
+func TestSettleKeepsSourceWhenBatchDidNotMoveInstance(t *testing.T) {
+	a, _ := newTestApp(t)
+	ctx := context.Background()
+	ticket := &models.Ticket{Title: "new laptop", Requester: "alice"}
+	if err := a.Workflow.CreateTicket(ctx, ticket); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := a.Workflow.SubmitTicket(ctx, ticket.ID); err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	pending, _ := a.Tickets.FindByID(ctx, ticket.ID)
+	pending.MigrationBatchID = "batch-failed"
+	if err := a.Tickets.Update(ctx, pending); err != nil {
+		t.Fatal(err)
+	}
+
+	if settled, err := a.Migrations.SettleMigrations(ctx); err != nil || settled != 1 {
+		t.Fatalf("settle = %d, %v, want 1", settled, err)
+	}
+	got, _ := a.Tickets.FindByID(ctx, ticket.ID)
+	if got.ProcessDefinitionID != pending.ProcessDefinitionID || got.MigrationBatchID != "" {
+		t.Errorf("ticket on %s with batch %q, want the source definition and no batch", got.ProcessDefinitionID, got.MigrationBatchID)
+	}
+}
+
+func TestMigrateLeavesTicketsOfOtherProcesses(t *testing.T) {
+	a, engine := newTestApp(t)
+	ctx := context.Background()
+	engine.AddDefinition("hardware-request")
+	if err := a.Types.Create(ctx, &models.TicketType{Key: "hardware", Name: "Hardware", ProcessKey: "hardware-request"}); err != nil {
+		t.Fatalf("create type: %v", err)
+	}
+	standard := &models.Ticket{Title: "vpn access", Requester: "alice"}
+	hardware := &models.Ticket{Title: "new laptop", Requester: "alice", Type: "hardware"}
+	for _, ticket := range []*models.Ticket{standard, hardware} {
+		if err := a.Workflow.CreateTicket(ctx, ticket); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		if err := a.Workflow.SubmitTicket(ctx, ticket.ID); err != nil {
+			t.Fatalf("submit: %v", err)
+		}
+	}
+	before, _ := a.Tickets.FindByID(ctx, hardware.ID)
+	target := engine.AddDefinition(config.Default().Camunda.ProcessKey)
+
+	report, err := a.Migrations.Migrate(ctx, service.MigrationRequest{TargetVersion: target.Version})
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	status := map[uuid.UUID]string{}
+	for _, result := range report.Results {
+		status[result.TicketID] = result.Status
+	}
+	if status[standard.ID] != service.MigrationStatusMigrated || status[hardware.ID] != service.MigrationStatusSkipped {
+		t.Fatalf("results = %+v, want the standard ticket migrated and the hardware ticket skipped", report.Results)
+	}
+	if instance, _ := engine.Instance(before.ProcessInstanceID); instance.DefinitionID != before.ProcessDefinitionID {
+		t.Errorf("hardware instance moved to %s", instance.DefinitionID)
+	}
+	got, _ := a.Tickets.FindByID(ctx, hardware.ID)
+	if got.ProcessDefinitionID != before.ProcessDefinitionID {
+		t.Errorf("hardware ticket on %s, want %s", got.ProcessDefinitionID, before.ProcessDefinitionID)
+	}
+}
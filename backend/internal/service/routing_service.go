@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/example/pflow/backend/internal/directory"
+	"github.com/example/pflow/backend/internal/models"
+	"github.com/example/pflow/backend/internal/repository"
+)
+
+// RoutingDecision is the set of approvers a ticket is routed to.
+type RoutingDecision struct {
+	RuleID   uint     `json:"ruleId,omitempty"`
+	RuleName string   `json:"ruleName,omitempty"`
+	Users    []string `json:"candidateUsers"`
+	Groups   []string `json:"candidateGroups"`
+	// Assignee is set when the rule's strategy picked a single approver.
+	Assignee string `json:"assignee,omitempty"`
+}
+
+// Variables returns the process variables consumed by the approval task's candidateUsers,
+// candidateGroups and assignee expressions.
+func (d *RoutingDecision) Variables() map[string]any {
+	vars := map[string]any{
+		"approverUsers":  strings.Join(d.Users, ","),
+		"approverGroups": strings.Join(d.Groups, ","),
+		"approver":       nil,
+	}
+	if d.Assignee != "" {
+		vars["approver"] = d.Assignee
+	}
+	return vars
+}
+
+// RoutingService evaluates the ordered routing rules to pick the approvers of submitted tickets.
+type RoutingService struct {
+	rules        *repository.RoutingRuleRepository
+	tickets      repository.TicketStore
+	directory    directory.Directory
+	assignments  *AssignmentService
+	defaultGroup string
+}
+
+// NewRoutingService builds a service with dependencies. Tickets matching no rule go to defaultGroup.
+func NewRoutingService(rules *repository.RoutingRuleRepository, tickets repository.TicketStore, dir directory.Directory, assignments *AssignmentService, defaultGroup string) *RoutingService {
+	return &RoutingService{rules: rules, tickets: tickets, directory: dir, assignments: assignments, defaultGroup: defaultGroup}
+}
+
+// List returns all rules in evaluation order.
+func (s *RoutingService) List(ctx context.Context) ([]models.RoutingRule, error) {
+	return s.rules.List(ctx)
+}
+
+// Save validates and stores a rule.
+func (s *RoutingService) Save(ctx context.Context, rule *models.RoutingRule) error {
+	if rule.Strategy == "" {
+		rule.Strategy = models.RoutingStrategyCandidates
+	}
+	switch rule.Strategy {
+	case models.RoutingStrategyCandidates:
+		if len(rule.CandidateUsers) == 0 && len(rule.CandidateGroups) == 0 {
+			return errors.Wrap(ErrInvalidTicket, "routing rule needs candidate users or groups")
+		}
+	case models.RoutingStrategyRoundRobin, models.RoutingStrategyLeastLoaded:
+		if len(rule.CandidateUsers) == 0 {
+			return errors.Wrapf(ErrInvalidTicket, "strategy %s needs candidate users", rule.Strategy)
+		}
+	default:
+		return errors.Wrapf(ErrInvalidTicket, "unknown routing strategy %s", rule.Strategy)
+	}
+	if rule.MinAmount != nil && rule.MaxAmount != nil && *rule.MinAmount > *rule.MaxAmount {
+		return errors.Wrap(ErrInvalidTicket, "minAmount is greater than maxAmount")
+	}
+	return s.rules.Save(ctx, rule)
+}
+
+// Delete removes a rule.
+func (s *RoutingService) Delete(ctx context.Context, id uint) error {
+	return s.rules.Delete(ctx, id)
+}
+
+// Route returns the approvers of the first enabled rule matching the ticket, or the default group.
+func (s *RoutingService) Route(ctx context.Context, ticket *models.Ticket) (*RoutingDecision, error) {
+	rules, err := s.rules.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	department := ""
+	if user, err := s.directory.Lookup(ctx, ticket.Requester); err == nil {
+		department = user.Department
+	} else {
+		log.Printf("look up requester %s for routing failed: %v", ticket.Requester, err)
+	}
+	for i := range rules {
+		rule := &rules[i]
+		if !rule.Enabled || !ruleMatches(rule, ticket, department) {
+			continue
+		}
+		decision := &RoutingDecision{RuleID: rule.ID, RuleName: rule.Name, Users: rule.CandidateUsers, Groups: rule.CandidateGroups}
+		assignee, err := s.pickAssignee(ctx, rule)
+		if err != nil || assignee == "" {
+			return decision, err
+		}
+		// Approvers who are out of office get their new tasks forwarded to their deputy.
+		decision.Assignee, err = s.assignments.Deputy(ctx, assignee, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		return decision, nil
+	}
+	return &RoutingDecision{Groups: []string{s.defaultGroup}}, nil
+}
+
+func (s *RoutingService) pickAssignee(ctx context.Context, rule *models.RoutingRule) (string, error) {
+	users := rule.CandidateUsers
+	switch rule.Strategy {
+	case models.RoutingStrategyRoundRobin:
+		cursor, err := s.rules.NextCursor(ctx, rule.ID)
+		if err != nil {
+			return "", err
+		}
+		return users[(cursor-1)%len(users)], nil
+	case models.RoutingStrategyLeastLoaded:
+		load, err := s.tickets.CountOpenByAssignee(ctx, users)
+		if err != nil {
+			return "", err
+		}
+		best := users[0]
+		for _, user := range users[1:] {
+			if load[user] < load[best] {
+				best = user
+			}
+		}
+		return best, nil
+	}
+	return "", nil
+}
+
+func ruleMatches(rule *models.RoutingRule, ticket *models.Ticket, department string) bool {
+	if rule.TicketType != "" && rule.TicketType != ticket.Type {
+		return false
+	}
+	if rule.Department != "" && !strings.EqualFold(rule.Department, department) {
+		return false
+	}
+	for field, expected := range rule.FieldEquals {
+		actual, ok := ticket.CustomFields[field]
+		if !ok || !fieldMatches(expected, actual) {
+			return false
+		}
+	}
+	if rule.MinAmount == nil && rule.MaxAmount == nil {
+		return true
+	}
+	field := rule.AmountField
+	if field == "" {
+		field = "amount"
+	}
+	amount, ok := numericField(ticket.CustomFields[field])
+	if !ok {
+		return false
+	}
+	if rule.MinAmount != nil && amount < *rule.MinAmount {
+		return false
+	}
+	return rule.MaxAmount == nil || amount < *rule.MaxAmount
+}
+
+func fieldMatches(expected, actual any) bool {
+	if options, ok := expected.([]any); ok {
+		for _, option := range options {
+			if fieldMatches(option, actual) {
+				return true
+			}
+		}
+		return false
+	}
+	return fmt.Sprint(expected) == fmt.Sprint(actual)
+}
+
+func numericField(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
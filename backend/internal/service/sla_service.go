@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/example/pflow/backend/internal/models"
+	"github.com/example/pflow/backend/internal/mq"
+	"github.com/example/pflow/backend/internal/repository"
+)
+
+// SLADefaults is the policy applied when no configured SLA policy matches a ticket.
+type SLADefaults struct {
+	Approval        time.Duration
+	Resolution      time.Duration
+	WarnBefore      time.Duration
+	EscalateAfter   time.Duration
+	EscalationGroup string
+}
+
+// SLAService assigns SLA deadlines to tickets and detects breaches.
+type SLAService struct {
+	policies *repository.SLAPolicyRepository
+	tickets  repository.TicketStore
+	mq       mq.Publisher
+	defaults SLADefaults
+}
+
+// NewSLAService builds a service with dependencies.
+func NewSLAService(policies *repository.SLAPolicyRepository, tickets repository.TicketStore, mq mq.Publisher, defaults SLADefaults) *SLAService {
+	return &SLAService{policies: policies, tickets: tickets, mq: mq, defaults: defaults}
+}
+
+// ListPolicies returns the configured SLA policies.
+func (s *SLAService) ListPolicies(ctx context.Context) ([]models.SLAPolicy, error) {
+	return s.policies.List(ctx)
+}
+
+// SavePolicy creates or updates an SLA policy.
+func (s *SLAService) SavePolicy(ctx context.Context, policy *models.SLAPolicy) error {
+	if policy.ApprovalMinutes < 0 || policy.ResolutionMinutes < 0 || policy.WarnBeforeMinutes < 0 || policy.EscalateAfterMinutes < 0 {
+		return fmt.Errorf("sla durations must not be negative")
+	}
+	return s.policies.Save(ctx, policy)
+}
+
+// DeletePolicy removes an SLA policy.
+func (s *SLAService) DeletePolicy(ctx context.Context, id uint) error {
+	return s.policies.Delete(ctx, id)
+}
+
+// Start computes the ticket's deadlines on submission and returns the process variables that drive
+// the BPMN escalation timer.
+func (s *SLAService) Start(ctx context.Context, ticket *models.Ticket, now time.Time) (map[string]any, error) {
+	policies, err := s.policies.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	policy := s.policyFor(policies, ticket)
+	approvalDue := now.Add(policy.approval)
+	resolutionDue := now.Add(policy.resolution)
+	ticket.ApprovalDueAt = &approvalDue
+	ticket.ResolutionDueAt = &resolutionDue
+	ticket.SLAPhase = models.SLAPhaseApproval
+	ticket.SLAState = models.SLAStateOnTrack
+	ticket.SLAWarnedAt = nil
+	ticket.SLABreachedAt = nil
+
+	return map[string]any{
+		"approvalDueDate":    approvalDue.UTC(),
+		"resolutionDueDate":  resolutionDue.UTC(),
+		"escalationAfter":    isoDuration(policy.escalateAfter),
+		"escalationGroup":    policy.escalationGroup,
+		"escalationAssignee": policy.escalationAssignee,
+	}, nil
+}
+
+// DecisionRecorded closes the approval phase. Approved tickets continue against the resolution
+// deadline, even while the status sync has not moved them on from submitted yet.
+func (s *SLAService) DecisionRecorded(ticket *models.Ticket, approved bool) {
+	if ticket.SLAState == "" {
+		return
+	}
+	if !approved {
+		s.finish(ticket)
+		return
+	}
+	enterPhase(ticket, models.SLAPhaseResolution)
+}
+
+// Completed closes the resolution phase.
+func (s *SLAService) Completed(ticket *models.Ticket) {
+	if ticket.SLAState != "" {
+		s.finish(ticket)
+	}
+}
+
+// finish records the final state: breached when any phase missed its deadline, met otherwise.
+func (s *SLAService) finish(ticket *models.Ticket) {
+	if ticket.SLABreachedAt != nil {
+		ticket.SLAState = models.SLAStateBreached
+	} else {
+		ticket.SLAState = models.SLAStateMet
+	}
+}
+
+// enterPhase measures the ticket against the deadline of the phase from now on. SLABreachedAt
+// keeps recording an earlier phase's breach.
+func enterPhase(ticket *models.Ticket, phase string) {
+	ticket.SLAPhase = phase
+	ticket.SLAState = models.SLAStateOnTrack
+	ticket.SLAWarnedAt = nil
+}
+
+// Check scans active tickets and publishes ticket.sla_warning and ticket.sla_breached events for
+// tickets that crossed their warning threshold or deadline since the last run.
+func (s *SLAService) Check(ctx context.Context, now time.Time) error {
+	policies, err := s.policies.List(ctx)
+	if err != nil {
+		return err
+	}
+	tickets, err := s.tickets.Find(ctx, repository.TicketFilter{Statuses: models.ActiveTicketStatuses})
+	if err != nil {
+		return err
+	}
+	for i := range tickets {
+		ticket := &tickets[i]
+		if ticket.SLAState == "" || ticket.SLAState == models.SLAStateMet {
+			continue
+		}
+		phase, due := currentDeadline(ticket)
+		// Tickets approved outside the API reach the resolution phase through the status sync.
+		changed := phase != ticket.SLAPhase
+		switch {
+		case ticket.SLAPhase == "":
+			// Tracked before phases were recorded: keep the state it has.
+			ticket.SLAPhase = phase
+		case changed:
+			enterPhase(ticket, phase)
+		}
+		var event string
+		switch {
+		case due == nil || ticket.SLAState == models.SLAStateBreached:
+		case now.After(*due):
+			ticket.SLAState = models.SLAStateBreached
+			if ticket.SLABreachedAt == nil {
+				ticket.SLABreachedAt = &now
+			}
+			event = "ticket.sla_breached"
+		case ticket.SLAState != models.SLAStateAtRisk && now.After(due.Add(-s.policyFor(policies, ticket).warnBefore)):
+			ticket.SLAState = models.SLAStateAtRisk
+			ticket.SLAWarnedAt = &now
+			event = "ticket.sla_warning"
+		}
+		if event == "" && !changed {
+			continue
+		}
+		if err := s.tickets.Update(ctx, ticket); err != nil {
+			log.Printf("update sla state of ticket %s failed: %v", ticket.ID, err)
+			continue
+		}
+		if event != "" {
+			s.publish(ctx, event, ticket, phase, *due)
+		}
+	}
+	return nil
+}
+
+func (s *SLAService) publish(ctx context.Context, event string, ticket *models.Ticket, phase string, due time.Time) {
+	if s.mq == nil {
+		return
+	}
+	payload := ticketEvent(event, ticket)
+	payload["slaState"] = ticket.SLAState
+	payload["slaPhase"] = phase
+	payload["dueAt"] = due.UTC().Format(time.RFC3339)
+	if err := s.mq.Publish(ctx, mq.RoutingKey(ticket.TenantID, event), payload); err != nil {
+		log.Printf("publish %s failed: %v", event, err)
+	}
+}
+
+// currentDeadline returns the phase the ticket is in and its deadline. The approval phase ends
+// with a recorded decision or when the ticket leaves submitted.
+func currentDeadline(ticket *models.Ticket) (string, *time.Time) {
+	if ticket.Status == models.TicketStatusSubmitted && ticket.SLAPhase != models.SLAPhaseResolution {
+		return models.SLAPhaseApproval, ticket.ApprovalDueAt
+	}
+	return models.SLAPhaseResolution, ticket.ResolutionDueAt
+}
+
+type resolvedPolicy struct {
+	approval           time.Duration
+	resolution         time.Duration
+	warnBefore         time.Duration
+	escalateAfter      time.Duration
+	escalationGroup    string
+	escalationAssignee string
+}
+
+// policyFor picks the most specific matching policy and fills unset values from the defaults.
+func (s *SLAService) policyFor(policies []models.SLAPolicy, ticket *models.Ticket) resolvedPolicy {
+	resolved := resolvedPolicy{
+		approval:        s.defaults.Approval,
+		resolution:      s.defaults.Resolution,
+		warnBefore:      s.defaults.WarnBefore,
+		escalateAfter:   s.defaults.EscalateAfter,
+		escalationGroup: s.defaults.EscalationGroup,
+	}
+	matching := make([]models.SLAPolicy, 0, len(policies))
+	for _, p := range policies {
+		if p.Matches(ticket) {
+			matching = append(matching, p)
+		}
+	}
+	sort.SliceStable(matching, func(i, j int) bool { return matching[i].Specificity() > matching[j].Specificity() })
+	if len(matching) > 0 {
+		p := matching[0]
+		setMinutes(&resolved.approval, p.ApprovalMinutes)
+		setMinutes(&resolved.resolution, p.ResolutionMinutes)
+		setMinutes(&resolved.warnBefore, p.WarnBeforeMinutes)
+		setMinutes(&resolved.escalateAfter, p.EscalateAfterMinutes)
+		if p.EscalationGroup != "" {
+			resolved.escalationGroup = p.EscalationGroup
+		}
+		resolved.escalationAssignee = p.EscalationAssignee
+	}
+	if resolved.escalateAfter <= 0 {
+		resolved.escalateAfter = resolved.approval
+	}
+	return resolved
+}
+
+func setMinutes(d *time.Duration, minutes int) {
+	if minutes > 0 {
+		*d = time.Duration(minutes) * time.Minute
+	}
+}
+
+// isoDuration formats d as an ISO 8601 duration understood by BPMN timer definitions.
+func isoDuration(d time.Duration) string {
+	seconds := int64(d / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return fmt.Sprintf("PT%dS", seconds)
+}
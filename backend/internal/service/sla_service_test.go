@@ -0,0 +1,124 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/example/pflow/backend/internal/models"
+	"github.com/example/pflow/backend/internal/repository"
+	"github.com/example/pflow/backend/internal/service"
+)
+
+// slaEvent is a published SLA event.
+type slaEvent struct {
+	name, phase string
+}
+
+type slaEvents []slaEvent
+
+func (e *slaEvents) Publish(_ context.Context, routingKey string, payload any) error {
+	*e = append(*e, slaEvent{routingKey, payload.(map[string]any)["slaPhase"].(string)})
+	return nil
+}
+
+func TestSLAApprovalToResolution(t *testing.T) {
+	tests := []struct {
+		name string
+		// approve moves the ticket into the resolution phase.
+		approve func(sla *service.SLAService, ticket *models.Ticket)
+	}{
+		{"decision recorded", func(sla *service.SLAService, ticket *models.Ticket) {
+			// The status sync has not caught up yet: the ticket is still submitted.
+			sla.DecisionRecorded(ticket, true)
+		}},
+		{"approved outside the api", func(_ *service.SLAService, ticket *models.Ticket) {
+			ticket.Status = models.TicketStatusProcessing
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, _ := newTestApp(t)
+			ctx := context.Background()
+			var events slaEvents
+			sla := service.NewSLAService(repository.NewSLAPolicyRepository(a.DB), a.Tickets, &events, service.SLADefaults{
+				Approval:   time.Hour,
+				Resolution: 4 * time.Hour,
+				WarnBefore: time.Minute,
+			})
+			start := time.Now()
+			ticket := &models.Ticket{Title: "new laptop", Requester: "alice", Status: models.TicketStatusSubmitted}
+			if _, err := sla.Start(ctx, ticket, start); err != nil {
+				t.Fatal(err)
+			}
+			if err := a.Tickets.Create(ctx, ticket); err != nil {
+				t.Fatal(err)
+			}
+			check := func(at time.Duration) *models.Ticket {
+				t.Helper()
+				if err := sla.Check(ctx, start.Add(at)); err != nil {
+					t.Fatalf("check: %v", err)
+				}
+				got, err := a.Tickets.FindByID(ctx, ticket.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return got
+			}
+
+			got := check(2 * time.Hour)
+			if got.SLAState != models.SLAStateBreached || got.SLAPhase != models.SLAPhaseApproval {
+				t.Fatalf("after approval deadline: %s in %s, want breached in approval", got.SLAState, got.SLAPhase)
+			}
+			breachedAt := got.SLABreachedAt
+
+			tt.approve(sla, got)
+			if err := a.Tickets.Update(ctx, got); err != nil {
+				t.Fatal(err)
+			}
+			got = check(2*time.Hour + time.Minute)
+			if got.SLAState != models.SLAStateOnTrack || got.SLAPhase != models.SLAPhaseResolution {
+				t.Errorf("after approval: %s in %s, want on track in resolution", got.SLAState, got.SLAPhase)
+			}
+			got = check(5 * time.Hour)
+			if got.SLAState != models.SLAStateBreached || !got.SLABreachedAt.Equal(*breachedAt) {
+				t.Errorf("after resolution deadline: %s breached at %v, want breached at %v", got.SLAState, got.SLABreachedAt, breachedAt)
+			}
+
+			want := slaEvents{
+				{"ticket.sla_breached", models.SLAPhaseApproval},
+				{"ticket.sla_breached", models.SLAPhaseResolution},
+			}
+			if len(events) != len(want) {
+				t.Fatalf("events = %v, want %v", events, want)
+			}
+			for i := range want {
+				if events[i] != want[i] {
+					t.Errorf("event %d = %v, want %v", i, events[i], want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSLAFinishReportsEarlierBreach(t *testing.T) {
+	a, _ := newTestApp(t)
+	ctx := context.Background()
+	start := time.Now()
+	ticket := &models.Ticket{Title: "new laptop", Requester: "alice", Status: models.TicketStatusSubmitted}
+	if _, err := a.SLA.Start(ctx, ticket, start); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Tickets.Create(ctx, ticket); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.SLA.Check(ctx, ticket.ApprovalDueAt.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := a.Tickets.FindByID(ctx, ticket.ID)
+	a.SLA.DecisionRecorded(got, true)
+	a.SLA.Completed(got)
+	if got.SLAState != models.SLAStateBreached {
+		t.Errorf("state = %s, want breached: the approval deadline was missed", got.SLAState)
+	}
+}
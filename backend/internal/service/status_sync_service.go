@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/example/pflow/backend/internal/models"
+	"github.com/example/pflow/backend/internal/mq"
+	"github.com/example/pflow/backend/internal/repository"
+	"github.com/example/pflow/backend/internal/workflow"
+)
+
+// statusSyncCursor names the position of the status sync in the sync cursor table.
+const statusSyncCursor = "ticket-status"
+
+const statusSyncPageSize = 200
+
+// statusRank orders statuses along the ticket life-cycle; the sync only moves tickets forward.
+var statusRank = map[models.TicketStatus]int{
+	models.TicketStatusDraft:      0,
+	models.TicketStatusSubmitted:  1,
+	models.TicketStatusApproved:   2,
+	models.TicketStatusProcessing: 3,
+	models.TicketStatusFailed:     3,
+	models.TicketStatusCompleted:  4,
+	models.TicketStatusRejected:   4,
+	models.TicketStatusCancelled:  4,
+}
+
+// ParseStatusMapping checks an activity ID to status mapping read from configuration.
+func ParseStatusMapping(raw map[string]string) (map[string]models.TicketStatus, error) {
+	mapping := make(map[string]models.TicketStatus, len(raw))
+	for activityID, value := range raw {
+		status := models.TicketStatus(value)
+		if _, ok := statusRank[status]; !ok {
+			return nil, errors.Errorf("activity %s maps to unknown ticket status %q", activityID, value)
+		}
+		mapping[activityID] = status
+	}
+	return mapping, nil
+}
+
+// StatusSyncService derives ticket status from the activities Camunda records in its history:
+// when a process instance reaches an activity listed in the mapping, its ticket takes the mapped
+// status. The start time of the last applied activity is kept as a watermark.
+type StatusSyncService struct {
+	tickets repository.TicketStore
+	camunda *workflow.CamundaClient
+	cursors *repository.SyncCursorRepository
+	sla     *SLAService
+	mq      mq.Publisher
+	mapping map[string]models.TicketStatus
+	overlap time.Duration
+}
+
+// NewStatusSyncService builds a service with dependencies. Each sync reads again from overlap
+// before the watermark to pick up activities whose transaction committed late.
+func NewStatusSyncService(tickets repository.TicketStore, camunda *workflow.CamundaClient, cursors *repository.SyncCursorRepository, sla *SLAService, mq mq.Publisher, mapping map[string]models.TicketStatus, overlap time.Duration) *StatusSyncService {
+	return &StatusSyncService{tickets: tickets, camunda: camunda, cursors: cursors, sla: sla, mq: mq, mapping: mapping, overlap: overlap}
+}
+
+// Sync applies the mapped activities started since the watermark and returns the number of
+// tickets whose status changed.
+func (s *StatusSyncService) Sync(ctx context.Context) (int, error) {
+	position, err := s.cursors.Position(ctx, statusSyncCursor)
+	if err != nil {
+		return 0, err
+	}
+	from := position
+	if !from.IsZero() {
+		from = from.Add(-s.overlap)
+	}
+	return s.syncFrom(ctx, from, position)
+}
+
+// SyncSince reads the activity history again from since, to repair tickets after an outage longer
+// than the overlap. The watermark only moves forward.
+func (s *StatusSyncService) SyncSince(ctx context.Context, since time.Time) (int, error) {
+	position, err := s.cursors.Position(ctx, statusSyncCursor)
+	if err != nil {
+		return 0, err
+	}
+	return s.syncFrom(ctx, since, position)
+}
+
+func (s *StatusSyncService) syncFrom(ctx context.Context, from, position time.Time) (int, error) {
+	changed := 0
+	for first := 0; ; first += statusSyncPageSize {
+		page, err := s.camunda.QueryHistoricActivityInstances(ctx, workflow.HistoricActivityQuery{
+			StartedAfter: from,
+			FirstResult:  first,
+			MaxResults:   statusSyncPageSize,
+		})
+		if err != nil {
+			return changed, errors.Wrap(err, "query activity history")
+		}
+		n, latest, err := s.apply(ctx, page)
+		changed += n
+		if err != nil {
+			return changed, err
+		}
+		if latest.After(position) {
+			position = latest
+			if err := s.cursors.Save(ctx, statusSyncCursor, position); err != nil {
+				return changed, err
+			}
+		}
+		if len(page) < statusSyncPageSize {
+			return changed, nil
+		}
+	}
+}
+
+// apply updates the tickets of one page of activities and returns the latest start time seen.
+func (s *StatusSyncService) apply(ctx context.Context, activities []workflow.HistoricActivityInstance) (int, time.Time, error) {
+	var latest time.Time
+	var instanceIDs []string
+	for _, a := range activities {
+		if start, err := workflow.ParseTime(a.StartTime); err == nil && start.After(latest) {
+			latest = start
+		}
+		if _, ok := s.mapping[a.ActivityID]; ok && !a.Canceled {
+			instanceIDs = append(instanceIDs, a.ProcessInstanceID)
+		}
+	}
+	if len(instanceIDs) == 0 {
+		return 0, latest, nil
+	}
+	tickets, err := s.tickets.Find(ctx, repository.TicketFilter{ProcessInstanceIDs: instanceIDs})
+	if err != nil {
+		return 0, latest, err
+	}
+	byInstance := make(map[string]*models.Ticket, len(tickets))
+	for i := range tickets {
+		byInstance[tickets[i].ProcessInstanceID] = &tickets[i]
+	}
+
+	changed := 0
+	for _, a := range activities {
+		status, ok := s.mapping[a.ActivityID]
+		ticket := byInstance[a.ProcessInstanceID]
+		if !ok || a.Canceled || ticket == nil || !advances(ticket.Status, status) {
+			continue
+		}
+		ticket.Status = status
+		if status.Terminal() {
+			s.sla.Completed(ticket)
+		}
+		if err := s.tickets.Update(ctx, ticket); err != nil {
+			return changed, latest, err
+		}
+		changed++
+		log.Printf("ticket %s is %s: process reached %s", ticket.ID, status, a.ActivityID)
+		if s.mq != nil {
+			event := "ticket." + string(status)
+			if err := s.mq.Publish(ctx, mq.RoutingKey(ticket.TenantID, event), ticketEvent(event, ticket)); err != nil {
+				log.Printf("publish %s failed: %v", event, err)
+			}
+		}
+	}
+	return changed, latest, nil
+}
+
+// advances reports whether the sync may move a ticket from one status to the other.
+func advances(from, to models.TicketStatus) bool {
+	return !from.Terminal() && statusRank[to] > statusRank[from]
+}
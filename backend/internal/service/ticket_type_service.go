@@ -0,0 +1,210 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+
+	"github.com/example/pflow/backend/internal/models"
+	"github.com/example/pflow/backend/internal/provision"
+	"github.com/example/pflow/backend/internal/repository"
+)
+
+// ErrInvalidTicket marks errors caused by ticket input that fails validation.
+var ErrInvalidTicket = errors.New("invalid ticket")
+
+type compiledSchema struct {
+	updatedAt time.Time
+	schema    *jsonschema.Schema
+}
+
+// TicketTypeService manages ticket types and validates custom fields against their schemas.
+type TicketTypeService struct {
+	types             *repository.TicketTypeRepository
+	tickets           repository.TicketStore
+	defaultProcessKey string
+	provisioner       *provision.Runner
+
+	mu      sync.Mutex
+	schemas map[string]compiledSchema
+}
+
+// NewTicketTypeService builds a service with dependencies.
+func NewTicketTypeService(types *repository.TicketTypeRepository, tickets repository.TicketStore, defaultProcessKey string, provisioner *provision.Runner) *TicketTypeService {
+	return &TicketTypeService{types: types, tickets: tickets, defaultProcessKey: defaultProcessKey, provisioner: provisioner, schemas: map[string]compiledSchema{}}
+}
+
+// List returns all ticket types.
+func (s *TicketTypeService) List(ctx context.Context) ([]models.TicketType, error) {
+	return s.types.List(ctx)
+}
+
+// Get returns a ticket type by key.
+func (s *TicketTypeService) Get(ctx context.Context, key string) (*models.TicketType, error) {
+	return s.types.FindByKey(ctx, key)
+}
+
+// Create stores a new ticket type after checking that its schema compiles.
+func (s *TicketTypeService) Create(ctx context.Context, ticketType *models.TicketType) error {
+	if ticketType.Key == "" {
+		return errors.Wrap(ErrInvalidTicket, "ticket type key is required")
+	}
+	if err := s.validateTicketType(ticketType); err != nil {
+		return err
+	}
+	return s.types.Create(ctx, ticketType)
+}
+
+// Update replaces an existing ticket type after checking that its schema compiles.
+func (s *TicketTypeService) Update(ctx context.Context, ticketType *models.TicketType) error {
+	existing, err := s.types.FindByKey(ctx, ticketType.Key)
+	if err != nil {
+		return err
+	}
+	if err := s.validateTicketType(ticketType); err != nil {
+		return err
+	}
+	ticketType.CreatedAt = existing.CreatedAt
+	return s.types.Update(ctx, ticketType)
+}
+
+// Delete removes a ticket type that no ticket uses.
+func (s *TicketTypeService) Delete(ctx context.Context, key string) error {
+	inUse, err := s.tickets.Find(ctx, repository.TicketFilter{Type: key, Limit: 1})
+	if err != nil {
+		return err
+	}
+	if len(inUse) > 0 {
+		return errors.Errorf("ticket type %s is still used by tickets", key)
+	}
+	s.mu.Lock()
+	delete(s.schemas, key)
+	s.mu.Unlock()
+	return s.types.Delete(ctx, key)
+}
+
+// Validate checks the ticket's custom fields against the schema of its type.
+func (s *TicketTypeService) Validate(ctx context.Context, ticket *models.Ticket) error {
+	if ticket.Type == "" {
+		if len(ticket.CustomFields) > 0 {
+			return errors.Wrap(ErrInvalidTicket, "custom fields require a ticket type")
+		}
+		return nil
+	}
+	ticketType, err := s.types.FindByKey(ctx, ticket.Type)
+	if err != nil {
+		return errors.Wrapf(ErrInvalidTicket, "unknown ticket type %s", ticket.Type)
+	}
+	schema, err := s.schemaFor(ticketType)
+	if err != nil {
+		return err
+	}
+	fields := map[string]any{}
+	for k, v := range ticket.CustomFields {
+		fields[k] = v
+	}
+	if err := schema.Validate(fields); err != nil {
+		return errors.Wrap(ErrInvalidTicket, err.Error())
+	}
+	return nil
+}
+
+// ProcessKey returns the BPMN process key tickets of the given type start.
+func (s *TicketTypeService) ProcessKey(ctx context.Context, typeKey string) (string, error) {
+	if typeKey == "" {
+		return s.defaultProcessKey, nil
+	}
+	ticketType, err := s.types.FindByKey(ctx, typeKey)
+	if err != nil {
+		return "", err
+	}
+	if ticketType.ProcessKey == "" {
+		return s.defaultProcessKey, nil
+	}
+	return ticketType.ProcessKey, nil
+}
+
+// ProvisionAction returns the provisioning action of the ticket type, or nil when tickets of the
+// type need no provisioning.
+func (s *TicketTypeService) ProvisionAction(ctx context.Context, typeKey string) (*models.ProvisionAction, error) {
+	if typeKey == "" {
+		return nil, nil
+	}
+	ticketType, err := s.types.FindByKey(ctx, typeKey)
+	if err != nil {
+		return nil, err
+	}
+	return ticketType.Provision, nil
+}
+
+// ProcessVariables maps the custom fields selected by the ticket's type into process variables.
+func (s *TicketTypeService) ProcessVariables(ctx context.Context, ticket *models.Ticket) (map[string]any, error) {
+	vars := map[string]any{}
+	if ticket.Type == "" {
+		return vars, nil
+	}
+	ticketType, err := s.types.FindByKey(ctx, ticket.Type)
+	if err != nil {
+		return nil, err
+	}
+	vars["ticketType"] = ticketType.Key
+	for field, variable := range ticketType.ProcessVariables {
+		if v, ok := ticket.CustomFields[field]; ok {
+			vars[variable] = v
+		}
+	}
+	return vars, nil
+}
+
+func (s *TicketTypeService) schemaFor(ticketType *models.TicketType) (*jsonschema.Schema, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cached, ok := s.schemas[ticketType.Key]; ok && cached.updatedAt.Equal(ticketType.UpdatedAt) {
+		return cached.schema, nil
+	}
+	schema, err := compileSchema(ticketType)
+	if err != nil {
+		return nil, err
+	}
+	s.schemas[ticketType.Key] = compiledSchema{updatedAt: ticketType.UpdatedAt, schema: schema}
+	return schema, nil
+}
+
+// validateTicketType checks that the schema compiles and the provision action can run.
+func (s *TicketTypeService) validateTicketType(ticketType *models.TicketType) error {
+	if _, err := compileSchema(ticketType); err != nil {
+		return err
+	}
+	if ticketType.Provision != nil {
+		if err := s.provisioner.Validate(*ticketType.Provision); err != nil {
+			return errors.Wrapf(ErrInvalidTicket, "ticket type %s: %v", ticketType.Key, err)
+		}
+	}
+	return nil
+}
+
+func compileSchema(ticketType *models.TicketType) (*jsonschema.Schema, error) {
+	doc := map[string]any(ticketType.Schema)
+	if doc == nil {
+		doc = map[string]any{"type": "object"}
+	}
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	url := "pflow://ticket-types/" + ticketType.Key + ".json"
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(url, bytes.NewReader(raw)); err != nil {
+		return nil, errors.Wrapf(ErrInvalidTicket, "schema of ticket type %s: %v", ticketType.Key, err)
+	}
+	schema, err := compiler.Compile(url)
+	if err != nil {
+		return nil, errors.Wrapf(ErrInvalidTicket, "schema of ticket type %s: %v", ticketType.Key, err)
+	}
+	return schema, nil
+}
@@ -4,35 +4,45 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
-	"gorm.io/gorm"
 
 	"github.com/example/pflow/backend/internal/models"
 	"github.com/example/pflow/backend/internal/mq"
+	"github.com/example/pflow/backend/internal/provision"
 	"github.com/example/pflow/backend/internal/repository"
+	"github.com/example/pflow/backend/internal/tenant"
 	"github.com/example/pflow/backend/internal/workflow"
 )
 
 // WorkflowService contains business logic for bridging persistence and the workflow engine.
 type WorkflowService struct {
-	db         *gorm.DB
-	tickets    *repository.TicketRepository
-	camunda    *workflow.CamundaClient
-	mq         mq.Publisher
-	processKey string
+	tickets     repository.TicketStore
+	camunda     *workflow.CamundaClient
+	definitions *DefinitionService
+	types       *TicketTypeService
+	sla         *SLAService
+	comments    *CommentService
+	routing     *RoutingService
+	provisioner *provision.Runner
+	mq          mq.Publisher
 }
 
 // NewWorkflowService builds a service with dependencies.
-func NewWorkflowService(db *gorm.DB, repo *repository.TicketRepository, camunda *workflow.CamundaClient, mq mq.Publisher, processKey string) *WorkflowService {
-	return &WorkflowService{db: db, tickets: repo, camunda: camunda, mq: mq, processKey: processKey}
+func NewWorkflowService(repo repository.TicketStore, camunda *workflow.CamundaClient, definitions *DefinitionService, types *TicketTypeService, sla *SLAService, comments *CommentService, routing *RoutingService, provisioner *provision.Runner, mq mq.Publisher) *WorkflowService {
+	return &WorkflowService{tickets: repo, camunda: camunda, definitions: definitions, types: types, sla: sla, comments: comments, routing: routing, provisioner: provisioner, mq: mq}
 }
 
 // CreateTicket persists a new ticket, publishes an event and keeps it in draft status.
 func (s *WorkflowService) CreateTicket(ctx context.Context, ticket *models.Ticket) error {
 	ticket.Status = models.TicketStatusDraft
+	if err := s.types.Validate(ctx, ticket); err != nil {
+		return err
+	}
 	if err := s.tickets.Create(ctx, ticket); err != nil {
 		return err
 	}
@@ -42,76 +52,359 @@ func (s *WorkflowService) CreateTicket(ctx context.Context, ticket *models.Ticke
 	return nil
 }
 
-// SubmitTicket transitions a ticket into the workflow and starts a process instance.
+// SubmitTicket transitions a ticket into the workflow and starts a process instance. The ticket row
+// stays locked until the local transaction ends; if it does not commit, the process instance
+// started for it is deleted again so Camunda holds no instance the database does not know about.
 func (s *WorkflowService) SubmitTicket(ctx context.Context, ticketID uuid.UUID) error {
-	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		ticket, err := s.tickets.FindByID(ctx, ticketID)
+	return s.submit(ctx, ticketID, false)
+}
+
+// ResubmitTicket starts a new process instance for a ticket stuck in an active status because its
+// process instance ended or was deleted without the ticket following. The ticket is routed and its
+// SLA restarted as on a first submit.
+func (s *WorkflowService) ResubmitTicket(ctx context.Context, ticketID uuid.UUID) error {
+	return s.submit(ctx, ticketID, true)
+}
+
+func (s *WorkflowService) submit(ctx context.Context, ticketID uuid.UUID, resubmit bool) error {
+	var started *workflow.ProcessInstance
+	var submitted *models.Ticket
+	err := s.tickets.Transaction(ctx, func(ctx context.Context) error {
+		ticket, err := s.tickets.FindByIDForUpdate(ctx, ticketID)
 		if err != nil {
 			return err
 		}
-		if ticket.Status != models.TicketStatusDraft && ticket.Status != models.TicketStatusRejected {
+		if resubmit {
+			if err := s.checkStuck(ctx, ticket); err != nil {
+				return err
+			}
+		} else if ticket.Status != models.TicketStatusDraft && ticket.Status != models.TicketStatusRejected {
 			return errors.Errorf("ticket %s cannot be submitted from status %s", ticket.ID, ticket.Status)
 		}
-		pid, err := s.camunda.StartProcessInstance(ctx, s.processKey, ticket.ID.String(), map[string]any{
-			"requester": ticket.Requester,
-			"title":     ticket.Title,
-		})
+		variables, err := s.types.ProcessVariables(ctx, ticket)
 		if err != nil {
 			return err
 		}
-		ticket.ProcessInstanceID = pid
-		ticket.Status = models.TicketStatusSubmitted
-		if err := s.tickets.Update(ctx, ticket); err != nil {
+		slaVariables, err := s.sla.Start(ctx, ticket, time.Now())
+		if err != nil {
 			return err
 		}
-		return s.publishEvent(ctx, "ticket.submitted", ticket)
+		for k, v := range slaVariables {
+			variables[k] = v
+		}
+		route, err := s.routing.Route(ctx, ticket)
+		if err != nil {
+			return err
+		}
+		for k, v := range route.Variables() {
+			variables[k] = v
+		}
+		ticket.Assignee = route.Assignee
+		if len(ticket.Attachments) > 0 {
+			names := make([]string, 0, len(ticket.Attachments))
+			for _, a := range ticket.Attachments {
+				names = append(names, a.FileName)
+			}
+			variables["attachmentCount"] = len(ticket.Attachments)
+			variables["attachmentNames"] = strings.Join(names, ", ")
+		}
+		variables["requester"] = ticket.Requester
+		variables["title"] = ticket.Title
+		variables["category"] = ticket.Category
+		variables["priority"] = string(ticket.Priority)
+		variables["taskPriority"] = ticket.Priority.TaskPriority()
+		instance, err := s.unrecordedInstance(ctx, ticket)
+		if err != nil {
+			return err
+		}
+		if instance == nil {
+			if instance, err = s.startProcess(ctx, ticket, variables); err != nil {
+				return err
+			}
+			started = instance
+		}
+		ticket.ProcessInstanceID = instance.ID
+		ticket.ProcessDefinitionID = instance.DefinitionID
+		if version, err := s.definitions.Version(ctx, instance.DefinitionID); err != nil {
+			log.Printf("resolve version of %s failed: %v", instance.DefinitionID, err)
+		} else {
+			ticket.ProcessDefinitionVersion = version
+		}
+		ticket.Status = models.TicketStatusSubmitted
+		submitted = ticket
+		return s.tickets.Update(ctx, ticket)
 	})
+	if err != nil {
+		if started != nil {
+			s.compensateStart(ctx, started, err)
+		}
+		return err
+	}
+	event := "ticket.submitted"
+	if resubmit {
+		event = "ticket.resubmitted"
+		log.Printf("ticket %s resubmitted as process instance %s", submitted.ID, submitted.ProcessInstanceID)
+	}
+	if err := s.publishEvent(ctx, event, submitted); err != nil {
+		log.Printf("publish %s failed: %v", event, err)
+	}
+	return nil
+}
+
+// checkStuck refuses to resubmit a ticket that is not active or whose process instance still runs.
+func (s *WorkflowService) checkStuck(ctx context.Context, ticket *models.Ticket) error {
+	if !slices.Contains(models.ActiveTicketStatuses, ticket.Status) {
+		return errors.Errorf("ticket %s cannot be resubmitted from status %s", ticket.ID, ticket.Status)
+	}
+	if ticket.ProcessInstanceID == "" {
+		return nil
+	}
+	instances, err := s.camunda.ListProcessInstancesByBusinessKey(ctx, ticket.ID.String())
+	if err != nil {
+		return errors.Wrap(err, "look up running process instances")
+	}
+	for _, instance := range instances {
+		if instance.ID == ticket.ProcessInstanceID && !instance.Ended {
+			return errors.Errorf("ticket %s is not stuck, process instance %s is still running", ticket.ID, instance.ID)
+		}
+	}
+	return nil
 }
 
-// RecordDecision records manager decision and advances the process via external task completion.
-func (s *WorkflowService) RecordDecision(ctx context.Context, ticketID uuid.UUID, approved bool, comment string) error {
+// compensateStart deletes a process instance whose ticket update was rolled back. It runs even
+// when the request was cancelled, since leaving the instance behind is worse.
+func (s *WorkflowService) compensateStart(ctx context.Context, instance *workflow.ProcessInstance, cause error) {
+	ctx = context.WithoutCancel(ctx)
+	if err := s.camunda.DeleteProcessInstance(ctx, instance.ID); err != nil {
+		log.Printf("compensate process instance %s after failed submit (%v) failed: %v", instance.ID, cause, err)
+		return
+	}
+	log.Printf("deleted process instance %s after failed submit: %v", instance.ID, cause)
+}
+
+// unrecordedInstance guards against starting a ticket twice: a running instance with the ticket's
+// business key that the ticket does not reference yet was started by a submit whose outcome was
+// lost, so it is adopted instead of starting another one.
+func (s *WorkflowService) unrecordedInstance(ctx context.Context, ticket *models.Ticket) (*workflow.ProcessInstance, error) {
+	instances, err := s.camunda.ListProcessInstancesByBusinessKey(ctx, ticket.ID.String())
+	if err != nil {
+		return nil, errors.Wrap(err, "look up running process instances")
+	}
+	for i := range instances {
+		if instances[i].ID != ticket.ProcessInstanceID && !instances[i].Ended {
+			log.Printf("ticket %s already has process instance %s, adopting it", ticket.ID, instances[i].ID)
+			return &instances[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// startProcess starts the pinned definition version of the ticket type's process, or the latest one when unpinned.
+// Tickets of a tenant start the definitions deployed for that tenant.
+func (s *WorkflowService) startProcess(ctx context.Context, ticket *models.Ticket, variables map[string]any) (*workflow.ProcessInstance, error) {
+	processKey, err := s.types.ProcessKey(ctx, ticket.Type)
+	if err != nil {
+		return nil, err
+	}
+	definitionID, err := s.definitions.PinnedDefinitionID(tenant.WithID(ctx, ticket.TenantID), processKey)
+	if err != nil {
+		return nil, err
+	}
+	if definitionID != "" {
+		return s.camunda.StartProcessInstanceByDefinitionID(ctx, definitionID, ticket.ID.String(), variables)
+	}
+	return s.camunda.StartProcessInstanceForTenant(ctx, processKey, ticket.TenantID, ticket.ID.String(), variables)
+}
+
+// RecordDecision completes the ticket's approval task with the manager's decision. The process
+// routes on the approved variable; the ticket status follows from the activity history through
+// StatusSyncService. A non-empty comment is kept in the ticket's discussion thread, linked to the
+// approval step.
+func (s *WorkflowService) RecordDecision(ctx context.Context, ticketID uuid.UUID, approver string, approved bool, comment string) error {
 	ticket, err := s.tickets.FindByID(ctx, ticketID)
 	if err != nil {
 		return err
 	}
-	if ticket.Status != models.TicketStatusSubmitted && ticket.Status != models.TicketStatusProcessing {
+	if ticket.Status != models.TicketStatusSubmitted || ticket.ProcessInstanceID == "" {
 		return errors.Errorf("ticket %s is not awaiting decision", ticket.ID)
 	}
-
-	if approved {
-		ticket.Status = models.TicketStatusApproved
-	} else {
-		ticket.Status = models.TicketStatusRejected
+	task, err := approvalTask(ctx, s.camunda, ticket.ProcessInstanceID)
+	if err != nil {
+		return errors.Wrapf(err, "ticket %s", ticket.ID)
+	}
+	if err := s.camunda.CompleteTask(ctx, task.ID, map[string]any{
+		"approved": approved,
+		"approver": approver,
+		"comment":  comment,
+	}); err != nil {
+		return errors.Wrap(err, "complete approval task")
 	}
+
+	s.sla.DecisionRecorded(ticket, approved)
 	if err := s.tickets.Update(ctx, ticket); err != nil {
 		return err
 	}
-	if err := s.publishEvent(ctx, "ticket.decision", ticket); err != nil {
-		log.Printf("publish ticket.decision failed: %v", err)
+	if err := s.comments.AddDecision(ctx, ticket, approver, approved, comment); err != nil {
+		log.Printf("store decision comment for ticket %s failed: %v", ticket.ID, err)
+	}
+	if s.mq != nil {
+		event := ticketEvent("ticket.decision", ticket)
+		event["approved"] = approved
+		event["approver"] = approver
+		if err := s.mq.Publish(ctx, mq.RoutingKey(ticket.TenantID, "ticket.decision"), event); err != nil {
+			log.Printf("publish ticket.decision failed: %v", err)
+		}
 	}
 	return nil
 }
 
-// CompleteProcessing marks the ticket as completed after asynchronous processing.
-func (s *WorkflowService) CompleteProcessing(ctx context.Context, ticketID uuid.UUID) error {
+// approvalTask returns the open approval task of a process instance. Other user tasks of the
+// instance are ignored; more than one open approval task is an error rather than a guess.
+func approvalTask(ctx context.Context, camunda *workflow.CamundaClient, instanceID string) (*workflow.Task, error) {
+	tasks, err := camunda.ListTasks(ctx, workflow.TaskQuery{
+		ProcessInstanceID: instanceID,
+		TaskDefinitionKey: ApprovalActivityID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	switch len(tasks) {
+	case 0:
+		return nil, ErrNoOpenTask
+	case 1:
+		return &tasks[0], nil
+	}
+	return nil, errors.Wrapf(ErrTaskConflict, "process instance %s has %d open approval tasks", instanceID, len(tasks))
+}
+
+// CancelTicket withdraws a ticket that has not finished yet, deleting its running process instance.
+func (s *WorkflowService) CancelTicket(ctx context.Context, ticketID uuid.UUID, actor string) error {
 	ticket, err := s.tickets.FindByID(ctx, ticketID)
 	if err != nil {
 		return err
 	}
-	ticket.Status = models.TicketStatusCompleted
+	if ticket.Status == models.TicketStatusCompleted || ticket.Status == models.TicketStatusCancelled {
+		return errors.Errorf("ticket %s cannot be cancelled from status %s", ticket.ID, ticket.Status)
+	}
+	if ticket.ProcessInstanceID != "" {
+		if err := s.camunda.DeleteProcessInstance(ctx, ticket.ProcessInstanceID); err != nil {
+			return errors.Wrap(err, "delete process instance")
+		}
+	}
+	ticket.Status = models.TicketStatusCancelled
 	if err := s.tickets.Update(ctx, ticket); err != nil {
 		return err
 	}
-	return s.publishEvent(ctx, "ticket.completed", ticket)
+	log.Printf("ticket %s cancelled by %q", ticket.ID, actor)
+	return s.publishEvent(ctx, "ticket.cancelled", ticket)
+}
+
+// ForceStatus sets a ticket's status by hand, bypassing the life-cycle rules, so operators can
+// repair tickets that went out of sync with their process. The process instance is left as it is.
+// The previous status and the reason are published with a ticket.status_forced event.
+func (s *WorkflowService) ForceStatus(ctx context.Context, ticketID uuid.UUID, status models.TicketStatus, reason, actor string) (*models.Ticket, error) {
+	if _, ok := statusRank[status]; !ok {
+		return nil, errors.Wrapf(ErrInvalidTicket, "unknown status %q", status)
+	}
+	if strings.TrimSpace(reason) == "" {
+		return nil, errors.Wrap(ErrInvalidTicket, "a reason is required")
+	}
+	ticket, err := s.tickets.FindByID(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	previous := ticket.Status
+	if previous == status {
+		return nil, errors.Errorf("ticket %s is already %s", ticket.ID, status)
+	}
+	ticket.Status = status
+	if status.Terminal() {
+		s.sla.Completed(ticket)
+	}
+	if err := s.tickets.Update(ctx, ticket); err != nil {
+		return nil, err
+	}
+	log.Printf("ticket %s forced from %s to %s by %q: %s", ticket.ID, previous, status, actor, reason)
+	if s.mq != nil {
+		event := ticketEvent("ticket.status_forced", ticket)
+		event["previousStatus"] = previous
+		event["reason"] = reason
+		event["actor"] = actor
+		if err := s.mq.Publish(ctx, mq.RoutingKey(ticket.TenantID, "ticket.status_forced"), event); err != nil {
+			log.Printf("publish ticket.status_forced failed: %v", err)
+		}
+	}
+	return ticket, nil
+}
+
+// ReplayEvents publishes the current state of the matching tickets again, oldest first, as
+// ticket.<status> events marked as replayed, for consumers that missed events or rebuild their
+// state. With a limit, the oldest matching tickets are replayed. It returns the number of events
+// published.
+func (s *WorkflowService) ReplayEvents(ctx context.Context, filter repository.TicketFilter) (int, error) {
+	if filterIsEmpty(filter) {
+		return 0, errors.Wrap(ErrInvalidTicket, "ids or a filter are required")
+	}
+	if s.mq == nil {
+		return 0, errors.New("message broker unavailable, cannot replay events")
+	}
+	filter.OldestFirst = true
+	tickets, err := s.tickets.Find(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	for i := range tickets {
+		ticket := &tickets[i]
+		event := "ticket." + string(ticket.Status)
+		payload := ticketEvent(event, ticket)
+		payload["replayed"] = true
+		if err := s.mq.Publish(ctx, mq.RoutingKey(ticket.TenantID, event), payload); err != nil {
+			return i, errors.Wrapf(err, "replay ticket %s", ticket.ID)
+		}
+	}
+	return len(tickets), nil
+}
+
+// CorrelateMessage delivers a BPMN message to the process instance bound to the ticket.
+func (s *WorkflowService) CorrelateMessage(ctx context.Context, ticketID uuid.UUID, messageName string, variables map[string]any, all bool) ([]workflow.MessageCorrelationResult, error) {
+	if messageName == "" {
+		return nil, errors.New("message name is required")
+	}
+	ticket, err := s.tickets.FindByID(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	if ticket.ProcessInstanceID == "" {
+		return nil, errors.Errorf("ticket %s has no running process instance", ticket.ID)
+	}
+	results, err := s.camunda.CorrelateMessage(ctx, workflow.MessageCorrelation{
+		MessageName: messageName,
+		BusinessKey: ticket.ID.String(),
+		Variables:   variables,
+		All:         all,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "correlate message %s", messageName)
+	}
+	if err := s.publishEvent(ctx, "ticket.message_correlated", ticket); err != nil {
+		log.Printf("publish ticket.message_correlated failed: %v", err)
+	}
+	return results, nil
 }
 
 func (s *WorkflowService) publishEvent(ctx context.Context, event string, ticket *models.Ticket) error {
 	if s.mq == nil {
 		return nil
 	}
-	payload := map[string]any{
+	return s.mq.Publish(ctx, mq.RoutingKey(ticket.TenantID, event), ticketEvent(event, ticket))
+}
+
+// ticketEvent builds the common event payload published for ticket changes.
+func ticketEvent(event string, ticket *models.Ticket) map[string]any {
+	return map[string]any{
 		"event":      event,
 		"ticketId":   ticket.ID.String(),
+		"tenantId":   ticket.TenantID,
 		"status":     ticket.Status,
 		"processId":  ticket.ProcessInstanceID,
 		"title":      ticket.Title,
@@ -119,35 +412,162 @@ func (s *WorkflowService) publishEvent(ctx context.Context, event string, ticket
 		"assignee":   ticket.Assignee,
 		"occurredAt": time.Now().UTC().Format(time.RFC3339),
 	}
-	return s.mq.Publish(ctx, event, payload)
 }
 
-// HandleExternalTask is invoked by worker when asynchronous steps are completed.
-func (s *WorkflowService) HandleExternalTask(ctx context.Context, task workflow.ExternalTask) error {
+// External task topics served by HandleExternalTask.
+const (
+	TopicTicketProcessing = "ticket-processing"
+	TopicTicketEscalation = "ticket-escalation"
+)
+
+// ExternalTaskTopics lists every topic the ticket process delegates to the external worker.
+var ExternalTaskTopics = []string{TopicTicketProcessing, TopicTicketEscalation}
+
+// HandleExternalTask is invoked by worker when asynchronous steps are completed. It returns the
+// variables to complete the task with.
+func (s *WorkflowService) HandleExternalTask(ctx context.Context, task workflow.ExternalTask) (map[string]any, error) {
 	switch task.ActivityID {
+	case "ServiceTask_EscalateApproval":
+		return nil, s.escalateApproval(ctx, task)
 	case "ServiceTask_ProcessTicket":
-		ticketID, err := uuid.Parse(task.BusinessKey)
+		return s.provisionTicket(ctx, task)
+	default:
+		return nil, fmt.Errorf("unhandled activity %s", task.ActivityID)
+	}
+}
+
+// provisionTicket runs the provision action of the ticket's type. The ticket is completed by the
+// status sync once the process reaches its end event. When the last retry fails the ticket is
+// marked failed; Camunda then raises an incident.
+func (s *WorkflowService) provisionTicket(ctx context.Context, task workflow.ExternalTask) (map[string]any, error) {
+	ticketID, err := uuid.Parse(task.BusinessKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid business key")
+	}
+	ticket, err := s.tickets.FindByID(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	if ticket.Status != models.TicketStatusProcessing {
+		ticket, err = s.transitionToProcessing(ctx, ticketID)
 		if err != nil {
-			return errors.Wrap(err, "invalid business key")
+			return nil, err
 		}
-		log.Printf("processing external task for ticket %s", ticketID)
-		if err := s.transitionToProcessing(ctx, ticketID); err != nil {
-			return err
+		if err := s.publishEvent(ctx, "ticket.processing", ticket); err != nil {
+			log.Printf("publish ticket.processing failed: %v", err)
 		}
-		return s.publishEvent(ctx, "ticket.processing", map[string]any{
-			"ticketId": ticketID.String(),
-			"activity": task.ActivityID,
+	}
+
+	action, err := s.types.ProvisionAction(ctx, ticket.Type)
+	if err != nil {
+		return nil, err
+	}
+	var outputs map[string]any
+	if action != nil {
+		log.Printf("provisioning ticket %s with %s action", ticket.ID, action.Kind)
+		outputs, err = s.provisioner.Run(ctx, *action, provision.Data{
+			Ticket:    ticket,
+			Fields:    ticket.CustomFields,
+			Variables: plainVariables(task),
 		})
-	default:
-		return fmt.Errorf("unhandled activity %s", task.ActivityID)
+		if err != nil {
+			if lastAttempt(task) {
+				s.failProvisioning(ctx, ticket, err)
+			}
+			return nil, err
+		}
+	}
+	return outputs, nil
+}
+
+// lastAttempt reports whether a failure of this attempt exhausts the task's retries. Retries is
+// nil on the first attempt, which is never the last one with the worker's retry budget.
+func lastAttempt(task workflow.ExternalTask) bool {
+	return task.Retries != nil && *task.Retries <= 1
+}
+
+func (s *WorkflowService) failProvisioning(ctx context.Context, ticket *models.Ticket, cause error) {
+	ticket.Status = models.TicketStatusFailed
+	if err := s.tickets.Update(ctx, ticket); err != nil {
+		log.Printf("mark ticket %s failed: %v", ticket.ID, err)
+		return
 	}
+	event := ticketEvent("ticket.failed", ticket)
+	event["error"] = cause.Error()
+	if s.mq == nil {
+		return
+	}
+	if err := s.mq.Publish(ctx, mq.RoutingKey(ticket.TenantID, "ticket.failed"), event); err != nil {
+		log.Printf("publish ticket.failed failed: %v", err)
+	}
+}
+
+// plainVariables exposes the task's process variables to provision templates as Go values: Dates
+// as time.Time, numbers as int64 or float64 and Json as decoded JSON. A variable that does not
+// convert is passed as sent.
+func plainVariables(task workflow.ExternalTask) map[string]any {
+	out := make(map[string]any, len(task.Variables))
+	for name, v := range task.Variables {
+		value, err := v.Interface()
+		if err != nil {
+			log.Printf("variable %s of external task %s: %v", name, task.ID, err)
+			value = v.Value
+		}
+		out[name] = value
+	}
+	return out
 }
 
-func (s *WorkflowService) transitionToProcessing(ctx context.Context, ticketID uuid.UUID) error {
+func (s *WorkflowService) transitionToProcessing(ctx context.Context, ticketID uuid.UUID) (*models.Ticket, error) {
 	ticket, err := s.tickets.FindByID(ctx, ticketID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	ticket.Status = models.TicketStatusProcessing
-	return s.tickets.Update(ctx, ticket)
+	if err := s.tickets.Update(ctx, ticket); err != nil {
+		return nil, err
+	}
+	return ticket, nil
+}
+
+// escalateApproval runs when the approval boundary timer fires: the open approval task is opened
+// to the escalation group and, when configured, reassigned to the escalation assignee.
+func (s *WorkflowService) escalateApproval(ctx context.Context, task workflow.ExternalTask) error {
+	ticketID, err := uuid.Parse(task.BusinessKey)
+	if err != nil {
+		return errors.Wrap(err, "invalid business key")
+	}
+	ticket, err := s.tickets.FindByID(ctx, ticketID)
+	if err != nil {
+		return err
+	}
+	tasks, err := s.camunda.ListTasks(ctx, workflow.TaskQuery{
+		ProcessInstanceID: task.ProcessID,
+		TaskDefinitionKey: ApprovalActivityID,
+	})
+	if err != nil {
+		return err
+	}
+	group := task.StringVariable("escalationGroup")
+	assignee := task.StringVariable("escalationAssignee")
+	for _, t := range tasks {
+		if group != "" {
+			if err := s.camunda.AddTaskCandidateGroup(ctx, t.ID, group); err != nil {
+				return err
+			}
+		}
+		if assignee != "" {
+			if err := s.camunda.SetTaskAssignee(ctx, t.ID, assignee); err != nil {
+				return err
+			}
+		}
+	}
+	if assignee != "" {
+		ticket.Assignee = assignee
+		if err := s.tickets.Update(ctx, ticket); err != nil {
+			return err
+		}
+	}
+	log.Printf("escalated approval of ticket %s to group=%q assignee=%q", ticket.ID, group, assignee)
+	return s.publishEvent(ctx, "ticket.escalated", ticket)
 }
@@ -0,0 +1,250 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/example/pflow/backend/internal/models"
+	"github.com/example/pflow/backend/internal/repository"
+	"github.com/example/pflow/backend/internal/service"
+	"github.com/example/pflow/backend/internal/workflow"
+	"github.com/example/pflow/backend/internal/workflow/camundatest"
+	"github.com/example/pflow/backend/internal/workflow/variables"
+)
+
+func TestSubmitTicketOnMemoryStore(t *testing.T) {
+	a, engine := newTestApp(t)
+	if _, ok := a.Tickets.(*repository.MemoryTicketStore); !ok {
+		t.Fatalf("tickets store is %T, want the memory store", a.Tickets)
+	}
+	ctx := context.Background()
+	ticket := &models.Ticket{Title: "new laptop", Requester: "alice"}
+	if err := a.Workflow.CreateTicket(ctx, ticket); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := a.Workflow.SubmitTicket(ctx, ticket.ID); err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+
+	got, err := a.Tickets.FindByID(ctx, ticket.ID)
+	if err != nil {
+		t.Fatalf("find: %v", err)
+	}
+	if got.Status != models.TicketStatusSubmitted {
+		t.Errorf("status = %s, want %s", got.Status, models.TicketStatusSubmitted)
+	}
+	instance, ok := engine.Instance(got.ProcessInstanceID)
+	if !ok {
+		t.Fatalf("process instance %q not started", got.ProcessInstanceID)
+	}
+	if instance.BusinessKey != ticket.ID.String() {
+		t.Errorf("business key = %q, want %q", instance.BusinessKey, ticket.ID)
+	}
+	if got.ProcessDefinitionVersion != 1 {
+		t.Errorf("definition version = %d, want 1", got.ProcessDefinitionVersion)
+	}
+
+	if err := a.Workflow.SubmitTicket(ctx, ticket.ID); err == nil {
+		t.Error("second submit succeeded, want an error")
+	}
+}
+
+func TestRecordDecisionCompletesApprovalTask(t *testing.T) {
+	tests := []struct {
+		name     string
+		approved bool
+		want     models.TicketStatus
+	}{
+		{"approve", true, models.TicketStatusProcessing},
+		{"reject", false, models.TicketStatusRejected},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, engine := newTestApp(t)
+			engine.OnStart(func(i camundatest.Instance) {
+				// An unrelated user task opened first must not be taken for the approval.
+				engine.AddUserTask(i.ID, camundatest.UserTask{Task: workflow.Task{TaskDefinitionKey: "UserTask_Other"}})
+				engine.AddUserTask(i.ID, camundatest.UserTask{Task: workflow.Task{TaskDefinitionKey: service.ApprovalActivityID}})
+			})
+			var completed []string
+			var decision variables.Map
+			engine.OnTaskComplete(func(task camundatest.UserTask, vars variables.Map) {
+				completed = append(completed, task.TaskDefinitionKey)
+				decision = vars
+				if vars.Get("approved").Bool() {
+					engine.AddExternalTask(task.ProcessInstanceID, camundatest.ExternalTask{ActivityID: "ServiceTask_ProcessTicket", TopicName: "ticket-processing"})
+				} else {
+					engine.ReachEndEvent(task.ProcessInstanceID, "EndEvent_Rejected")
+				}
+			})
+			ctx := context.Background()
+			ticket := &models.Ticket{Title: "new laptop", Requester: "alice"}
+			if err := a.Workflow.CreateTicket(ctx, ticket); err != nil {
+				t.Fatalf("create: %v", err)
+			}
+			if err := a.Workflow.SubmitTicket(ctx, ticket.ID); err != nil {
+				t.Fatalf("submit: %v", err)
+			}
+
+			if err := a.Workflow.RecordDecision(ctx, ticket.ID, "bob", tt.approved, "ok"); err != nil {
+				t.Fatalf("decision: %v", err)
+			}
+			if len(completed) != 1 || completed[0] != service.ApprovalActivityID {
+				t.Fatalf("completed tasks = %v, want only the approval task", completed)
+			}
+			if got := decision.Get("approved").Bool(); got != tt.approved {
+				t.Errorf("approved variable = %v, want %v", got, tt.approved)
+			}
+			if got := decision.Get("approver").String(); got != "bob" {
+				t.Errorf("approver variable = %q, want bob", got)
+			}
+			got, _ := a.Tickets.FindByID(ctx, ticket.ID)
+			if got.Status != models.TicketStatusSubmitted {
+				t.Errorf("status before sync = %s, want it left to the sync", got.Status)
+			}
+
+			if _, err := a.StatusSync.Sync(ctx); err != nil {
+				t.Fatalf("sync: %v", err)
+			}
+			got, _ = a.Tickets.FindByID(ctx, ticket.ID)
+			if got.Status != tt.want {
+				t.Errorf("status after sync = %s, want %s", got.Status, tt.want)
+			}
+			if err := a.Workflow.RecordDecision(ctx, ticket.ID, "bob", tt.approved, ""); err == nil {
+				t.Error("second decision succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestRecordDecisionWithoutApprovalTask(t *testing.T) {
+	a, _ := newTestApp(t)
+	ctx := context.Background()
+	ticket := &models.Ticket{Title: "new laptop", Requester: "alice"}
+	if err := a.Workflow.CreateTicket(ctx, ticket); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := a.Workflow.SubmitTicket(ctx, ticket.ID); err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	err := a.Workflow.RecordDecision(ctx, ticket.ID, "bob", true, "")
+	if !errors.Is(err, service.ErrNoOpenTask) {
+		t.Fatalf("err = %v, want ErrNoOpenTask", err)
+	}
+}
+
+// faultyStore fails ticket updates or the commit of transactions with the configured errors. A
+// failed commit rolls the wrapped transaction back, as a database would.
+type faultyStore struct {
+	repository.TicketStore
+	updateErr error
+	commitErr error
+}
+
+func (s *faultyStore) Update(ctx context.Context, ticket *models.Ticket) error {
+	if s.updateErr != nil {
+		return s.updateErr
+	}
+	return s.TicketStore.Update(ctx, ticket)
+}
+
+func (s *faultyStore) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return s.TicketStore.Transaction(ctx, func(ctx context.Context) error {
+		if err := fn(ctx); err != nil {
+			return err
+		}
+		return s.commitErr
+	})
+}
+
+func TestSubmitTicketFailures(t *testing.T) {
+	errDisk := errors.New("disk full")
+	tests := []struct {
+		name      string
+		updateErr error
+		commitErr error
+		faults    []camundatest.Fault
+		// started is whether a process instance is left after the failure.
+		started bool
+		// deleted is whether compensation deleted the instance again.
+		deleted bool
+	}{
+		{
+			name:   "start fails",
+			faults: []camundatest.Fault{{Method: "POST", Path: "/process-definition/", Status: 500, Type: "ProcessEngineException", Message: "boom"}},
+		},
+		{name: "update fails", updateErr: errDisk, started: true, deleted: true},
+		{name: "commit fails", commitErr: errDisk, started: true, deleted: true},
+		{
+			name:      "compensation fails",
+			updateErr: errDisk,
+			faults:    []camundatest.Fault{{Method: "DELETE", Path: "/process-instance/", Status: 500, Type: "ProcessEngineException", Message: "boom"}},
+			started:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, engine := newTestApp(t)
+			store := &faultyStore{TicketStore: a.Tickets}
+			svc := service.NewWorkflowService(store, a.Camunda, a.Definitions, a.Types, a.SLA, a.Comments, a.Routing, nil, a.Publisher)
+			ctx := context.Background()
+			ticket := &models.Ticket{Title: "new laptop", Requester: "alice"}
+			if err := svc.CreateTicket(ctx, ticket); err != nil {
+				t.Fatalf("create: %v", err)
+			}
+			store.updateErr, store.commitErr = tt.updateErr, tt.commitErr
+			for _, f := range tt.faults {
+				engine.Inject(f)
+			}
+
+			err := svc.SubmitTicket(ctx, ticket.ID)
+			if err == nil {
+				t.Fatal("submit succeeded, want an error")
+			}
+			if tt.updateErr != nil && !errors.Is(err, tt.updateErr) || tt.commitErr != nil && !errors.Is(err, tt.commitErr) {
+				t.Errorf("err = %v, want the store's error", err)
+			}
+			got, _ := a.Tickets.FindByID(ctx, ticket.ID)
+			if got.Status != models.TicketStatusDraft || got.ProcessInstanceID != "" {
+				t.Errorf("ticket = %s with instance %q, want it left in draft", got.Status, got.ProcessInstanceID)
+			}
+			instances := engine.Instances()
+			if !tt.started {
+				if len(instances) != 0 {
+					t.Fatalf("instances = %d, want none", len(instances))
+				}
+				if deletes := engine.RequestsTo("DELETE", "/process-instance/"); len(deletes) != 0 {
+					t.Errorf("compensation sent %d deletes, want none", len(deletes))
+				}
+				return
+			}
+			if len(instances) != 1 {
+				t.Fatalf("instances = %d, want 1", len(instances))
+			}
+			if deletes := engine.RequestsTo("DELETE", "/process-instance/"+instances[0].ID); len(deletes) != 1 {
+				t.Errorf("compensation sent %d deletes, want 1", len(deletes))
+			}
+			if ended := instances[0].Ended(); ended != tt.deleted {
+				t.Errorf("instance ended = %v, want %v", ended, tt.deleted)
+			}
+			if tt.deleted {
+				return
+			}
+
+			// The instance compensation failed to delete is adopted by the next submit.
+			engine.ClearFaults()
+			store.updateErr, store.commitErr = nil, nil
+			if err := svc.SubmitTicket(ctx, ticket.ID); err != nil {
+				t.Fatalf("second submit: %v", err)
+			}
+			got, _ = a.Tickets.FindByID(ctx, ticket.ID)
+			if got.ProcessInstanceID != instances[0].ID {
+				t.Errorf("instance = %q, want the leftover %q adopted", got.ProcessInstanceID, instances[0].ID)
+			}
+			if n := len(engine.Instances()); n != 1 {
+				t.Errorf("instances = %d, want 1", n)
+			}
+		})
+	}
+}
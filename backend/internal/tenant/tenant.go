@@ -0,0 +1,43 @@
+// Package tenant carries the tenant a request or job acts for through contexts. Repositories scope
+// their queries to that tenant; an empty tenant ID means the deployment is not multi-tenant or the
+// caller is a background job acting across all tenants.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// MaxIDLength bounds tenant IDs, matching the column Camunda stores them in.
+const MaxIDLength = 64
+
+// ErrInvalidID is returned for tenant IDs that cannot be used in Camunda or routing keys.
+var ErrInvalidID = errors.New("invalid tenant id")
+
+type ctxKey struct{}
+
+// WithID returns a context acting for the tenant.
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext returns the tenant the context acts for, or "" when there is none.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// Validate accepts IDs of ASCII letters, digits, '-' and '_'. Dots are excluded because tenant IDs
+// become a segment of MQ routing keys.
+func Validate(id string) error {
+	if id == "" || len(id) > MaxIDLength {
+		return fmt.Errorf("%w %q: must be 1 to %d characters", ErrInvalidID, id, MaxIDLength)
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return fmt.Errorf("%w %q: only letters, digits, '-' and '_' are allowed", ErrInvalidID, id)
+		}
+	}
+	return nil
+}
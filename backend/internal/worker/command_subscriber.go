@@ -0,0 +1,179 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rabbitmq/amqp091-go"
+
+	"github.com/example/pflow/backend/internal/mq"
+	"github.com/example/pflow/backend/internal/service"
+	"github.com/example/pflow/backend/internal/tenant"
+)
+
+// CommandRoutingPrefix is the routing key prefix of inbound ticket commands. The remainder of the
+// key names the BPMN message, e.g. "ticket.command.PaymentReceived".
+const CommandRoutingPrefix = "ticket.command."
+
+// CommandBindingKey matches every inbound ticket command on the ticket exchange.
+const CommandBindingKey = CommandRoutingPrefix + "*"
+
+// TenantCommandBindingKey matches the commands of every tenant, whose routing keys carry the tenant
+// ID in front, e.g. "acme.ticket.command.PaymentReceived".
+const TenantCommandBindingKey = "*." + CommandBindingKey
+
+// CommandMessage is the JSON body of a ticket command delivered over RabbitMQ.
+type CommandMessage struct {
+	TicketID    string         `json:"ticketId"`
+	MessageName string         `json:"messageName"`
+	Variables   map[string]any `json:"variables"`
+	All         bool           `json:"all"`
+}
+
+// CommandSubscriberConfig configures a CommandSubscriber.
+type CommandSubscriberConfig struct {
+	// Tenants lists the tenants of a multi-tenant deployment. When set, every command must resolve
+	// to one of them.
+	Tenants []string
+	// DefaultTenant applies to commands whose routing key carries no tenant.
+	DefaultTenant string
+	// MaxAttempts bounds how often a failing command is tried before it is dead-lettered; it
+	// defaults to 5.
+	MaxAttempts int
+	// Metrics receives the subscriber's counters; nil keeps them unexported.
+	Metrics *Metrics
+}
+
+// CommandSubscriber correlates inbound ticket commands to the matching process instances.
+type CommandSubscriber struct {
+	consumer      mq.Consumer
+	service       *service.WorkflowService
+	tenants       []string
+	defaultTenant string
+	maxAttempts   int
+	metrics       *Metrics
+}
+
+// NewCommandSubscriber creates a subscriber reading from the given consumer.
+func NewCommandSubscriber(cfg CommandSubscriberConfig, consumer mq.Consumer, svc *service.WorkflowService) *CommandSubscriber {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 5
+	}
+	if cfg.Metrics == nil {
+		cfg.Metrics = NewMetrics(nil)
+	}
+	return &CommandSubscriber{
+		consumer:      consumer,
+		service:       svc,
+		tenants:       cfg.Tenants,
+		defaultTenant: cfg.DefaultTenant,
+		maxAttempts:   cfg.MaxAttempts,
+		metrics:       cfg.Metrics,
+	}
+}
+
+// Run starts consuming and blocks until the context is cancelled.
+func (s *CommandSubscriber) Run(ctx context.Context) error {
+	// Handlers keep running after ctx ends so Close can let the command in progress finish.
+	handlerCtx := context.WithoutCancel(ctx)
+	if err := s.consumer.Consume(func(d amqp091.Delivery) { s.handle(handlerCtx, d) }); err != nil {
+		return err
+	}
+	<-ctx.Done()
+	log.Println("command subscriber shutting down")
+	return s.consumer.Close()
+}
+
+func (s *CommandSubscriber) handle(ctx context.Context, d amqp091.Delivery) {
+	routingKey := mq.OriginalRoutingKey(d)
+	var cmd CommandMessage
+	if err := json.Unmarshal(d.Body, &cmd); err != nil {
+		s.reject(ctx, d, fmt.Sprintf("malformed command: %v", err))
+		return
+	}
+	tenantID, key := "", routingKey
+	if i := strings.Index(key, "."+CommandRoutingPrefix); i > 0 {
+		tenantID, key = key[:i], key[i+1:]
+	}
+	tenantID, err := s.resolveTenant(tenantID)
+	if err != nil {
+		s.reject(ctx, d, err.Error())
+		return
+	}
+	if tenantID != "" {
+		ctx = tenant.WithID(ctx, tenantID)
+	}
+	if cmd.MessageName == "" {
+		cmd.MessageName = strings.TrimPrefix(key, CommandRoutingPrefix)
+	}
+	ticketID, err := uuid.Parse(cmd.TicketID)
+	if err != nil || cmd.MessageName == "" {
+		s.reject(ctx, d, "missing ticket id or message name")
+		return
+	}
+	if _, err := s.service.CorrelateMessage(ctx, ticketID, cmd.MessageName, cmd.Variables, cmd.All); err != nil {
+		log.Printf("correlate command %s for ticket %s failed: %v", cmd.MessageName, ticketID, err)
+		s.metrics.CommandsFailed.Inc()
+		s.retry(ctx, d, err)
+		return
+	}
+	s.metrics.CommandsHandled.Inc()
+	_ = d.Ack(false)
+}
+
+// resolveTenant maps the tenant named by the routing key, or the default for commands without one,
+// to the tenant the command acts for.
+func (s *CommandSubscriber) resolveTenant(id string) (string, error) {
+	if id == "" {
+		id = s.defaultTenant
+	}
+	if id == "" {
+		if len(s.tenants) > 0 {
+			return "", errors.New("command names no tenant")
+		}
+		return "", nil
+	}
+	if err := tenant.Validate(id); err != nil {
+		return "", err
+	}
+	if len(s.tenants) > 0 && !slices.Contains(s.tenants, id) {
+		return "", fmt.Errorf("unknown tenant %q", id)
+	}
+	return id, nil
+}
+
+// reject dead-letters a command that can never succeed.
+func (s *CommandSubscriber) reject(ctx context.Context, d amqp091.Delivery, reason string) {
+	log.Printf("dead-letter command %s: %s", mq.OriginalRoutingKey(d), reason)
+	s.metrics.CommandsRejected.Inc()
+	s.deadLetter(ctx, d, reason)
+}
+
+// retry hands a failed command back for another attempt after the retry delay, for instance
+// because the process has not reached the catch event yet, until MaxAttempts is used up.
+func (s *CommandSubscriber) retry(ctx context.Context, d amqp091.Delivery, cause error) {
+	if attempts := mq.Attempts(d) + 1; attempts >= s.maxAttempts {
+		log.Printf("dead-letter command %s after %d attempts", mq.OriginalRoutingKey(d), attempts)
+		s.deadLetter(ctx, d, cause.Error())
+		return
+	}
+	if err := s.consumer.Retry(ctx, d); err != nil {
+		log.Printf("retry command %s: %v", mq.OriginalRoutingKey(d), err)
+		_ = d.Nack(false, true)
+	}
+}
+
+func (s *CommandSubscriber) deadLetter(ctx context.Context, d amqp091.Delivery, reason string) {
+	if err := s.consumer.DeadLetter(ctx, d, reason); err != nil {
+		log.Printf("dead-letter command %s: %v", mq.OriginalRoutingKey(d), err)
+		_ = d.Nack(false, true)
+		return
+	}
+	s.metrics.CommandsDeadLettered.Inc()
+}
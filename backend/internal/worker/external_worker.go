@@ -2,39 +2,103 @@ package worker
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"os"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 
+	"github.com/example/pflow/backend/internal/lifecycle"
 	"github.com/example/pflow/backend/internal/service"
+	"github.com/example/pflow/backend/internal/tenant"
 	"github.com/example/pflow/backend/internal/workflow"
 )
 
-// ExternalWorker continuously polls Camunda for external tasks on a topic and delegates to the service.
+// Failed tasks are retried this many times, failureBackoff apart, before Camunda raises an incident.
+const (
+	defaultTaskRetries = 3
+	failureBackoff     = 30 * time.Second
+)
+
+// ExternalWorkerConfig configures an ExternalWorker.
+type ExternalWorkerConfig struct {
+	// ID identifies the worker to Camunda. It should stay the same across restarts of one replica
+	// and differ between replicas; a random ID is used when empty.
+	ID     string
+	Topics []string
+	// Tenants limits fetching to the tasks of those tenants; empty serves every tenant.
+	Tenants      []string
+	Interval     time.Duration
+	LockDuration time.Duration
+	// Metrics receives the worker's counters; nil keeps them unexported.
+	Metrics *Metrics
+}
+
+// ExternalWorker continuously polls Camunda for external tasks on its topics and delegates to the service.
 type ExternalWorker struct {
 	id       string
-	topic    string
+	topics   []string
+	tenants  []string
 	service  *service.WorkflowService
 	camunda  *workflow.CamundaClient
 	interval time.Duration
 	lock     time.Duration
+	metrics  *Metrics
+
+	stopping chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+
+	mu          sync.Mutex
+	abort       context.CancelFunc
+	lastPoll    time.Time
+	lastSuccess time.Time
+	lastErr     error
 }
 
-// NewExternalWorker creates the worker with random identifier.
-func NewExternalWorker(topic string, svc *service.WorkflowService, camunda *workflow.CamundaClient, interval, lock time.Duration) *ExternalWorker {
+// NewExternalWorker creates the worker from the configuration.
+func NewExternalWorker(cfg ExternalWorkerConfig, svc *service.WorkflowService, camunda *workflow.CamundaClient) *ExternalWorker {
+	if cfg.ID == "" {
+		cfg.ID = uuid.New().String()
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 5 * time.Second
+	}
+	if cfg.Metrics == nil {
+		cfg.Metrics = NewMetrics(nil)
+	}
 	return &ExternalWorker{
-		id:       uuid.New().String(),
-		topic:    topic,
+		id:       cfg.ID,
+		topics:   cfg.Topics,
+		tenants:  cfg.Tenants,
 		service:  svc,
 		camunda:  camunda,
-		interval: interval,
-		lock:     lock,
+		interval: cfg.Interval,
+		lock:     cfg.LockDuration,
+		metrics:  cfg.Metrics,
+		stopping: make(chan struct{}),
+		done:     make(chan struct{}),
 	}
 }
 
-// Run starts the polling loop and should be launched in its own goroutine.
+// ID returns the worker ID sent to Camunda.
+func (w *ExternalWorker) ID() string {
+	return w.id
+}
+
+// Run starts the polling loop and should be launched in its own goroutine. It returns when ctx is
+// cancelled, which aborts the task in progress, or after Shutdown.
 func (w *ExternalWorker) Run(ctx context.Context) {
+	defer close(w.done)
+	ctx, abort := context.WithCancel(ctx)
+	defer abort()
+	w.mu.Lock()
+	w.abort = abort
+	w.mu.Unlock()
+
+	w.releaseLocks(ctx)
 	ticker := time.NewTicker(w.interval)
 	defer ticker.Stop()
 
@@ -43,25 +107,243 @@ func (w *ExternalWorker) Run(ctx context.Context) {
 		case <-ctx.Done():
 			log.Println("external worker shutting down")
 			return
+		case <-w.stopping:
+			log.Println("external worker stopped polling")
+			return
 		case <-ticker.C:
 			w.poll(ctx)
 		}
 	}
 }
 
+// abortGrace is held back from the shutdown deadline to abort the running task and unlock it, so
+// the unlock finishes before the lifecycle manager moves on to close Camunda's client and the DB.
+const abortGrace = 5 * time.Second
+
+// Shutdown stops polling and waits for the task in progress to finish; fetched tasks that were
+// not started yet are unlocked. When the task is still running shortly before ctx's deadline it
+// is aborted and unlocked too, so another worker can fetch it without waiting for the lock to
+// expire.
+func (w *ExternalWorker) Shutdown(ctx context.Context) error {
+	w.stopOnce.Do(func() { close(w.stopping) })
+	drain, cancel := context.WithCancel(ctx)
+	if deadline, ok := ctx.Deadline(); ok {
+		grace := min(abortGrace, time.Until(deadline)/2)
+		drain, cancel = context.WithDeadline(ctx, deadline.Add(-grace))
+	}
+	defer cancel()
+	select {
+	case <-w.done:
+		return nil
+	case <-drain.Done():
+	}
+	w.mu.Lock()
+	abort := w.abort
+	w.mu.Unlock()
+	if abort != nil {
+		abort()
+	}
+	// The aborted handler returns promptly; wait for its task to be unlocked within the grace left.
+	select {
+	case <-w.done:
+		return fmt.Errorf("in-flight task aborted: %w", drain.Err())
+	case <-ctx.Done():
+		return fmt.Errorf("in-flight task aborted but not unlocked: %w", ctx.Err())
+	}
+}
+
+func (w *ExternalWorker) stopRequested() bool {
+	select {
+	case <-w.stopping:
+		return true
+	default:
+		return false
+	}
+}
+
+// unlock hands a task back to Camunda; it runs during shutdown, so it ignores ctx's cancellation.
+func (w *ExternalWorker) unlock(ctx context.Context, task workflow.ExternalTask) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), abortGrace)
+	defer cancel()
+	if err := w.camunda.UnlockExternalTask(ctx, task.ID); err != nil {
+		log.Printf("unlock external task %s failed: %v", task.ID, err)
+		return
+	}
+	log.Printf("unlocked external task %s on shutdown", task.ID)
+}
+
+// releaseLocks hands back the tasks a previous run with the same ID still holds, so they are
+// fetched again now instead of after their lock expires.
+func (w *ExternalWorker) releaseLocks(ctx context.Context) {
+	tasks, err := w.camunda.LockedExternalTasks(ctx, w.id)
+	if err != nil {
+		log.Printf("look up tasks locked by worker %s failed: %v", w.id, err)
+		return
+	}
+	for _, task := range tasks {
+		if err := w.camunda.UnlockExternalTask(ctx, task.ID); err != nil {
+			log.Printf("release lock of external task %s failed: %v", task.ID, err)
+			continue
+		}
+		w.metrics.LocksReleased.Inc()
+		log.Printf("released external task %s locked by a previous run of worker %s", task.ID, w.id)
+	}
+}
+
+// Health describes the state of the polling loop.
+type Health struct {
+	WorkerID    string     `json:"workerId"`
+	Topics      []string   `json:"topics"`
+	Tenants     []string   `json:"tenants,omitempty"`
+	LastPoll    *time.Time `json:"lastPoll,omitempty"`
+	LastSuccess *time.Time `json:"lastSuccess,omitempty"`
+	LastError   string     `json:"lastError,omitempty"`
+	// Ready is true while fetches succeed: the last one succeeded no longer ago than staleAfter.
+	Ready bool `json:"ready"`
+}
+
+// Health reports whether the worker reached Camunda recently.
+func (w *ExternalWorker) Health(now time.Time) Health {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	h := Health{WorkerID: w.id, Topics: w.topics, Tenants: w.tenants}
+	if !w.lastPoll.IsZero() {
+		last := w.lastPoll
+		h.LastPoll = &last
+	}
+	if !w.lastSuccess.IsZero() {
+		last := w.lastSuccess
+		h.LastSuccess = &last
+		h.Ready = now.Sub(last) <= w.staleAfter()
+	}
+	if w.lastErr != nil {
+		h.LastError = w.lastErr.Error()
+	}
+	return h
+}
+
+// staleAfter leaves room for a few missed polls, each of which may spend its time on retries.
+func (w *ExternalWorker) staleAfter() time.Duration {
+	return max(5*w.interval, time.Minute)
+}
+
+func (w *ExternalWorker) recordPoll(err error) {
+	now := time.Now()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.lastPoll = now
+	w.lastErr = err
+	w.metrics.Polls.Inc()
+	if err != nil {
+		w.metrics.PollErrors.Inc()
+		return
+	}
+	w.lastSuccess = now
+	w.metrics.LastPollSuccess.Set(float64(now.Unix()))
+}
+
 func (w *ExternalWorker) poll(ctx context.Context) {
-	tasks, err := w.camunda.FetchAndLockExternalTasks(ctx, w.id, w.topic, w.lock)
+	tasks, err := w.camunda.FetchAndLockExternalTasks(ctx, w.id, w.topics, w.tenants, w.lock)
+	w.recordPoll(err)
 	if err != nil {
 		log.Printf("fetch external tasks error: %v", err)
 		return
 	}
+	w.metrics.TasksFetched.Add(uint64(len(tasks)))
 	for _, task := range tasks {
-		if err := w.service.HandleExternalTask(ctx, task); err != nil {
+		if w.stopRequested() || ctx.Err() != nil {
+			w.unlock(ctx, task)
+			continue
+		}
+		ctx := ctx
+		if task.TenantID != "" {
+			ctx = tenant.WithID(ctx, task.TenantID)
+		}
+		stop := w.keepLocked(ctx, task)
+		vars, err := w.service.HandleExternalTask(ctx, task)
+		stop()
+		if err != nil && ctx.Err() != nil {
+			log.Printf("external task %s aborted: %v", task.ID, err)
+			w.unlock(ctx, task)
+			continue
+		}
+		if err != nil {
 			log.Printf("handle external task %s failed: %v", task.ID, err)
+			w.metrics.TasksFailed.Inc()
+			w.reportFailure(ctx, task, err)
 			continue
 		}
-		if err := w.camunda.CompleteExternalTask(ctx, w.id, task.ID, map[string]any{"handledAt": time.Now().UTC().Format(time.RFC3339)}); err != nil {
+		if vars == nil {
+			vars = map[string]any{}
+		}
+		vars["handledAt"] = time.Now().UTC()
+		// The work is done, so complete the task even when shutdown aborts the worker meanwhile.
+		if err := w.camunda.CompleteExternalTask(context.WithoutCancel(ctx), w.id, task.ID, vars); err != nil {
 			log.Printf("complete external task %s failed: %v", task.ID, err)
+			continue
 		}
+		w.metrics.TasksCompleted.Inc()
+	}
+}
+
+// keepLocked extends the task's lock at half its duration until stop is called, so handlers such
+// as provisioning actions may run longer than one lock.
+func (w *ExternalWorker) keepLocked(ctx context.Context, task workflow.ExternalTask) (stop func()) {
+	if w.lock <= 0 {
+		return func() {}
+	}
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(w.lock / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := w.camunda.ExtendExternalTaskLock(ctx, w.id, task.ID, w.lock); err != nil {
+					log.Printf("extend lock of external task %s failed: %v", task.ID, err)
+				}
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+func (w *ExternalWorker) reportFailure(ctx context.Context, task workflow.ExternalTask, cause error) {
+	retries := defaultTaskRetries
+	if task.Retries != nil {
+		retries = *task.Retries - 1
+	}
+	if retries < 0 {
+		retries = 0
+	}
+	if err := w.camunda.HandleExternalTaskFailure(ctx, w.id, task.ID, cause.Error(), retries, failureBackoff); err != nil {
+		log.Printf("report failure of external task %s failed: %v", task.ID, err)
+	}
+}
+
+// DefaultID derives a worker ID from the host name, which stays the same across restarts of a
+// container or StatefulSet pod. The component keeps an embedded and a standalone worker on one host apart.
+func DefaultID(component string) string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		return component + "-" + uuid.New().String()
+	}
+	return component + "@" + host
+}
+
+// Component runs the worker under a lifecycle manager; stopping it calls Shutdown.
+func (w *ExternalWorker) Component(stopTimeout time.Duration) lifecycle.Component {
+	return lifecycle.Component{
+		Name: "external worker",
+		Start: func(ctx context.Context) error {
+			go w.Run(context.WithoutCancel(ctx))
+			return nil
+		},
+		Stop:        w.Shutdown,
+		StopTimeout: stopTimeout,
 	}
 }
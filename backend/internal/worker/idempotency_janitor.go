@@ -0,0 +1,40 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/example/pflow/backend/internal/service"
+)
+
+// IdempotencyJanitor periodically removes expired idempotency keys.
+type IdempotencyJanitor struct {
+	service  *service.IdempotencyService
+	interval time.Duration
+}
+
+// NewIdempotencyJanitor creates a janitor purging every interval.
+func NewIdempotencyJanitor(svc *service.IdempotencyService, interval time.Duration) *IdempotencyJanitor {
+	return &IdempotencyJanitor{service: svc, interval: interval}
+}
+
+// Run starts the purge loop and should be launched in its own goroutine.
+func (j *IdempotencyJanitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("idempotency janitor shutting down")
+			return
+		case now := <-ticker.C:
+			if n, err := j.service.Purge(ctx, now); err != nil {
+				log.Printf("purge idempotency keys failed: %v", err)
+			} else if n > 0 {
+				log.Printf("purged %d expired idempotency keys", n)
+			}
+		}
+	}
+}
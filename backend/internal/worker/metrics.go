@@ -0,0 +1,39 @@
+package worker
+
+import "github.com/example/pflow/backend/internal/metrics"
+
+// Metrics counts what the external worker and the command subscriber do.
+type Metrics struct {
+	Polls            *metrics.Counter
+	PollErrors       *metrics.Counter
+	TasksFetched     *metrics.Counter
+	TasksCompleted   *metrics.Counter
+	TasksFailed      *metrics.Counter
+	LocksReleased    *metrics.Counter
+	LastPollSuccess  *metrics.Gauge
+	CommandsHandled  *metrics.Counter
+	CommandsRejected *metrics.Counter
+	CommandsFailed   *metrics.Counter
+	// CommandsDeadLettered counts commands parked on the dead-letter queue.
+	CommandsDeadLettered *metrics.Counter
+}
+
+// NewMetrics registers the worker metrics. A nil registry yields metrics that are not exported.
+func NewMetrics(reg *metrics.Registry) *Metrics {
+	if reg == nil {
+		reg = metrics.NewRegistry()
+	}
+	return &Metrics{
+		Polls:                reg.Counter("pflow_worker_polls_total", "Fetch-and-lock requests sent to Camunda."),
+		PollErrors:           reg.Counter("pflow_worker_poll_errors_total", "Fetch-and-lock requests that failed."),
+		TasksFetched:         reg.Counter("pflow_worker_tasks_fetched_total", "External tasks locked by the worker."),
+		TasksCompleted:       reg.Counter("pflow_worker_tasks_completed_total", "External tasks handled and completed."),
+		TasksFailed:          reg.Counter("pflow_worker_tasks_failed_total", "External tasks whose handling failed."),
+		LocksReleased:        reg.Counter("pflow_worker_locks_released_total", "Locks of a previous run released at startup."),
+		LastPollSuccess:      reg.Gauge("pflow_worker_last_poll_success_timestamp_seconds", "Unix time of the last successful fetch-and-lock."),
+		CommandsHandled:      reg.Counter("pflow_commands_handled_total", "Ticket commands correlated to a process instance."),
+		CommandsRejected:     reg.Counter("pflow_commands_rejected_total", "Malformed ticket commands dead-lettered without retry."),
+		CommandsFailed:       reg.Counter("pflow_commands_failed_total", "Ticket commands whose correlation failed."),
+		CommandsDeadLettered: reg.Counter("pflow_commands_dead_lettered_total", "Ticket commands moved to the dead-letter queue."),
+	}
+}
@@ -0,0 +1,38 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/example/pflow/backend/internal/service"
+)
+
+// SLAScheduler periodically checks active tickets against their SLA deadlines.
+type SLAScheduler struct {
+	service  *service.SLAService
+	interval time.Duration
+}
+
+// NewSLAScheduler creates a scheduler running a check every interval.
+func NewSLAScheduler(svc *service.SLAService, interval time.Duration) *SLAScheduler {
+	return &SLAScheduler{service: svc, interval: interval}
+}
+
+// Run starts the check loop and should be launched in its own goroutine.
+func (s *SLAScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("sla scheduler shutting down")
+			return
+		case now := <-ticker.C:
+			if err := s.service.Check(ctx, now); err != nil {
+				log.Printf("sla check failed: %v", err)
+			}
+		}
+	}
+}
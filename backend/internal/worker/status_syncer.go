@@ -0,0 +1,43 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/example/pflow/backend/internal/service"
+)
+
+// StatusSyncer periodically applies Camunda activity history to ticket status and records the
+// outcome of pending migration batches.
+type StatusSyncer struct {
+	service    *service.StatusSyncService
+	migrations *service.MigrationService
+	interval   time.Duration
+}
+
+// NewStatusSyncer creates a syncer running every interval.
+func NewStatusSyncer(svc *service.StatusSyncService, migrations *service.MigrationService, interval time.Duration) *StatusSyncer {
+	return &StatusSyncer{service: svc, migrations: migrations, interval: interval}
+}
+
+// Run starts the sync loop and should be launched in its own goroutine.
+func (s *StatusSyncer) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("status syncer shutting down")
+			return
+		case <-ticker.C:
+			if _, err := s.service.Sync(ctx); err != nil {
+				log.Printf("status sync failed: %v", err)
+			}
+			if _, err := s.migrations.SettleMigrations(ctx); err != nil {
+				log.Printf("settle migrations failed: %v", err)
+			}
+		}
+	}
+}
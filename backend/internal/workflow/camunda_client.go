@@ -7,114 +7,262 @@ import (
 	"fmt"
 	"mime/multipart"
 	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/example/pflow/backend/internal/workflow/variables"
 )
 
+// ClientConfig configures how the client reaches and authenticates against the engine.
+type ClientConfig struct {
+	// BaseURL is the REST root, e.g. http://camunda:8080/engine-rest.
+	BaseURL string
+	// Username and Password enable HTTP Basic authentication.
+	Username string
+	Password string
+	// BearerToken, when set, is sent instead of Basic credentials.
+	BearerToken string
+	// Timeout bounds each attempt; it defaults to 15s.
+	Timeout time.Duration
+	// MaxRetries is how often idempotent calls are retried after connection errors and 5xx
+	// responses; zero disables retries.
+	MaxRetries int
+	// RetryBackoff is the initial delay between retries, doubled per attempt up to RetryMaxBackoff
+	// and jittered. They default to 200ms and 5s.
+	RetryBackoff    time.Duration
+	RetryMaxBackoff time.Duration
+	// BreakerThreshold consecutive engine failures open the circuit breaker for BreakerCooldown
+	// (default 30s); zero disables the breaker.
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
 // CamundaClient exposes a small subset of Camunda REST operations used by the application.
 type CamundaClient struct {
+	cfg     ClientConfig
 	baseURL string
 	client  *http.Client
+	breaker *breaker
 }
 
-// NewCamundaClient constructs a client targeting the provided base URL.
-func NewCamundaClient(baseURL string) *CamundaClient {
+// NewCamundaClient constructs a client from the configuration, filling in defaults.
+func NewCamundaClient(cfg ClientConfig) *CamundaClient {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 15 * time.Second
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = 200 * time.Millisecond
+	}
+	if cfg.RetryMaxBackoff < cfg.RetryBackoff {
+		cfg.RetryMaxBackoff = max(5*time.Second, cfg.RetryBackoff)
+	}
+	if cfg.BreakerCooldown <= 0 {
+		cfg.BreakerCooldown = 30 * time.Second
+	}
 	return &CamundaClient{
-		baseURL: baseURL,
-		client:  &http.Client{Timeout: 15 * time.Second},
+		cfg:     cfg,
+		baseURL: strings.TrimSuffix(cfg.BaseURL, "/"),
+		client:  &http.Client{Timeout: cfg.Timeout},
+		breaker: newBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown),
+	}
+}
+
+// DeployOptions controls how Camunda treats a new deployment.
+type DeployOptions struct {
+	// Name is the deployment name; it defaults to the resource name.
+	Name string
+	// Source tags the deployment origin, e.g. "pflow-startup" or "pflow-admin".
+	Source string
+	// EnableDuplicateFiltering skips the deployment when the resources are unchanged.
+	EnableDuplicateFiltering bool
+	// DeployChangedOnly redeploys only the resources that changed since the last deployment.
+	DeployChangedOnly bool
+	// TenantID deploys the definitions for one tenant; they are shared by all tenants when empty.
+	TenantID string
+}
+
+// Deployment mirrors the Camunda deployment response.
+type Deployment struct {
+	ID                         string                       `json:"id"`
+	Name                       string                       `json:"name"`
+	Source                     string                       `json:"source"`
+	DeploymentTime             string                       `json:"deploymentTime"`
+	TenantID                   string                       `json:"tenantId,omitempty"`
+	DeployedProcessDefinitions map[string]ProcessDefinition `json:"deployedProcessDefinitions"`
+}
+
+// ProcessDefinitionIDs returns the identifiers of the process definitions created by the deployment.
+func (d *Deployment) ProcessDefinitionIDs() []string {
+	ids := make([]string, 0, len(d.DeployedProcessDefinitions))
+	for id := range d.DeployedProcessDefinitions {
+		ids = append(ids, id)
 	}
+	sort.Strings(ids)
+	return ids
+}
+
+// ProcessDefinition mirrors a Camunda process definition.
+type ProcessDefinition struct {
+	ID           string `json:"id"`
+	Key          string `json:"key"`
+	Name         string `json:"name"`
+	Version      int    `json:"version"`
+	VersionTag   string `json:"versionTag"`
+	DeploymentID string `json:"deploymentId"`
+	Resource     string `json:"resource"`
+	Suspended    bool   `json:"suspended"`
+	TenantID     string `json:"tenantId,omitempty"`
 }
 
-// DeployProcess deploys a BPMN definition to Camunda.
-func (c *CamundaClient) DeployProcess(ctx context.Context, name string, bpmn []byte) error {
+// DeployProcess deploys a BPMN definition to Camunda and returns the resulting deployment.
+func (c *CamundaClient) DeployProcess(ctx context.Context, name string, bpmn []byte, opts DeployOptions) (*Deployment, error) {
+	if opts.Name == "" {
+		opts.Name = name
+	}
 	body := &bytes.Buffer{}
 	writer := multipart.NewWriter(body)
+	fields := map[string]string{
+		"deployment-name":            opts.Name,
+		"enable-duplicate-filtering": strconv.FormatBool(opts.EnableDuplicateFiltering),
+		"deploy-changed-only":        strconv.FormatBool(opts.DeployChangedOnly),
+	}
+	if opts.Source != "" {
+		fields["deployment-source"] = opts.Source
+	}
+	if opts.TenantID != "" {
+		fields["tenant-id"] = opts.TenantID
+	}
+	for k, v := range fields {
+		if err := writer.WriteField(k, v); err != nil {
+			return nil, err
+		}
+	}
 	part, err := writer.CreateFormFile("data", fmt.Sprintf("%s.bpmn", name))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if _, err := part.Write(bpmn); err != nil {
-		return err
+		return nil, err
 	}
 	if err := writer.Close(); err != nil {
-		return err
+		return nil, err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/deployment/create", c.baseURL), body)
+	var deployment Deployment
+	err = c.do(ctx, request{
+		method:      http.MethodPost,
+		path:        "/deployment/create",
+		contentType: writer.FormDataContentType(),
+		body:        body.Bytes(),
+	}, &deployment)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return &deployment, nil
+}
 
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return err
+// ListProcessDefinitions returns all versions of the process definitions with the given key, newest
+// first. A tenant ID limits the result to that tenant's definitions.
+func (c *CamundaClient) ListProcessDefinitions(ctx context.Context, key, tenantID string) ([]ProcessDefinition, error) {
+	query := url.Values{"sortBy": {"version"}, "sortOrder": {"desc"}}
+	if key != "" {
+		query.Set("key", key)
+	}
+	if tenantID != "" {
+		query.Set("tenantIdIn", tenantID)
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode >= 300 {
-		return fmt.Errorf("camunda deploy failed: %s", resp.Status)
+	var defs []ProcessDefinition
+	if err := c.doJSON(ctx, http.MethodGet, "/process-definition?"+query.Encode(), nil, &defs); err != nil {
+		return nil, err
 	}
-	return nil
+	return defs, nil
 }
 
-// StartProcessInstance starts a process by key and business key.
-func (c *CamundaClient) StartProcessInstance(ctx context.Context, key, businessKey string, variables map[string]any) (string, error) {
-	payload := map[string]any{
-		"variables":   wrapVariables(variables),
-		"businessKey": businessKey,
-	}
-	body, _ := json.Marshal(payload)
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/process-definition/key/%s/start", c.baseURL, key), bytes.NewReader(body))
-	if err != nil {
-		return "", err
+// GetProcessDefinition loads a single process definition by id.
+func (c *CamundaClient) GetProcessDefinition(ctx context.Context, id string) (*ProcessDefinition, error) {
+	var def ProcessDefinition
+	if err := c.doJSON(ctx, http.MethodGet, "/process-definition/"+url.PathEscape(id), nil, &def); err != nil {
+		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/json")
+	return &def, nil
+}
 
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode >= 300 {
-		return "", fmt.Errorf("failed to start process: %s", resp.Status)
-	}
+// ProcessInstance mirrors the Camunda process instance representation.
+type ProcessInstance struct {
+	ID           string `json:"id"`
+	DefinitionID string `json:"definitionId"`
+	BusinessKey  string `json:"businessKey"`
+	Ended        bool   `json:"ended"`
+	Suspended    bool   `json:"suspended"`
+	TenantID     string `json:"tenantId,omitempty"`
+}
 
-	var result struct {
-		ID string `json:"id"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", err
+// StartProcessInstance starts the latest version of a process by key and business key.
+func (c *CamundaClient) StartProcessInstance(ctx context.Context, key, businessKey string, variables map[string]any) (*ProcessInstance, error) {
+	return c.startProcessInstance(ctx, fmt.Sprintf("/process-definition/key/%s/start", url.PathEscape(key)), businessKey, variables)
+}
+
+// StartProcessInstanceForTenant starts the latest version of the tenant's process by key. An empty
+// tenant ID starts the shared definition, like StartProcessInstance.
+func (c *CamundaClient) StartProcessInstanceForTenant(ctx context.Context, key, tenantID, businessKey string, variables map[string]any) (*ProcessInstance, error) {
+	if tenantID == "" {
+		return c.StartProcessInstance(ctx, key, businessKey, variables)
 	}
-	return result.ID, nil
+	path := fmt.Sprintf("/process-definition/key/%s/tenant-id/%s/start", url.PathEscape(key), url.PathEscape(tenantID))
+	return c.startProcessInstance(ctx, path, businessKey, variables)
 }
 
-// FetchAndLockExternalTasks pulls external tasks for the given topic.
-func (c *CamundaClient) FetchAndLockExternalTasks(ctx context.Context, workerID, topic string, lockDuration time.Duration) ([]ExternalTask, error) {
+// StartProcessInstanceByDefinitionID starts a specific process definition version.
+func (c *CamundaClient) StartProcessInstanceByDefinitionID(ctx context.Context, definitionID, businessKey string, variables map[string]any) (*ProcessInstance, error) {
+	return c.startProcessInstance(ctx, fmt.Sprintf("/process-definition/%s/start", url.PathEscape(definitionID)), businessKey, variables)
+}
+
+func (c *CamundaClient) startProcessInstance(ctx context.Context, path, businessKey string, variables map[string]any) (*ProcessInstance, error) {
 	payload := map[string]any{
-		"workerId":    workerID,
-		"maxTasks":    5,
-		"usePriority": true,
-		"topics": []map[string]any{{
-			"topicName":    topic,
-			"lockDuration": int(lockDuration.Milliseconds()),
-		}},
+		"variables":   typedVariables(variables),
+		"businessKey": businessKey,
 	}
-	body, _ := json.Marshal(payload)
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/external-task/fetchAndLock", c.baseURL), bytes.NewReader(body))
-	if err != nil {
+	var result ProcessInstance
+	if err := c.doJSON(ctx, http.MethodPost, path, payload, &result); err != nil {
 		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/json")
+	return &result, nil
+}
 
-	resp, err := c.client.Do(req)
-	if err != nil {
+// ListProcessInstancesByBusinessKey returns the running process instances with the business key.
+func (c *CamundaClient) ListProcessInstancesByBusinessKey(ctx context.Context, businessKey string) ([]ProcessInstance, error) {
+	var instances []ProcessInstance
+	if err := c.doJSON(ctx, http.MethodGet, "/process-instance?businessKey="+url.QueryEscape(businessKey), nil, &instances); err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("fetchAndLock failed: %s", resp.Status)
+	return instances, nil
+}
+
+// FetchAndLockExternalTasks pulls external tasks for the given topics. When tenant IDs are given
+// only tasks of those tenants are fetched.
+func (c *CamundaClient) FetchAndLockExternalTasks(ctx context.Context, workerID string, topics, tenantIDs []string, lockDuration time.Duration) ([]ExternalTask, error) {
+	topicPayload := make([]map[string]any, 0, len(topics))
+	for _, topic := range topics {
+		t := map[string]any{
+			"topicName":    topic,
+			"lockDuration": int(lockDuration.Milliseconds()),
+		}
+		if len(tenantIDs) > 0 {
+			t["tenantIdIn"] = tenantIDs
+		}
+		topicPayload = append(topicPayload, t)
+	}
+	payload := map[string]any{
+		"workerId":    workerID,
+		"maxTasks":    5,
+		"usePriority": true,
+		"topics":      topicPayload,
 	}
 	var tasks []ExternalTask
-	if err := json.NewDecoder(resp.Body).Decode(&tasks); err != nil {
+	if err := c.doJSON(ctx, http.MethodPost, "/external-task/fetchAndLock", payload, &tasks); err != nil {
 		return nil, err
 	}
 	return tasks, nil
@@ -124,48 +272,78 @@ func (c *CamundaClient) FetchAndLockExternalTasks(ctx context.Context, workerID,
 func (c *CamundaClient) CompleteExternalTask(ctx context.Context, workerID, taskID string, variables map[string]any) error {
 	payload := map[string]any{
 		"workerId":  workerID,
-		"variables": wrapVariables(variables),
-	}
-	body, _ := json.Marshal(payload)
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/external-task/%s/complete", c.baseURL, taskID), bytes.NewReader(body))
-	if err != nil {
-		return err
+		"variables": typedVariables(variables),
 	}
-	req.Header.Set("Content-Type", "application/json")
+	return c.doJSON(ctx, http.MethodPost, "/external-task/"+url.PathEscape(taskID)+"/complete", payload, nil)
+}
 
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode >= 300 {
-		return fmt.Errorf("complete failed: %s", resp.Status)
+// HandleExternalTaskFailure reports that the worker could not finish a task. Camunda retries it
+// after retryTimeout while retries remain and raises an incident once they reach zero.
+func (c *CamundaClient) HandleExternalTaskFailure(ctx context.Context, workerID, taskID, message string, retries int, retryTimeout time.Duration) error {
+	return c.doJSON(ctx, http.MethodPost, "/external-task/"+url.PathEscape(taskID)+"/failure", map[string]any{
+		"workerId":     workerID,
+		"errorMessage": message,
+		"retries":      retries,
+		"retryTimeout": retryTimeout.Milliseconds(),
+	}, nil)
+}
+
+// ExtendExternalTaskLock keeps a long-running task locked for the worker for another duration.
+func (c *CamundaClient) ExtendExternalTaskLock(ctx context.Context, workerID, taskID string, duration time.Duration) error {
+	return c.doJSON(ctx, http.MethodPost, "/external-task/"+url.PathEscape(taskID)+"/extendLock", map[string]any{
+		"workerId":    workerID,
+		"newDuration": duration.Milliseconds(),
+	}, nil)
+}
+
+// LockedExternalTasks returns the external tasks currently locked by the worker.
+func (c *CamundaClient) LockedExternalTasks(ctx context.Context, workerID string) ([]ExternalTask, error) {
+	query := url.Values{"workerId": {workerID}, "locked": {"true"}}
+	var tasks []ExternalTask
+	if err := c.doJSON(ctx, http.MethodGet, "/external-task?"+query.Encode(), nil, &tasks); err != nil {
+		return nil, err
 	}
-	return nil
+	return tasks, nil
+}
+
+// UnlockExternalTask releases the lock on a task so any worker can fetch it again right away.
+func (c *CamundaClient) UnlockExternalTask(ctx context.Context, taskID string) error {
+	return c.doJSON(ctx, http.MethodPost, "/external-task/"+url.PathEscape(taskID)+"/unlock", map[string]any{}, nil)
 }
 
 // ExternalTask mirrors the Camunda response.
 type ExternalTask struct {
-	ID           string `json:"id"`
-	ProcessID    string `json:"processInstanceId"`
-	ActivityID   string `json:"activityId"`
-	TopicName    string `json:"topicName"`
-	BusinessKey  string `json:"businessKey"`
-	VariablesRaw map[string]struct {
-		Type  string      `json:"type"`
-		Value interface{} `json:"value"`
-	} `json:"variables"`
+	ID          string `json:"id"`
+	ProcessID   string `json:"processInstanceId"`
+	ActivityID  string `json:"activityId"`
+	TopicName   string `json:"topicName"`
+	BusinessKey string `json:"businessKey"`
+	TenantID    string `json:"tenantId"`
+	// Retries is nil until the first failure is reported.
+	Retries   *int          `json:"retries"`
+	Variables variables.Map `json:"variables"`
 }
 
-func wrapVariables(vars map[string]any) map[string]any {
-	if vars == nil {
-		return map[string]any{}
+// Var returns the named process variable; a missing variable reads as null.
+func (t ExternalTask) Var(name string) variables.Value {
+	return t.Variables.Get(name)
+}
+
+// StringVariable returns the named process variable as a string, or "" when it is absent or not a string.
+func (t ExternalTask) StringVariable(name string) string {
+	if s, ok := t.Var(name).Value.(string); ok {
+		return s
 	}
-	out := make(map[string]any, len(vars))
-	for k, v := range vars {
-		out[k] = map[string]any{
-			"value": v,
-		}
+	return ""
+}
+
+// typedVariables sends Go values as typed Camunda variables; see variables.Of for the mapping.
+type typedVariables map[string]any
+
+func (t typedVariables) MarshalJSON() ([]byte, error) {
+	vars, err := variables.FromMap(t)
+	if err != nil {
+		return nil, err
 	}
-	return out
+	return json.Marshal(vars)
 }
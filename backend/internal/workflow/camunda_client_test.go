@@ -0,0 +1,321 @@
+package workflow_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/example/pflow/backend/internal/workflow"
+	"github.com/example/pflow/backend/internal/workflow/camundatest"
+	"github.com/example/pflow/backend/internal/workflow/variables"
+)
+
+const bpmn = `<?xml version="1.0" encoding="UTF-8"?>
+<bpmn:definitions xmlns:bpmn="http://www.omg.org/spec/BPMN/20100524/MODEL" id="Definitions_1">
+  <bpmn:process id="ticket-process" name="Ticket" isExecutable="true">
+    <bpmn:startEvent id="StartEvent_1" />
+    <bpmn:serviceTask id="ServiceTask_ProcessTicket" />
+    <bpmn:endEvent id="EndEvent_Completed" />
+  </bpmn:process>
+</bpmn:definitions>`
+
+// newClient starts a fake engine and a client against it with fast retries.
+func newClient(t *testing.T, cfg workflow.ClientConfig) (*workflow.CamundaClient, *camundatest.Server) {
+	t.Helper()
+	engine := camundatest.NewServer()
+	t.Cleanup(engine.Close)
+	cfg.BaseURL = engine.URL()
+	if cfg.RetryBackoff == 0 {
+		cfg.RetryBackoff = time.Millisecond
+	}
+	return workflow.NewCamundaClient(cfg), engine
+}
+
+func TestDeployAndStart(t *testing.T) {
+	client, engine := newClient(t, workflow.ClientConfig{})
+	ctx := context.Background()
+
+	deployment, err := client.DeployProcess(ctx, "ticket-process", []byte(bpmn), workflow.DeployOptions{Source: "test", EnableDuplicateFiltering: true})
+	if err != nil {
+		t.Fatalf("deploy: %v", err)
+	}
+	if deployment.Name != "ticket-process" || deployment.Source != "test" {
+		t.Errorf("deployment = %+v", deployment)
+	}
+	ids := deployment.ProcessDefinitionIDs()
+	if len(ids) != 1 {
+		t.Fatalf("deployed definitions = %v, want 1", ids)
+	}
+	def, err := client.GetProcessDefinition(ctx, ids[0])
+	if err != nil {
+		t.Fatalf("get definition: %v", err)
+	}
+	if def.Key != "ticket-process" || def.Version != 1 {
+		t.Errorf("definition = %+v", def)
+	}
+	again, err := client.DeployProcess(ctx, "ticket-process", []byte(bpmn), workflow.DeployOptions{EnableDuplicateFiltering: true})
+	if err != nil {
+		t.Fatalf("redeploy: %v", err)
+	}
+	if len(again.DeployedProcessDefinitions) != 0 {
+		t.Errorf("unchanged redeploy created %v", again.ProcessDefinitionIDs())
+	}
+
+	due := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	instance, err := client.StartProcessInstance(ctx, "ticket-process", "ticket-1", map[string]any{
+		"title": "laptop",
+		"count": int64(1 << 40),
+		"due":   due,
+	})
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if instance.DefinitionID != ids[0] || instance.BusinessKey != "ticket-1" {
+		t.Errorf("instance = %+v", instance)
+	}
+	started, ok := engine.Instance(instance.ID)
+	if !ok {
+		t.Fatalf("instance %s unknown to the engine", instance.ID)
+	}
+	vars := started.Variables
+	if vars.Get("count").Type != variables.TypeLong || vars.Get("count").Int() != 1<<40 {
+		t.Errorf("count = %+v, want a Long", vars.Get("count"))
+	}
+	if vars.Get("due").Type != variables.TypeDate || !vars.Get("due").Time().Equal(due) {
+		t.Errorf("due = %+v, want a Date", vars.Get("due"))
+	}
+
+	running, err := client.ListProcessInstancesByBusinessKey(ctx, "ticket-1")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(running) != 1 || running[0].ID != instance.ID {
+		t.Errorf("running = %+v", running)
+	}
+	if _, err := client.StartProcessInstance(ctx, "unknown", "ticket-2", nil); !workflow.IsNotFound(err) && !workflow.IsBadUserRequest(err) {
+		t.Errorf("start of unknown key: err = %v, want the engine's rejection", err)
+	}
+}
+
+func TestExternalTaskFetchAndComplete(t *testing.T) {
+	client, engine := newClient(t, workflow.ClientConfig{})
+	ctx := context.Background()
+	engine.AddDefinition("ticket-process")
+	instance, err := client.StartProcessInstance(ctx, "ticket-process", "ticket-1", map[string]any{"title": "laptop"})
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if _, err := engine.AddExternalTask(instance.ID, camundatest.ExternalTask{ActivityID: "ServiceTask_ProcessTicket", TopicName: "ticket-processing"}); err != nil {
+		t.Fatal(err)
+	}
+	var completed variables.Map
+	engine.OnExternalTaskComplete(func(_ camundatest.ExternalTask, vars variables.Map) {
+		completed = vars
+	})
+
+	tasks, err := client.FetchAndLockExternalTasks(ctx, "worker-1", []string{"ticket-processing"}, nil, time.Minute)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].BusinessKey != "ticket-1" || tasks[0].Var("title").String() != "laptop" {
+		t.Fatalf("tasks = %+v", tasks)
+	}
+	if again, _ := client.FetchAndLockExternalTasks(ctx, "worker-2", []string{"ticket-processing"}, nil, time.Minute); len(again) != 0 {
+		t.Errorf("locked task fetched again by another worker: %+v", again)
+	}
+	locked, err := client.LockedExternalTasks(ctx, "worker-1")
+	if err != nil || len(locked) != 1 {
+		t.Fatalf("locked = %+v, %v", locked, err)
+	}
+	if err := client.ExtendExternalTaskLock(ctx, "worker-1", tasks[0].ID, time.Minute); err != nil {
+		t.Fatalf("extend: %v", err)
+	}
+	if err := client.CompleteExternalTask(ctx, "worker-2", tasks[0].ID, nil); !workflow.IsBadUserRequest(err) {
+		t.Errorf("complete by another worker: err = %v, want a bad user request", err)
+	}
+	if err := client.CompleteExternalTask(ctx, "worker-1", tasks[0].ID, map[string]any{"result": "ok"}); err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+	if completed.Get("result").String() != "ok" {
+		t.Errorf("completed with %v", completed)
+	}
+	if open := engine.ExternalTasks(); len(open) != 0 {
+		t.Errorf("open tasks = %+v, want none", open)
+	}
+
+	reqs := engine.RequestsTo(http.MethodPost, "/external-task/fetchAndLock")
+	var body struct {
+		WorkerID string `json:"workerId"`
+		Topics   []struct {
+			TopicName    string `json:"topicName"`
+			LockDuration int64  `json:"lockDuration"`
+		} `json:"topics"`
+	}
+	if err := reqs[0].JSON(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.WorkerID != "worker-1" || len(body.Topics) != 1 || body.Topics[0].LockDuration != 60000 {
+		t.Errorf("fetch body = %+v", body)
+	}
+}
+
+func TestRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		fault    camundatest.Fault
+		call     func(context.Context, *workflow.CamundaClient) error
+		wantErr  bool
+		requests int
+	}{
+		{
+			name:     "idempotent call recovers from 503",
+			fault:    camundatest.Fault{Method: http.MethodGet, Path: "/process-instance", Status: http.StatusServiceUnavailable, Times: 2},
+			call:     listInstances,
+			requests: 3,
+		},
+		{
+			name:     "retries are bounded",
+			fault:    camundatest.Fault{Method: http.MethodGet, Path: "/process-instance", Status: http.StatusBadGateway},
+			call:     listInstances,
+			wantErr:  true,
+			requests: 4,
+		},
+		{
+			name:  "task query is retried",
+			fault: camundatest.Fault{Method: http.MethodPost, Path: "/task", Status: http.StatusInternalServerError, Times: 1},
+			call: func(ctx context.Context, c *workflow.CamundaClient) error {
+				_, err := c.ListTasks(ctx, workflow.TaskQuery{})
+				return err
+			},
+			requests: 2,
+		},
+		{
+			name:  "start is not retried",
+			fault: camundatest.Fault{Method: http.MethodPost, Path: "/process-definition/key/", Status: http.StatusServiceUnavailable, Times: 1},
+			call: func(ctx context.Context, c *workflow.CamundaClient) error {
+				_, err := c.StartProcessInstance(ctx, "p", "k", nil)
+				return err
+			},
+			wantErr:  true,
+			requests: 1,
+		},
+		{
+			name:     "client errors are not retried",
+			fault:    camundatest.Fault{Method: http.MethodGet, Path: "/process-instance", Status: http.StatusBadRequest, Type: "InvalidRequestException", Message: "bad", Times: 1},
+			call:     listInstances,
+			wantErr:  true,
+			requests: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, engine := newClient(t, workflow.ClientConfig{MaxRetries: 3})
+			engine.AddDefinition("p")
+			engine.Inject(tt.fault)
+			err := tt.call(context.Background(), client)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if n := len(engine.RequestsTo(tt.fault.Method, tt.fault.Path)); n != tt.requests {
+				t.Errorf("requests = %d, want %d", n, tt.requests)
+			}
+		})
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	client, engine := newClient(t, workflow.ClientConfig{Timeout: 50 * time.Millisecond, MaxRetries: 1})
+	engine.Inject(camundatest.Fault{Method: http.MethodGet, Path: "/process-instance", Hang: true})
+	start := time.Now()
+	err := listInstances(context.Background(), client)
+	if err == nil {
+		t.Fatal("hanging engine answered")
+	}
+	if _, ok := workflow.AsCamundaError(err); ok {
+		t.Errorf("err = %v, want a connection error", err)
+	}
+	if n := len(engine.RequestsTo(http.MethodGet, "/process-instance")); n != 2 {
+		t.Errorf("requests = %d, want the attempt and one retry", n)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("took %s despite the timeout", elapsed)
+	}
+
+	// A delay shorter than the timeout is waited out.
+	engine.ClearFaults()
+	engine.Inject(camundatest.Fault{Method: http.MethodGet, Path: "/process-instance", Delay: 10 * time.Millisecond})
+	if err := listInstances(context.Background(), client); err != nil {
+		t.Errorf("delayed answer: %v", err)
+	}
+
+	// The caller's deadline stops retries.
+	engine.ClearFaults()
+	engine.Inject(camundatest.Fault{Method: http.MethodGet, Path: "/process-instance", Hang: true})
+	engine.ResetRequests()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := listInstances(ctx, client); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want the context's deadline", err)
+	}
+	if n := len(engine.Requests()); n != 1 {
+		t.Errorf("requests = %d, want no retry after the deadline", n)
+	}
+}
+
+func TestErrorDecoding(t *testing.T) {
+	client, engine := newClient(t, workflow.ClientConfig{})
+	ctx := context.Background()
+	if _, err := client.GetProcessInstance(ctx, "missing"); !workflow.IsNotFound(err) {
+		t.Errorf("missing instance: err = %v, want not found", err)
+	}
+	engine.Inject(camundatest.Fault{Path: "/task/", Status: http.StatusInternalServerError, Type: "OptimisticLockingException", Message: "changed", Times: 1})
+	err := client.CompleteTask(ctx, "task-1", nil)
+	if !workflow.IsOptimisticLocking(err) {
+		t.Errorf("err = %v, want optimistic locking", err)
+	}
+	ce, ok := workflow.AsCamundaError(err)
+	if !ok || ce.StatusCode != http.StatusInternalServerError || ce.Message != "changed" {
+		t.Errorf("decoded error = %+v", ce)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	client, engine := newClient(t, workflow.ClientConfig{BreakerThreshold: 2, BreakerCooldown: time.Hour})
+	engine.Inject(camundatest.Fault{Method: http.MethodGet, Path: "/process-instance", Status: http.StatusServiceUnavailable})
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if err := listInstances(ctx, client); err == nil || errors.Is(err, workflow.ErrCircuitOpen) {
+			t.Fatalf("call %d: err = %v, want the engine's failure", i, err)
+		}
+	}
+	engine.ResetRequests()
+	if err := listInstances(ctx, client); !errors.Is(err, workflow.ErrCircuitOpen) {
+		t.Fatalf("err = %v, want the open circuit", err)
+	}
+	if n := len(engine.Requests()); n != 0 {
+		t.Errorf("open circuit sent %d requests", n)
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	client, engine := newClient(t, workflow.ClientConfig{BearerToken: "secret"})
+	engine.RequireAuthorization("Bearer secret")
+	if err := listInstances(context.Background(), client); err != nil {
+		t.Fatalf("authorized call: %v", err)
+	}
+	other := workflow.NewCamundaClient(workflow.ClientConfig{BaseURL: engine.URL(), Username: "demo", Password: "demo"})
+	err := listInstances(context.Background(), other)
+	if ce, ok := workflow.AsCamundaError(err); !ok || ce.StatusCode != http.StatusUnauthorized {
+		t.Errorf("err = %v, want 401", err)
+	}
+	if got := engine.Requests()[0].Header.Get("Authorization"); got != "Bearer secret" {
+		t.Errorf("authorization = %q", got)
+	}
+}
+
+func listInstances(ctx context.Context, c *workflow.CamundaClient) error {
+	_, err := c.ListProcessInstancesByBusinessKey(ctx, "ticket-1")
+	return err
+}
@@ -0,0 +1,1079 @@
+package camundatest
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/example/pflow/backend/internal/workflow"
+	"github.com/example/pflow/backend/internal/workflow/variables"
+)
+
+const bpmnModelNamespace = "http://www.omg.org/spec/BPMN/20100524/MODEL"
+
+// typedVariables infers types for untyped values, as the engine does for JSON input.
+func typedVariables(in variables.Map) variables.Map {
+	out := make(variables.Map, len(in))
+	for name, v := range in {
+		if v.Type == "" {
+			if inferred, err := variables.Of(v.Value); err == nil {
+				v = inferred
+			}
+		}
+		out[name] = v
+	}
+	return out
+}
+
+func (s *Server) route(w http.ResponseWriter, r *http.Request, path string) {
+	seg := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case r.Method == http.MethodPost && path == "/deployment/create":
+		s.deploy(w, r)
+	case r.Method == http.MethodGet && path == "/process-definition":
+		s.listDefinitions(w, r)
+	case r.Method == http.MethodPost && len(seg) == 4 && seg[0] == "process-definition" && seg[1] == "key" && seg[3] == "start":
+		s.start(w, r, func() *definition { return s.latestDefinition(seg[2], "") })
+	case r.Method == http.MethodPost && len(seg) == 6 && seg[0] == "process-definition" && seg[1] == "key" && seg[3] == "tenant-id" && seg[5] == "start":
+		s.start(w, r, func() *definition { return s.latestDefinition(seg[2], seg[4]) })
+	case r.Method == http.MethodPost && len(seg) == 3 && seg[0] == "process-definition" && seg[2] == "start":
+		s.start(w, r, func() *definition { return s.definitionByID(seg[1]) })
+	case r.Method == http.MethodGet && len(seg) == 2 && seg[0] == "process-definition":
+		s.getDefinition(w, seg[1], false)
+	case r.Method == http.MethodGet && len(seg) == 3 && seg[0] == "process-definition" && seg[2] == "xml":
+		s.getDefinition(w, seg[1], true)
+	case r.Method == http.MethodGet && path == "/process-instance":
+		s.listInstances(w, r)
+	case r.Method == http.MethodGet && len(seg) == 2 && seg[0] == "process-instance":
+		s.getInstance(w, seg[1])
+	case r.Method == http.MethodDelete && len(seg) == 2 && seg[0] == "process-instance":
+		s.deleteInstance(w, r, seg[1])
+	case r.Method == http.MethodGet && len(seg) == 3 && seg[0] == "process-instance" && seg[2] == "activity-instances":
+		s.activityTree(w, seg[1])
+	case r.Method == http.MethodGet && path == "/history/activity-instance":
+		s.historicActivities(w, r)
+	case r.Method == http.MethodGet && len(seg) == 3 && seg[0] == "history" && seg[1] == "process-instance":
+		s.historicInstance(w, seg[2])
+	case r.Method == http.MethodGet && path == "/incident":
+		s.listIncidents(w, r)
+	case r.Method == http.MethodPost && path == "/message":
+		s.correlate(w, r)
+	case r.Method == http.MethodPost && len(seg) == 2 && seg[0] == "migration":
+		s.migrate(w, r, seg[1])
+	case r.Method == http.MethodGet && len(seg) == 2 && seg[0] == "batch":
+		// Batches run synchronously in the fake, so they have always finished and been removed.
+		writeError(w, http.StatusNotFound, "InvalidRequestException", "Batch for id '"+seg[1]+"' cannot be found")
+	case r.Method == http.MethodGet && path == "/external-task":
+		s.listExternalTasks(w, r)
+	case r.Method == http.MethodPost && path == "/external-task/fetchAndLock":
+		s.fetchAndLock(w, r)
+	case r.Method == http.MethodPost && len(seg) == 3 && seg[0] == "external-task":
+		s.externalTaskAction(w, r, seg[1], seg[2])
+	case r.Method == http.MethodPut && len(seg) == 3 && seg[0] == "external-task" && seg[2] == "retries":
+		s.setExternalTaskRetries(w, r, seg[1])
+	case r.Method == http.MethodPost && (path == "/task" || path == "/task/count"):
+		s.queryTasks(w, r, path == "/task/count")
+	case r.Method == http.MethodPost && len(seg) == 3 && seg[0] == "task":
+		s.taskAction(w, r, seg[1], seg[2])
+	default:
+		writeError(w, http.StatusNotImplemented, "RestException", fmt.Sprintf("%s %s is not supported by the fake engine", r.Method, path))
+	}
+}
+
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil && err != io.EOF {
+		writeError(w, http.StatusBadRequest, "InvalidRequestException", "invalid JSON body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// splitList parses comma-separated query parameters such as tenantIdIn; empty reads as nil.
+func splitList(v string) []string {
+	if v == "" {
+		return nil
+	}
+	return strings.Split(v, ",")
+}
+
+// nullable renders empty strings as null, as Camunda does for unset ids.
+func nullable(v string) any {
+	if v == "" {
+		return nil
+	}
+	return v
+}
+
+func (s *Server) deploy(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidRequestException", err.Error())
+		return
+	}
+	form := r.MultipartForm
+	field := func(name string) string {
+		if v := form.Value[name]; len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+	filter := field("enable-duplicate-filtering") == "true" || field("deploy-changed-only") == "true"
+	tenantID := field("tenant-id")
+
+	type resource struct {
+		name string
+		data []byte
+	}
+	var resources []resource
+	for _, files := range form.File {
+		for _, fh := range files {
+			f, err := fh.Open()
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "InvalidRequestException", err.Error())
+				return
+			}
+			data, err := io.ReadAll(f)
+			f.Close()
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "InvalidRequestException", err.Error())
+				return
+			}
+			resources = append(resources, resource{name: fh.Filename, data: data})
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	deployment := &workflow.Deployment{
+		ID:             s.nextID("deployment"),
+		Name:           field("deployment-name"),
+		Source:         field("deployment-source"),
+		DeploymentTime: workflow.FormatTime(s.now()),
+		TenantID:       tenantID,
+	}
+	for _, res := range resources {
+		processes, err := parseProcesses(res.data)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "ParseException", fmt.Sprintf("resource %s: %v", res.name, err))
+			return
+		}
+		for _, p := range processes {
+			if latest := s.latestDefinition(p.id, tenantID); filter && latest != nil && bytes.Equal(latest.xml, res.data) {
+				continue
+			}
+			def := s.addDefinition(p.id, tenantID, p.name, p.versionTag, res.name, res.data)
+			def.DeploymentID = deployment.ID
+			if deployment.DeployedProcessDefinitions == nil {
+				deployment.DeployedProcessDefinitions = map[string]workflow.ProcessDefinition{}
+			}
+			deployment.DeployedProcessDefinitions[def.ID] = def.ProcessDefinition
+		}
+	}
+	s.deployments[deployment.ID] = deployment
+	writeJSON(w, http.StatusOK, deployment)
+}
+
+type bpmnProcess struct {
+	id, name, versionTag string
+	activities           []string
+}
+
+// parseProcesses extracts the processes of a BPMN document together with the ids of their flow nodes.
+func parseProcesses(data []byte) ([]bpmnProcess, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var processes []bpmnProcess
+	var current *bpmnProcess
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch el := tok.(type) {
+		case xml.StartElement:
+			if el.Name.Space != bpmnModelNamespace {
+				continue
+			}
+			attrs := map[string]string{}
+			for _, a := range el.Attr {
+				attrs[a.Name.Local] = a.Value
+			}
+			switch {
+			case el.Name.Local == "process":
+				processes = append(processes, bpmnProcess{id: attrs["id"], name: attrs["name"], versionTag: attrs["versionTag"]})
+				current = &processes[len(processes)-1]
+			case current != nil && attrs["id"] != "" && el.Name.Local != "sequenceFlow":
+				current.activities = append(current.activities, attrs["id"])
+			}
+		case xml.EndElement:
+			if el.Name.Space == bpmnModelNamespace && el.Name.Local == "process" {
+				current = nil
+			}
+		}
+	}
+	if len(processes) == 0 {
+		return nil, fmt.Errorf("no process definition found")
+	}
+	return processes, nil
+}
+
+func (s *Server) listDefinitions(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	tenants := splitList(r.URL.Query().Get("tenantIdIn"))
+	s.mu.Lock()
+	out := []workflow.ProcessDefinition{}
+	for _, d := range s.definitions {
+		if (key == "" || d.Key == key) && (tenants == nil || slices.Contains(tenants, d.TenantID)) {
+			out = append(out, d.ProcessDefinition)
+		}
+	}
+	s.mu.Unlock()
+	if r.URL.Query().Get("sortBy") == "version" {
+		desc := r.URL.Query().Get("sortOrder") == "desc"
+		sort.SliceStable(out, func(i, j int) bool {
+			if desc {
+				return out[i].Version > out[j].Version
+			}
+			return out[i].Version < out[j].Version
+		})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) getDefinition(w http.ResponseWriter, id string, withXML bool) {
+	s.mu.Lock()
+	def := s.definitionByID(id)
+	s.mu.Unlock()
+	if def == nil {
+		writeError(w, http.StatusNotFound, "RestException", "No matching definition with id "+id)
+		return
+	}
+	if withXML {
+		writeJSON(w, http.StatusOK, map[string]any{"id": def.ID, "bpmn20Xml": string(def.xml)})
+		return
+	}
+	writeJSON(w, http.StatusOK, def.ProcessDefinition)
+}
+
+func (s *Server) start(w http.ResponseWriter, r *http.Request, find func() *definition) {
+	var payload struct {
+		BusinessKey string        `json:"businessKey"`
+		Variables   variables.Map `json:"variables"`
+	}
+	if !decode(w, r, &payload) {
+		return
+	}
+	s.mu.Lock()
+	def := find()
+	if def == nil {
+		s.mu.Unlock()
+		writeError(w, http.StatusNotFound, "RestException", "No matching process definition")
+		return
+	}
+	if def.Suspended {
+		s.mu.Unlock()
+		writeError(w, http.StatusInternalServerError, "ProcessEngineException", "Cannot start process instance. Process definition "+def.ID+" is suspended")
+		return
+	}
+	inst := &Instance{
+		ID:            s.nextID("instance"),
+		DefinitionID:  def.ID,
+		DefinitionKey: def.Key,
+		BusinessKey:   payload.BusinessKey,
+		TenantID:      def.TenantID,
+		Variables:     typedVariables(payload.Variables),
+		State:         StateActive,
+		StartTime:     s.now(),
+	}
+	s.instances[inst.ID] = inst
+	snapshot := inst.clone()
+	hook := s.onStart
+	s.mu.Unlock()
+
+	if hook != nil {
+		hook(snapshot)
+	}
+	writeJSON(w, http.StatusOK, s.runtimeInstance(snapshot.ID))
+}
+
+func (s *Server) runtimeInstance(id string) workflow.ProcessInstance {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inst := s.instances[id]
+	return workflow.ProcessInstance{ID: inst.ID, DefinitionID: inst.DefinitionID, BusinessKey: inst.BusinessKey, Ended: inst.Ended(), TenantID: inst.TenantID}
+}
+
+func (s *Server) listInstances(w http.ResponseWriter, r *http.Request) {
+	businessKey := r.URL.Query().Get("businessKey")
+	s.mu.Lock()
+	out := []workflow.ProcessInstance{}
+	for _, inst := range s.sortedInstances() {
+		if !inst.Ended() && (businessKey == "" || inst.BusinessKey == businessKey) {
+			out = append(out, workflow.ProcessInstance{ID: inst.ID, DefinitionID: inst.DefinitionID, BusinessKey: inst.BusinessKey, TenantID: inst.TenantID})
+		}
+	}
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, out)
+}
+
+// sortedInstances returns the instances in start order. Callers hold s.mu.
+func (s *Server) sortedInstances() []*Instance {
+	out := make([]*Instance, 0, len(s.instances))
+	for _, inst := range s.instances {
+		out = append(out, inst)
+	}
+	sort.Slice(out, func(i, j int) bool { return idSeq(out[i].ID) < idSeq(out[j].ID) })
+	return out
+}
+
+func idSeq(id string) int {
+	n, _ := strconv.Atoi(id[strings.LastIndex(id, "-")+1:])
+	return n
+}
+
+func (s *Server) getInstance(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	inst, ok := s.instances[id]
+	running := ok && !inst.Ended()
+	s.mu.Unlock()
+	if !running {
+		writeError(w, http.StatusNotFound, "InvalidRequestException", "Process instance with id "+id+" does not exist")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.runtimeInstance(id))
+}
+
+func (s *Server) deleteInstance(w http.ResponseWriter, r *http.Request, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inst, ok := s.instances[id]
+	if !ok || inst.Ended() {
+		if r.URL.Query().Get("failIfNotExists") == "false" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		writeError(w, http.StatusNotFound, "InvalidRequestException", "Process instance with id "+id+" does not exist")
+		return
+	}
+	s.endInstance(inst, StateExternallyTerminated)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) activityTree(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inst, ok := s.instances[id]
+	if !ok || inst.Ended() {
+		writeError(w, http.StatusNotFound, "InvalidRequestException", "Process instance with id "+id+" does not exist")
+		return
+	}
+	root := workflow.ActivityInstance{
+		ID:                       inst.ID,
+		ActivityID:               inst.DefinitionID,
+		ActivityType:             "processDefinition",
+		ChildActivityInstances:   []workflow.ActivityInstance{},
+		ChildTransitionInstances: []workflow.TransitionInstance{},
+	}
+	for _, a := range inst.Activities {
+		if a.EndTime == nil {
+			root.ChildActivityInstances = append(root.ChildActivityInstances, workflow.ActivityInstance{
+				ID:           a.ID,
+				ActivityID:   a.ActivityID,
+				ActivityName: a.ActivityName,
+				ActivityType: a.ActivityType,
+			})
+		}
+	}
+	writeJSON(w, http.StatusOK, root)
+}
+
+func (s *Server) historicActivities(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	instanceID := query.Get("processInstanceId")
+	var startedAfter time.Time
+	if v := query.Get("startedAfter"); v != "" {
+		t, err := workflow.ParseTime(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "InvalidRequestException", "Cannot set query parameter 'startedAfter' to value '"+v+"'")
+			return
+		}
+		startedAfter = t
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := []workflow.HistoricActivityInstance{}
+	for _, inst := range s.sortedInstances() {
+		if instanceID != "" && inst.ID != instanceID {
+			continue
+		}
+		for _, a := range inst.Activities {
+			if start, _ := workflow.ParseTime(a.StartTime); !start.Before(startedAfter) {
+				out = append(out, a)
+			}
+		}
+	}
+	if query.Get("sortBy") == "startTime" {
+		sort.SliceStable(out, func(i, j int) bool { return out[i].StartTime < out[j].StartTime })
+	}
+	first, _ := strconv.Atoi(query.Get("firstResult"))
+	out = out[min(first, len(out)):]
+	if limit, _ := strconv.Atoi(query.Get("maxResults")); limit > 0 && limit < len(out) {
+		out = out[:limit]
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) historicInstance(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inst, ok := s.instances[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "InvalidRequestException", "Historic process instance with id "+id+" does not exist")
+		return
+	}
+	out := workflow.HistoricProcessInstance{
+		ID:                   inst.ID,
+		BusinessKey:          inst.BusinessKey,
+		ProcessDefinitionID:  inst.DefinitionID,
+		ProcessDefinitionKey: inst.DefinitionKey,
+		StartTime:            workflow.FormatTime(inst.StartTime),
+		State:                inst.State,
+	}
+	if def := s.definitionByID(inst.DefinitionID); def != nil {
+		out.ProcessDefinitionVersion = def.Version
+	}
+	if inst.EndTime != nil {
+		end := workflow.FormatTime(*inst.EndTime)
+		out.EndTime = &end
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) listIncidents(w http.ResponseWriter, r *http.Request) {
+	instanceID := r.URL.Query().Get("processInstanceId")
+	incidentID := r.URL.Query().Get("incidentId")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := []workflow.Incident{}
+	for _, inc := range s.incidents {
+		if (instanceID == "" || inc.ProcessInstanceID == instanceID) && (incidentID == "" || inc.ID == incidentID) {
+			out = append(out, inc)
+		}
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) correlate(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		MessageName       string        `json:"messageName"`
+		BusinessKey       string        `json:"businessKey"`
+		ProcessInstanceID string        `json:"processInstanceId"`
+		ProcessVariables  variables.Map `json:"processVariables"`
+		All               bool          `json:"all"`
+	}
+	if !decode(w, r, &payload) {
+		return
+	}
+	msg := Message{
+		Name:              payload.MessageName,
+		BusinessKey:       payload.BusinessKey,
+		ProcessInstanceID: payload.ProcessInstanceID,
+		Variables:         typedVariables(payload.ProcessVariables),
+		All:               payload.All,
+	}
+
+	s.mu.Lock()
+	var matched []*Instance
+	for _, inst := range s.sortedInstances() {
+		if inst.Ended() ||
+			(msg.BusinessKey != "" && inst.BusinessKey != msg.BusinessKey) ||
+			(msg.ProcessInstanceID != "" && inst.ID != msg.ProcessInstanceID) {
+			continue
+		}
+		matched = append(matched, inst)
+	}
+	hook := s.onMessage
+	s.mu.Unlock()
+
+	if len(matched) == 0 {
+		writeError(w, http.StatusBadRequest, "MismatchingMessageCorrelationException",
+			fmt.Sprintf("Cannot correlate message '%s': No process definition or execution matches the parameters", msg.Name))
+		return
+	}
+	if len(matched) > 1 && !msg.All {
+		writeError(w, http.StatusBadRequest, "MismatchingMessageCorrelationException",
+			fmt.Sprintf("Cannot correlate a message with name '%s' to a single execution. %d executions match the correlation keys", msg.Name, len(matched)))
+		return
+	}
+	if hook != nil {
+		if err := hook(msg); err != nil {
+			writeError(w, http.StatusBadRequest, "MismatchingMessageCorrelationException", err.Error())
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	results := make([]map[string]any, 0, len(matched))
+	for _, inst := range matched {
+		for name, v := range msg.Variables {
+			inst.Variables[name] = v
+		}
+		results = append(results, map[string]any{
+			"resultType": "Execution",
+			"execution":  map[string]any{"id": inst.ID, "processInstanceId": inst.ID},
+		})
+	}
+	writeJSON(w, http.StatusOK, results)
+}
+
+func (s *Server) migrate(w http.ResponseWriter, r *http.Request, op string) {
+	switch op {
+	case "generate":
+		var payload struct {
+			Source string `json:"sourceProcessDefinitionId"`
+			Target string `json:"targetProcessDefinitionId"`
+		}
+		if !decode(w, r, &payload) {
+			return
+		}
+		s.mu.Lock()
+		source, target := s.definitionByID(payload.Source), s.definitionByID(payload.Target)
+		s.mu.Unlock()
+		if source == nil || target == nil {
+			writeError(w, http.StatusBadRequest, "BadUserRequestException", "source or target process definition does not exist")
+			return
+		}
+		plan := workflow.MigrationPlan{SourceProcessDefinitionID: source.ID, TargetProcessDefinitionID: target.ID, Instructions: []workflow.MigrationInstruction{}}
+		targetActivities := definitionActivities(target)
+		for _, id := range definitionActivities(source) {
+			if slices.Contains(targetActivities, id) {
+				plan.Instructions = append(plan.Instructions, workflow.MigrationInstruction{SourceActivityIDs: []string{id}, TargetActivityIDs: []string{id}})
+			}
+		}
+		writeJSON(w, http.StatusOK, plan)
+	case "validate":
+		writeJSON(w, http.StatusOK, map[string]any{"instructionReports": []any{}})
+	case "execute", "executeAsync":
+		var payload struct {
+			Plan        workflow.MigrationPlan `json:"migrationPlan"`
+			InstanceIDs []string               `json:"processInstanceIds"`
+		}
+		if !decode(w, r, &payload) {
+			return
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		target := s.definitionByID(payload.Plan.TargetProcessDefinitionID)
+		if target == nil {
+			writeError(w, http.StatusBadRequest, "BadUserRequestException", "target process definition does not exist")
+			return
+		}
+		for _, id := range payload.InstanceIDs {
+			inst, ok := s.instances[id]
+			if !ok || inst.Ended() || inst.DefinitionID != payload.Plan.SourceProcessDefinitionID {
+				writeError(w, http.StatusBadRequest, "BadUserRequestException",
+					fmt.Sprintf("Process instance %s is not a running instance of %s", id, payload.Plan.SourceProcessDefinitionID))
+				return
+			}
+		}
+		for _, id := range payload.InstanceIDs {
+			s.instances[id].DefinitionID = target.ID
+			s.instances[id].DefinitionKey = target.Key
+		}
+		if op == "execute" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		writeJSON(w, http.StatusOK, workflow.Batch{ID: s.nextID("batch"), Type: "instance-migration", TotalJobs: len(payload.InstanceIDs)})
+	default:
+		writeError(w, http.StatusNotImplemented, "RestException", "migration "+op+" is not supported by the fake engine")
+	}
+}
+
+func definitionActivities(def *definition) []string {
+	processes, err := parseProcesses(def.xml)
+	if err != nil {
+		return nil
+	}
+	for _, p := range processes {
+		if p.id == def.Key {
+			return p.activities
+		}
+	}
+	return nil
+}
+
+func (s *Server) fetchAndLock(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		WorkerID    string `json:"workerId"`
+		MaxTasks    int    `json:"maxTasks"`
+		UsePriority bool   `json:"usePriority"`
+		Topics      []struct {
+			TopicName    string   `json:"topicName"`
+			LockDuration int64    `json:"lockDuration"`
+			TenantIDIn   []string `json:"tenantIdIn"`
+		} `json:"topics"`
+	}
+	if !decode(w, r, &payload) {
+		return
+	}
+	if payload.WorkerID == "" {
+		writeError(w, http.StatusBadRequest, "InvalidRequestException", "workerId is required")
+		return
+	}
+	lockFor := map[string]time.Duration{}
+	tenantsFor := map[string][]string{}
+	for _, t := range payload.Topics {
+		lockFor[t.TopicName] = time.Duration(t.LockDuration) * time.Millisecond
+		tenantsFor[t.TopicName] = t.TenantIDIn
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	var candidates []*ExternalTask
+	for _, t := range s.externalTasks {
+		if _, ok := lockFor[t.TopicName]; !ok {
+			continue
+		}
+		if tenants := tenantsFor[t.TopicName]; len(tenants) > 0 && !slices.Contains(tenants, s.instances[t.ProcessInstanceID].TenantID) {
+			continue
+		}
+		if now.Before(t.LockExpiration) || now.Before(t.AvailableAt) || (t.Retries != nil && *t.Retries == 0) {
+			continue
+		}
+		candidates = append(candidates, t)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if payload.UsePriority && candidates[i].Priority != candidates[j].Priority {
+			return candidates[i].Priority > candidates[j].Priority
+		}
+		return candidates[i].seq < candidates[j].seq
+	})
+	if payload.MaxTasks > 0 && len(candidates) > payload.MaxTasks {
+		candidates = candidates[:payload.MaxTasks]
+	}
+
+	out := make([]map[string]any, 0, len(candidates))
+	for _, t := range candidates {
+		t.WorkerID = payload.WorkerID
+		t.LockExpiration = now.Add(lockFor[t.TopicName])
+		inst := s.instances[t.ProcessInstanceID]
+		out = append(out, map[string]any{
+			"id":                  t.ID,
+			"topicName":           t.TopicName,
+			"workerId":            t.WorkerID,
+			"processInstanceId":   t.ProcessInstanceID,
+			"processDefinitionId": inst.DefinitionID,
+			"activityId":          t.ActivityID,
+			"businessKey":         inst.BusinessKey,
+			"tenantId":            nullable(inst.TenantID),
+			"priority":            t.Priority,
+			"retries":             t.Retries,
+			"errorMessage":        t.ErrorMessage,
+			"lockExpirationTime":  workflow.FormatTime(t.LockExpiration),
+			"variables":           inst.Variables,
+		})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) listExternalTasks(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	var tasks []*ExternalTask
+	for _, t := range s.externalTasks {
+		locked := now.Before(t.LockExpiration)
+		if (q.Has("workerId") && t.WorkerID != q.Get("workerId")) ||
+			(q.Get("locked") == "true" && !locked) || (q.Get("notLocked") == "true" && locked) ||
+			(q.Has("topicName") && t.TopicName != q.Get("topicName")) {
+			continue
+		}
+		tasks = append(tasks, t)
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].seq < tasks[j].seq })
+	out := make([]map[string]any, 0, len(tasks))
+	for _, t := range tasks {
+		inst := s.instances[t.ProcessInstanceID]
+		out = append(out, map[string]any{
+			"id":                 t.ID,
+			"topicName":          t.TopicName,
+			"workerId":           nullable(t.WorkerID),
+			"processInstanceId":  t.ProcessInstanceID,
+			"activityId":         t.ActivityID,
+			"businessKey":        inst.BusinessKey,
+			"tenantId":           nullable(inst.TenantID),
+			"priority":           t.Priority,
+			"retries":            t.Retries,
+			"errorMessage":       t.ErrorMessage,
+			"lockExpirationTime": lockExpiration(t.LockExpiration),
+		})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// setExternalTaskRetries makes a failed task fetchable again and resolves its incident.
+func (s *Server) setExternalTaskRetries(w http.ResponseWriter, r *http.Request, id string) {
+	var payload struct {
+		Retries *int `json:"retries"`
+	}
+	if !decode(w, r, &payload) {
+		return
+	}
+	if payload.Retries == nil || *payload.Retries < 0 {
+		writeError(w, http.StatusBadRequest, "InvalidRequestException", "retries must be a non-negative number")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	task, ok := s.externalTasks[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "RestException", "External task with id "+id+" does not exist")
+		return
+	}
+	retries := *payload.Retries
+	task.Retries = &retries
+	task.AvailableAt = time.Time{}
+	if retries > 0 {
+		open := s.incidents[:0]
+		for _, inc := range s.incidents {
+			if inc.IncidentType != "failedExternalTask" || inc.Configuration != id {
+				open = append(open, inc)
+			}
+		}
+		s.incidents = open
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func lockExpiration(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return workflow.FormatTime(t)
+}
+
+func (s *Server) externalTaskAction(w http.ResponseWriter, r *http.Request, id, action string) {
+	var payload struct {
+		WorkerID     string        `json:"workerId"`
+		Variables    variables.Map `json:"variables"`
+		ErrorMessage string        `json:"errorMessage"`
+		Retries      *int          `json:"retries"`
+		RetryTimeout int64         `json:"retryTimeout"`
+		NewDuration  int64         `json:"newDuration"`
+	}
+	if !decode(w, r, &payload) {
+		return
+	}
+
+	s.mu.Lock()
+	task, ok := s.externalTasks[id]
+	if !ok {
+		s.mu.Unlock()
+		writeError(w, http.StatusNotFound, "RestException", "External task with id "+id+" does not exist")
+		return
+	}
+	now := s.now()
+	if action == "unlock" {
+		// Unlocking is an operator action and is not restricted to the lock holder.
+		task.WorkerID = ""
+		task.LockExpiration = time.Time{}
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if task.WorkerID != payload.WorkerID {
+		s.mu.Unlock()
+		writeError(w, http.StatusBadRequest, "BadUserRequestException",
+			fmt.Sprintf("External Task %s cannot be %s by worker '%s'. It is locked by worker '%s'.", id, externalTaskVerb(action), payload.WorkerID, task.WorkerID))
+		return
+	}
+
+	switch action {
+	case "complete":
+		inst := s.instances[task.ProcessInstanceID]
+		vars := typedVariables(payload.Variables)
+		for name, v := range vars {
+			inst.Variables[name] = v
+		}
+		delete(s.externalTasks, id)
+		s.endActivity(task.ProcessInstanceID, id, false)
+		done, hook := *task, s.onExternalTaskComplete
+		s.mu.Unlock()
+		if hook != nil {
+			hook(done, vars)
+		}
+	case "failure":
+		retries := 0
+		if payload.Retries != nil {
+			retries = *payload.Retries
+		}
+		task.Retries = &retries
+		task.ErrorMessage = payload.ErrorMessage
+		task.WorkerID = ""
+		task.LockExpiration = time.Time{}
+		task.AvailableAt = now.Add(time.Duration(payload.RetryTimeout) * time.Millisecond)
+		if retries == 0 {
+			s.incidents = append(s.incidents, workflow.Incident{
+				ID:                s.nextID("incident"),
+				ProcessInstanceID: task.ProcessInstanceID,
+				ActivityID:        task.ActivityID,
+				IncidentType:      "failedExternalTask",
+				IncidentMessage:   payload.ErrorMessage,
+				IncidentTimestamp: workflow.FormatTime(now),
+				Configuration:     task.ID,
+			})
+		}
+		s.mu.Unlock()
+	case "extendLock":
+		if !now.Before(task.LockExpiration) {
+			s.mu.Unlock()
+			writeError(w, http.StatusBadRequest, "BadUserRequestException",
+				fmt.Sprintf("The lock of the External Task %s cannot be extended by worker '%s'", id, payload.WorkerID))
+			return
+		}
+		task.LockExpiration = now.Add(time.Duration(payload.NewDuration) * time.Millisecond)
+		s.mu.Unlock()
+	default:
+		s.mu.Unlock()
+		writeError(w, http.StatusNotImplemented, "RestException", "external task "+action+" is not supported by the fake engine")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func externalTaskVerb(action string) string {
+	switch action {
+	case "complete":
+		return "completed"
+	case "failure":
+		return "failed"
+	default:
+		return "extended"
+	}
+}
+
+func (s *Server) queryTasks(w http.ResponseWriter, r *http.Request, count bool) {
+	var query workflow.TaskQuery
+	if !decode(w, r, &query) {
+		return
+	}
+	s.mu.Lock()
+	var tasks []workflow.Task
+	for _, t := range s.sortedUserTasks() {
+		if taskMatches(query, t) && (len(query.OrQueries) == 0 || slices.ContainsFunc(query.OrQueries, func(q workflow.TaskQuery) bool { return taskMatches(q, t) })) {
+			tasks = append(tasks, t.Task)
+		}
+	}
+	s.mu.Unlock()
+
+	if count {
+		writeJSON(w, http.StatusOK, map[string]any{"count": len(tasks)})
+		return
+	}
+	sortTasks(tasks, query.Sorting)
+	first, _ := strconv.Atoi(r.URL.Query().Get("firstResult"))
+	first = min(max(first, 0), len(tasks))
+	tasks = tasks[first:]
+	if maxResults, err := strconv.Atoi(r.URL.Query().Get("maxResults")); err == nil && maxResults < len(tasks) {
+		tasks = tasks[:max(maxResults, 0)]
+	}
+	if tasks == nil {
+		tasks = []workflow.Task{}
+	}
+	writeJSON(w, http.StatusOK, tasks)
+}
+
+// sortedUserTasks returns the open user tasks in creation order. Callers hold s.mu.
+func (s *Server) sortedUserTasks() []*UserTask {
+	out := make([]*UserTask, 0, len(s.userTasks))
+	for _, t := range s.userTasks {
+		out = append(out, t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].seq < out[j].seq })
+	return out
+}
+
+// taskMatches applies the AND-ed filters of a query, ignoring its OR branches.
+func taskMatches(q workflow.TaskQuery, t *UserTask) bool {
+	if q.ProcessInstanceID != "" && t.ProcessInstanceID != q.ProcessInstanceID {
+		return false
+	}
+	if q.TaskDefinitionKey != "" && t.TaskDefinitionKey != q.TaskDefinitionKey {
+		return false
+	}
+	if q.Assignee != "" && t.Assignee != q.Assignee {
+		return false
+	}
+	if q.Unassigned && t.Assignee != "" {
+		return false
+	}
+	if q.DelegationState != "" && t.DelegationState != q.DelegationState {
+		return false
+	}
+	if len(q.TenantIDIn) > 0 && !slices.Contains(q.TenantIDIn, t.TenantID) {
+		return false
+	}
+	candidateFilter := q.CandidateUser != "" || len(q.CandidateGroups) > 0
+	if candidateFilter && t.Assignee != "" && !q.IncludeAssignedTasks {
+		return false
+	}
+	if q.CandidateUser != "" && !slices.Contains(t.CandidateUsers, q.CandidateUser) {
+		return false
+	}
+	if len(q.CandidateGroups) > 0 && !slices.ContainsFunc(q.CandidateGroups, func(g string) bool { return slices.Contains(t.CandidateGroups, g) }) {
+		return false
+	}
+	if q.DueBefore != "" {
+		if t.Due == nil || !dueBefore(*t.Due, q.DueBefore) {
+			return false
+		}
+	}
+	return true
+}
+
+func dueBefore(due, limit string) bool {
+	const layout = "2006-01-02T15:04:05.000-0700"
+	d, err1 := time.Parse(layout, due)
+	l, err2 := time.Parse(layout, limit)
+	if err1 != nil || err2 != nil {
+		return due < limit
+	}
+	return d.Before(l)
+}
+
+func sortTasks(tasks []workflow.Task, sorting []workflow.TaskSorting) {
+	sort.SliceStable(tasks, func(i, j int) bool {
+		for _, by := range sorting {
+			c := compareTasks(tasks[i], tasks[j], by.SortBy)
+			if c == 0 {
+				continue
+			}
+			if by.SortOrder == "desc" {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+}
+
+// compareTasks orders tasks by a Camunda sort key. Tasks without a due date sort last.
+func compareTasks(a, b workflow.Task, by string) int {
+	switch by {
+	case "dueDate":
+		switch {
+		case a.Due == nil && b.Due == nil:
+			return 0
+		case a.Due == nil:
+			return 1
+		case b.Due == nil:
+			return -1
+		}
+		return strings.Compare(*a.Due, *b.Due)
+	case "priority":
+		return a.Priority - b.Priority
+	case "created":
+		return strings.Compare(a.Created, b.Created)
+	case "name":
+		return strings.Compare(a.Name, b.Name)
+	case "id":
+		return idSeq(a.ID) - idSeq(b.ID)
+	}
+	return 0
+}
+
+func (s *Server) taskAction(w http.ResponseWriter, r *http.Request, id, action string) {
+	var payload struct {
+		UserID    string        `json:"userId"`
+		GroupID   string        `json:"groupId"`
+		Type      string        `json:"type"`
+		Variables variables.Map `json:"variables"`
+	}
+	if !decode(w, r, &payload) {
+		return
+	}
+
+	s.mu.Lock()
+	task, ok := s.userTasks[id]
+	if !ok {
+		s.mu.Unlock()
+		writeError(w, http.StatusNotFound, "InvalidRequestException", "Cannot find task with id "+id)
+		return
+	}
+	vars := typedVariables(payload.Variables)
+	setVariables := func() {
+		inst := s.instances[task.ProcessInstanceID]
+		for name, v := range vars {
+			inst.Variables[name] = v
+		}
+	}
+
+	switch action {
+	case "complete":
+		setVariables()
+		delete(s.userTasks, id)
+		s.endActivity(task.ProcessInstanceID, id, false)
+		done, hook := *task, s.onTaskComplete
+		s.mu.Unlock()
+		if hook != nil {
+			hook(done, vars)
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	case "assignee":
+		task.Assignee = payload.UserID
+	case "claim":
+		if task.Assignee != "" && task.Assignee != payload.UserID {
+			s.mu.Unlock()
+			writeError(w, http.StatusInternalServerError, "TaskAlreadyClaimedException",
+				fmt.Sprintf("Task '%s' is already claimed by someone else.", id))
+			return
+		}
+		task.Assignee = payload.UserID
+	case "unclaim":
+		task.Assignee = ""
+	case "delegate":
+		if task.Owner == "" {
+			task.Owner = task.Assignee
+		}
+		task.Assignee = payload.UserID
+		task.DelegationState = "PENDING"
+	case "resolve":
+		setVariables()
+		task.Assignee = task.Owner
+		task.DelegationState = "RESOLVED"
+	case "identity-links":
+		switch payload.Type {
+		case "candidate":
+			if payload.GroupID != "" && !slices.Contains(task.CandidateGroups, payload.GroupID) {
+				task.CandidateGroups = append(task.CandidateGroups, payload.GroupID)
+			}
+			if payload.UserID != "" && !slices.Contains(task.CandidateUsers, payload.UserID) {
+				task.CandidateUsers = append(task.CandidateUsers, payload.UserID)
+			}
+		case "assignee":
+			task.Assignee = payload.UserID
+		case "owner":
+			task.Owner = payload.UserID
+		}
+	default:
+		s.mu.Unlock()
+		writeError(w, http.StatusNotImplemented, "RestException", "task "+action+" is not supported by the fake engine")
+		return
+	}
+	s.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
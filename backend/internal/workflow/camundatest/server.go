@@ -0,0 +1,264 @@
+// Package camundatest provides an in-process fake of the Camunda 7 REST API for tests that
+// exercise code built on workflow.CamundaClient without a running engine.
+//
+// The fake keeps process definitions, instances, user tasks and external tasks in memory. It does
+// not execute BPMN: tests script what happens when an instance starts or a task completes through
+// the On* hooks and the Add* helpers. Faults can be injected per endpoint and every request is
+// recorded for assertions.
+package camundatest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/example/pflow/backend/internal/workflow"
+	"github.com/example/pflow/backend/internal/workflow/variables"
+)
+
+// BasePath is the path prefix the fake serves the REST API under, as Camunda Run does.
+const BasePath = "/engine-rest"
+
+// Server is a fake Camunda engine listening on a local httptest server.
+type Server struct {
+	srv    *httptest.Server
+	closed chan struct{}
+	once   sync.Once
+
+	mu            sync.Mutex
+	now           func() time.Time
+	authorization string
+	seq           int
+	faults        []*Fault
+	requests      []Request
+
+	definitions   []*definition
+	deployments   map[string]*workflow.Deployment
+	instances     map[string]*Instance
+	userTasks     map[string]*UserTask
+	externalTasks map[string]*ExternalTask
+	incidents     []workflow.Incident
+
+	onStart                func(Instance)
+	onTaskComplete         func(UserTask, variables.Map)
+	onExternalTaskComplete func(ExternalTask, variables.Map)
+	onMessage              func(Message) error
+}
+
+// NewServer starts a fake engine. Callers must Close it when done.
+func NewServer() *Server {
+	s := &Server{
+		closed:        make(chan struct{}),
+		now:           time.Now,
+		deployments:   map[string]*workflow.Deployment{},
+		instances:     map[string]*Instance{},
+		userTasks:     map[string]*UserTask{},
+		externalTasks: map[string]*ExternalTask{},
+	}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// URL returns the REST base URL to pass to workflow.NewCamundaClient.
+func (s *Server) URL() string {
+	return s.srv.URL + BasePath
+}
+
+// Client returns a CamundaClient targeting the fake, without retries or circuit breaker.
+func (s *Server) Client() *workflow.CamundaClient {
+	return workflow.NewCamundaClient(workflow.ClientConfig{BaseURL: s.URL()})
+}
+
+// RequireAuthorization makes the fake answer 401 to requests whose Authorization header differs
+// from value. An empty value disables the check.
+func (s *Server) RequireAuthorization(value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.authorization = value
+}
+
+// Close releases hanging requests and shuts the server down.
+func (s *Server) Close() {
+	s.once.Do(func() { close(s.closed) })
+	s.srv.Close()
+}
+
+// SetClock replaces the clock used for lock expiry, due dates and timestamps.
+func (s *Server) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+// Fault makes matching requests misbehave instead of, or before, being handled.
+type Fault struct {
+	// Method and Path select the requests; an empty Method matches any method and Path is a
+	// prefix of the path below BasePath, e.g. "/external-task/fetchAndLock".
+	Method string
+	Path   string
+	// Delay is added before the request is answered.
+	Delay time.Duration
+	// Hang keeps the request open until the client gives up or the server is closed,
+	// simulating an engine that stopped responding.
+	Hang bool
+	// Status, when set, is returned instead of handling the request. Type, Message and Code fill
+	// a Camunda exception body; without them the body is plain text, as from a failing proxy.
+	Status  int
+	Type    string
+	Message string
+	Code    int
+	// Times limits how many requests the fault applies to; zero means until cleared.
+	Times int
+}
+
+// Inject registers a fault. Faults are matched in the order they were injected.
+func (s *Server) Inject(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults removes all injected faults.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// Request is a request received by the fake.
+type Request struct {
+	Method string
+	// Path is relative to BasePath.
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   []byte
+	At     time.Time
+}
+
+// JSON decodes the request body into v.
+func (r Request) JSON(v any) error {
+	return json.Unmarshal(r.Body, v)
+}
+
+// Requests returns every request received so far, including the ones answered by a fault.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// RequestsTo returns the recorded requests with the method (any when empty) whose path starts
+// with the prefix.
+func (s *Server) RequestsTo(method, pathPrefix string) []Request {
+	var out []Request
+	for _, r := range s.Requests() {
+		if (method == "" || r.Method == method) && strings.HasPrefix(r.Path, pathPrefix) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// ResetRequests forgets the recorded requests.
+func (s *Server) ResetRequests() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	path := strings.TrimPrefix(r.URL.Path, BasePath)
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{
+		Method: r.Method,
+		Path:   path,
+		Query:  r.URL.Query(),
+		Header: r.Header.Clone(),
+		Body:   body,
+		At:     s.now(),
+	})
+	fault := s.matchFault(r.Method, path)
+	authorized := s.authorization == "" || r.Header.Get("Authorization") == s.authorization
+	s.mu.Unlock()
+
+	if fault != nil {
+		if fault.Delay > 0 {
+			select {
+			case <-time.After(fault.Delay):
+			case <-r.Context().Done():
+				return
+			case <-s.closed:
+				return
+			}
+		}
+		if fault.Hang {
+			select {
+			case <-r.Context().Done():
+			case <-s.closed:
+			}
+			return
+		}
+		if fault.Status != 0 {
+			if fault.Type == "" && fault.Message == "" && fault.Code == 0 {
+				http.Error(w, http.StatusText(fault.Status), fault.Status)
+				return
+			}
+			writeJSON(w, fault.Status, map[string]any{"type": fault.Type, "message": fault.Message, "code": fault.Code})
+			return
+		}
+	}
+	if !authorized {
+		writeError(w, http.StatusUnauthorized, "AuthenticationException", "missing or invalid credentials")
+		return
+	}
+	if !strings.HasPrefix(r.URL.Path, BasePath+"/") {
+		writeError(w, http.StatusNotFound, "NotFoundException", "unknown path "+r.URL.Path)
+		return
+	}
+	s.route(w, r, path)
+}
+
+// matchFault returns the first matching fault and consumes one of its uses. Callers hold s.mu.
+func (s *Server) matchFault(method, path string) *Fault {
+	for i, f := range s.faults {
+		if (f.Method != "" && f.Method != method) || !strings.HasPrefix(path, f.Path) {
+			continue
+		}
+		matched := *f
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+		return &matched
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError answers with the error body Camunda uses for REST exceptions.
+func writeError(w http.ResponseWriter, status int, typ, message string) {
+	if typ == "" {
+		typ = "RestException"
+	}
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	writeJSON(w, status, map[string]any{"type": typ, "message": message})
+}
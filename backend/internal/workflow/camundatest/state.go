@@ -0,0 +1,383 @@
+package camundatest
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/example/pflow/backend/internal/workflow"
+	"github.com/example/pflow/backend/internal/workflow/variables"
+)
+
+// Process instance states as reported by the history API.
+const (
+	StateActive               = "ACTIVE"
+	StateCompleted            = "COMPLETED"
+	StateExternallyTerminated = "EXTERNALLY_TERMINATED"
+)
+
+func cloneVariables(v variables.Map) variables.Map {
+	out := make(variables.Map, len(v))
+	for k, val := range v {
+		out[k] = val
+	}
+	return out
+}
+
+// Instance is a process instance held by the fake.
+type Instance struct {
+	ID            string
+	DefinitionID  string
+	DefinitionKey string
+	BusinessKey   string
+	TenantID      string
+	Variables     variables.Map
+	State         string
+	StartTime     time.Time
+	EndTime       *time.Time
+	// Activities lists the tasks the instance has passed through, oldest first.
+	Activities []workflow.HistoricActivityInstance
+}
+
+// Ended reports whether the instance has completed or was deleted.
+func (i Instance) Ended() bool {
+	return i.State != StateActive
+}
+
+func (i *Instance) clone() Instance {
+	out := *i
+	out.Variables = cloneVariables(i.Variables)
+	out.Activities = append([]workflow.HistoricActivityInstance(nil), i.Activities...)
+	return out
+}
+
+// UserTask is an open user task. CandidateUsers and CandidateGroups drive the candidate filters
+// of task queries.
+type UserTask struct {
+	workflow.Task
+	CandidateUsers  []string
+	CandidateGroups []string
+
+	seq int
+}
+
+// ExternalTask is an open external task waiting to be fetched by a worker.
+type ExternalTask struct {
+	ID                string
+	ProcessInstanceID string
+	ActivityID        string
+	TopicName         string
+	Priority          int64
+	// Retries is nil until a worker reports a failure.
+	Retries        *int
+	ErrorMessage   string
+	WorkerID       string
+	LockExpiration time.Time
+	// AvailableAt delays fetching after a failure with a retry timeout.
+	AvailableAt time.Time
+
+	seq int
+}
+
+// Message is a correlation request received on /message.
+type Message struct {
+	Name              string
+	BusinessKey       string
+	ProcessInstanceID string
+	Variables         variables.Map
+	All               bool
+}
+
+type definition struct {
+	workflow.ProcessDefinition
+	xml []byte
+}
+
+// OnStart registers a hook run after a process instance is started, before the start request is
+// answered. Use it to create the first tasks of the instance.
+func (s *Server) OnStart(fn func(Instance)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onStart = fn
+}
+
+// OnTaskComplete registers a hook run after a user task is completed with the submitted variables.
+func (s *Server) OnTaskComplete(fn func(UserTask, variables.Map)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onTaskComplete = fn
+}
+
+// OnExternalTaskComplete registers a hook run after an external task is completed with the
+// submitted variables.
+func (s *Server) OnExternalTaskComplete(fn func(ExternalTask, variables.Map)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onExternalTaskComplete = fn
+}
+
+// OnMessage registers a hook run for every correlated message. Returning an error answers the
+// request with 400 as Camunda does for mismatching correlations.
+func (s *Server) OnMessage(fn func(Message) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onMessage = fn
+}
+
+// AddDefinition registers a new version of a shared process definition without deploying BPMN.
+func (s *Server) AddDefinition(key string) workflow.ProcessDefinition {
+	return s.AddTenantDefinition(key, "")
+}
+
+// AddTenantDefinition registers a new version of a tenant's process definition without deploying BPMN.
+func (s *Server) AddTenantDefinition(key, tenantID string) workflow.ProcessDefinition {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addDefinition(key, tenantID, key, "", "", nil).ProcessDefinition
+}
+
+// Definitions returns all deployed process definitions, oldest first.
+func (s *Server) Definitions() []workflow.ProcessDefinition {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]workflow.ProcessDefinition, 0, len(s.definitions))
+	for _, d := range s.definitions {
+		out = append(out, d.ProcessDefinition)
+	}
+	return out
+}
+
+// Instance returns a snapshot of the process instance.
+func (s *Server) Instance(id string) (Instance, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inst, ok := s.instances[id]
+	if !ok {
+		return Instance{}, false
+	}
+	return inst.clone(), true
+}
+
+// Instances returns snapshots of all process instances, including ended ones.
+func (s *Server) Instances() []Instance {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Instance, 0, len(s.instances))
+	for _, inst := range s.sortedInstances() {
+		out = append(out, inst.clone())
+	}
+	return out
+}
+
+// SetVariable sets a process variable on a running instance.
+func (s *Server) SetVariable(instanceID, name string, value any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inst, ok := s.instances[instanceID]
+	if !ok {
+		return fmt.Errorf("process instance %s not found", instanceID)
+	}
+	v, err := variables.Of(value)
+	if err != nil {
+		return err
+	}
+	inst.Variables[name] = v
+	return nil
+}
+
+// CompleteInstance ends a running instance as if it reached an end event, dropping its open tasks.
+func (s *Server) CompleteInstance(instanceID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inst, ok := s.instances[instanceID]
+	if !ok {
+		return fmt.Errorf("process instance %s not found", instanceID)
+	}
+	s.endInstance(inst, StateCompleted)
+	return nil
+}
+
+// ReachEndEvent completes the instance through the given end event, which is recorded in its
+// activity history.
+func (s *Server) ReachEndEvent(instanceID, activityID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inst, ok := s.instances[instanceID]
+	if !ok || inst.Ended() {
+		return fmt.Errorf("process instance %s is not running", instanceID)
+	}
+	id := s.nextID("activity")
+	s.endInstance(inst, StateCompleted)
+	s.startActivity(inst, id, activityID, activityID, "noneEndEvent", "")
+	s.endActivity(inst.ID, id, false)
+	return nil
+}
+
+// AddUserTask opens a user task on a running instance. ID, Created and ProcessInstanceID are
+// filled in.
+func (s *Server) AddUserTask(instanceID string, task UserTask) (UserTask, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inst, ok := s.instances[instanceID]
+	if !ok || inst.Ended() {
+		return UserTask{}, fmt.Errorf("process instance %s is not running", instanceID)
+	}
+	task.ID = s.nextID("task")
+	task.seq = s.seq
+	task.ProcessInstanceID = instanceID
+	task.TenantID = inst.TenantID
+	task.Created = workflow.FormatTime(s.now())
+	if task.Name == "" {
+		task.Name = task.TaskDefinitionKey
+	}
+	s.userTasks[task.ID] = &task
+	s.startActivity(inst, task.ID, task.TaskDefinitionKey, task.Name, "userTask", task.Assignee)
+	return task, nil
+}
+
+// UserTasks returns the open user tasks.
+func (s *Server) UserTasks() []UserTask {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]UserTask, 0, len(s.userTasks))
+	for _, t := range s.sortedUserTasks() {
+		out = append(out, *t)
+	}
+	return out
+}
+
+// AddExternalTask opens an external task on a running instance. ID and ProcessInstanceID are
+// filled in.
+func (s *Server) AddExternalTask(instanceID string, task ExternalTask) (ExternalTask, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inst, ok := s.instances[instanceID]
+	if !ok || inst.Ended() {
+		return ExternalTask{}, fmt.Errorf("process instance %s is not running", instanceID)
+	}
+	task.ID = s.nextID("external-task")
+	task.seq = s.seq
+	task.ProcessInstanceID = instanceID
+	s.externalTasks[task.ID] = &task
+	s.startActivity(inst, task.ID, task.ActivityID, task.ActivityID, "serviceTask", "")
+	return task, nil
+}
+
+// ExternalTasks returns the open external tasks.
+func (s *Server) ExternalTasks() []ExternalTask {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]ExternalTask, 0, len(s.externalTasks))
+	for _, t := range s.externalTasks {
+		out = append(out, *t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].seq < out[j].seq })
+	return out
+}
+
+// Incidents returns the incidents raised when external tasks ran out of retries.
+func (s *Server) Incidents() []workflow.Incident {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]workflow.Incident(nil), s.incidents...)
+}
+
+// The helpers below expect s.mu to be held.
+
+func (s *Server) nextID(prefix string) string {
+	s.seq++
+	return fmt.Sprintf("%s-%d", prefix, s.seq)
+}
+
+// addDefinition adds the next version of a definition; tenants version their definitions independently.
+func (s *Server) addDefinition(key, tenantID, name, versionTag, resource string, xml []byte) *definition {
+	version := 1
+	for _, d := range s.definitions {
+		if d.Key == key && d.TenantID == tenantID && d.Version >= version {
+			version = d.Version + 1
+		}
+	}
+	def := &definition{
+		ProcessDefinition: workflow.ProcessDefinition{
+			ID:         fmt.Sprintf("%s:%d:%s", key, version, s.nextID("def")),
+			Key:        key,
+			Name:       name,
+			Version:    version,
+			VersionTag: versionTag,
+			Resource:   resource,
+			TenantID:   tenantID,
+		},
+		xml: xml,
+	}
+	s.definitions = append(s.definitions, def)
+	return def
+}
+
+func (s *Server) latestDefinition(key, tenantID string) *definition {
+	var latest *definition
+	for _, d := range s.definitions {
+		if d.Key == key && d.TenantID == tenantID && (latest == nil || d.Version > latest.Version) {
+			latest = d
+		}
+	}
+	return latest
+}
+
+func (s *Server) definitionByID(id string) *definition {
+	for _, d := range s.definitions {
+		if d.ID == id {
+			return d
+		}
+	}
+	return nil
+}
+
+func (s *Server) startActivity(inst *Instance, id, activityID, name, typ, assignee string) {
+	inst.Activities = append(inst.Activities, workflow.HistoricActivityInstance{
+		ID:                id,
+		ProcessInstanceID: inst.ID,
+		TenantID:          inst.TenantID,
+		ActivityID:        activityID,
+		ActivityName:      name,
+		ActivityType:      typ,
+		Assignee:          assignee,
+		StartTime:         workflow.FormatTime(s.now()),
+	})
+}
+
+func (s *Server) endActivity(instanceID, id string, canceled bool) {
+	inst, ok := s.instances[instanceID]
+	if !ok {
+		return
+	}
+	for i := range inst.Activities {
+		a := &inst.Activities[i]
+		if a.ID == id && a.EndTime == nil {
+			end := workflow.FormatTime(s.now())
+			a.EndTime = &end
+			a.Canceled = canceled
+		}
+	}
+}
+
+func (s *Server) endInstance(inst *Instance, state string) {
+	if inst.Ended() {
+		return
+	}
+	canceled := state != StateCompleted
+	for id, t := range s.userTasks {
+		if t.ProcessInstanceID == inst.ID {
+			s.endActivity(inst.ID, id, canceled)
+			delete(s.userTasks, id)
+		}
+	}
+	for id, t := range s.externalTasks {
+		if t.ProcessInstanceID == inst.ID {
+			s.endActivity(inst.ID, id, canceled)
+			delete(s.externalTasks, id)
+		}
+	}
+	end := s.now()
+	inst.State = state
+	inst.EndTime = &end
+}
@@ -0,0 +1,86 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	// ErrNotFound is returned when the engine reports that the requested resource does not exist.
+	ErrNotFound = errors.New("camunda resource not found")
+	// ErrCircuitOpen is returned without contacting the engine while the circuit breaker is open.
+	ErrCircuitOpen = errors.New("camunda circuit breaker is open")
+)
+
+// ErrorCodeOptimisticLocking is the built-in Camunda error code for optimistic locking failures.
+const ErrorCodeOptimisticLocking = 1
+
+// CamundaError is a failed REST call, decoded from the exception body Camunda returns.
+type CamundaError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	// Type is the simple class name of the engine exception, e.g. "BadUserRequestException".
+	Type    string
+	Message string
+	// Code is the engine error code; zero when the engine did not provide one.
+	Code int
+}
+
+func (e *CamundaError) Error() string {
+	if e.Type == "" && e.Message == "" {
+		return fmt.Sprintf("camunda %s %s failed: %d %s", e.Method, e.Path, e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("camunda %s %s failed: %d %s: %s", e.Method, e.Path, e.StatusCode, e.Type, e.Message)
+}
+
+// Is lets errors.Is(err, ErrNotFound) match 404 responses.
+func (e *CamundaError) Is(target error) bool {
+	return target == ErrNotFound && e.StatusCode == http.StatusNotFound
+}
+
+// engineUnavailable reports whether the response indicates the engine itself is failing rather
+// than rejecting the request; exceptions raised by the engine carry a type.
+func (e *CamundaError) engineUnavailable() bool {
+	switch e.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return e.StatusCode >= 500 && e.Type == ""
+}
+
+// AsCamundaError returns the CamundaError in err's chain, if any.
+func AsCamundaError(err error) (*CamundaError, bool) {
+	var ce *CamundaError
+	ok := errors.As(err, &ce)
+	return ce, ok
+}
+
+// IsNotFound reports whether the engine answered that the resource does not exist.
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// IsOptimisticLocking reports whether the call failed because a concurrent transaction changed
+// the same entity; such calls can usually be retried after reloading.
+func IsOptimisticLocking(err error) bool {
+	ce, ok := AsCamundaError(err)
+	return ok && (ce.Type == "OptimisticLockingException" || ce.Code == ErrorCodeOptimisticLocking)
+}
+
+// IsBadUserRequest reports whether the engine rejected the request as invalid, e.g. an unknown
+// variable type or a task that cannot be completed in its current state.
+func IsBadUserRequest(err error) bool {
+	ce, ok := AsCamundaError(err)
+	return ok && (ce.StatusCode == http.StatusBadRequest || ce.Type == "BadUserRequestException")
+}
+
+// connError marks failures to reach the engine or read its response.
+type connError struct {
+	err error
+}
+
+func (e *connError) Error() string { return e.err.Error() }
+
+func (e *connError) Unwrap() error { return e.err }
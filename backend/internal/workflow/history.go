@@ -0,0 +1,203 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ActivityInstance is a node of the runtime activity instance tree of a process instance.
+type ActivityInstance struct {
+	ID                       string               `json:"id"`
+	ActivityID               string               `json:"activityId"`
+	ActivityName             string               `json:"activityName"`
+	ActivityType             string               `json:"activityType"`
+	ChildActivityInstances   []ActivityInstance   `json:"childActivityInstances"`
+	ChildTransitionInstances []TransitionInstance `json:"childTransitionInstances"`
+	IncidentIDs              []string             `json:"incidentIds"`
+}
+
+// TransitionInstance is an execution waiting in an asynchronous continuation.
+type TransitionInstance struct {
+	ID         string `json:"id"`
+	ActivityID string `json:"activityId"`
+}
+
+// ActiveActivityIDs flattens the tree into the ids of activities that currently hold a token.
+func (a *ActivityInstance) ActiveActivityIDs() []string {
+	var ids []string
+	var walk func(node *ActivityInstance)
+	walk = func(node *ActivityInstance) {
+		for i := range node.ChildActivityInstances {
+			child := &node.ChildActivityInstances[i]
+			ids = append(ids, child.ActivityID)
+			walk(child)
+		}
+		for _, t := range node.ChildTransitionInstances {
+			ids = append(ids, t.ActivityID)
+		}
+	}
+	walk(a)
+	return ids
+}
+
+// HistoricActivityInstance mirrors a Camunda history activity instance.
+type HistoricActivityInstance struct {
+	ID                string  `json:"id"`
+	ProcessInstanceID string  `json:"processInstanceId"`
+	TenantID          string  `json:"tenantId"`
+	ActivityID        string  `json:"activityId"`
+	ActivityName      string  `json:"activityName"`
+	ActivityType      string  `json:"activityType"`
+	Assignee          string  `json:"assignee"`
+	StartTime         string  `json:"startTime"`
+	EndTime           *string `json:"endTime"`
+	Canceled          bool    `json:"canceled"`
+}
+
+// Completed reports whether the activity finished without being cancelled.
+func (h HistoricActivityInstance) Completed() bool {
+	return h.EndTime != nil && !h.Canceled
+}
+
+// HistoricProcessInstance mirrors a Camunda history process instance.
+type HistoricProcessInstance struct {
+	ID                       string  `json:"id"`
+	BusinessKey              string  `json:"businessKey"`
+	ProcessDefinitionID      string  `json:"processDefinitionId"`
+	ProcessDefinitionKey     string  `json:"processDefinitionKey"`
+	ProcessDefinitionVersion int     `json:"processDefinitionVersion"`
+	StartTime                string  `json:"startTime"`
+	EndTime                  *string `json:"endTime"`
+	State                    string  `json:"state"`
+}
+
+// Incident mirrors a Camunda incident.
+type Incident struct {
+	ID                string `json:"id"`
+	ProcessInstanceID string `json:"processInstanceId"`
+	ActivityID        string `json:"activityId"`
+	IncidentType      string `json:"incidentType"`
+	IncidentMessage   string `json:"incidentMessage"`
+	IncidentTimestamp string `json:"incidentTimestamp"`
+	Configuration     string `json:"configuration"`
+}
+
+// GetProcessDefinitionXML returns the BPMN 2.0 XML of a deployed process definition.
+func (c *CamundaClient) GetProcessDefinitionXML(ctx context.Context, definitionID string) ([]byte, error) {
+	var result struct {
+		BPMN20XML string `json:"bpmn20Xml"`
+	}
+	if err := c.doJSON(ctx, http.MethodGet, "/process-definition/"+url.PathEscape(definitionID)+"/xml", nil, &result); err != nil {
+		return nil, err
+	}
+	return []byte(result.BPMN20XML), nil
+}
+
+// GetActivityInstanceTree returns the runtime activity tree, or nil when the instance has ended.
+func (c *CamundaClient) GetActivityInstanceTree(ctx context.Context, processInstanceID string) (*ActivityInstance, error) {
+	var tree ActivityInstance
+	err := c.doJSON(ctx, http.MethodGet, "/process-instance/"+url.PathEscape(processInstanceID)+"/activity-instances", nil, &tree)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tree, nil
+}
+
+// ListHistoricActivityInstances returns the activities a process instance has passed through, oldest first.
+func (c *CamundaClient) ListHistoricActivityInstances(ctx context.Context, processInstanceID string) ([]HistoricActivityInstance, error) {
+	query := url.Values{"processInstanceId": {processInstanceID}, "sortBy": {"startTime"}, "sortOrder": {"asc"}}
+	var items []HistoricActivityInstance
+	if err := c.doJSON(ctx, http.MethodGet, "/history/activity-instance?"+query.Encode(), nil, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// HistoricActivityQuery selects history activity instances across process instances.
+type HistoricActivityQuery struct {
+	// StartedAfter keeps activities started at or after the time.
+	StartedAfter time.Time
+	FirstResult  int
+	MaxResults   int
+}
+
+// QueryHistoricActivityInstances returns one page of history activity instances, oldest first.
+func (c *CamundaClient) QueryHistoricActivityInstances(ctx context.Context, q HistoricActivityQuery) ([]HistoricActivityInstance, error) {
+	query := url.Values{"sortBy": {"startTime"}, "sortOrder": {"asc"}}
+	if !q.StartedAfter.IsZero() {
+		query.Set("startedAfter", FormatTime(q.StartedAfter))
+	}
+	if q.FirstResult > 0 {
+		query.Set("firstResult", strconv.Itoa(q.FirstResult))
+	}
+	if q.MaxResults > 0 {
+		query.Set("maxResults", strconv.Itoa(q.MaxResults))
+	}
+	var items []HistoricActivityInstance
+	if err := c.doJSON(ctx, http.MethodGet, "/history/activity-instance?"+query.Encode(), nil, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// GetHistoricProcessInstance loads a process instance from history, including ended ones.
+func (c *CamundaClient) GetHistoricProcessInstance(ctx context.Context, processInstanceID string) (*HistoricProcessInstance, error) {
+	var instance HistoricProcessInstance
+	if err := c.doJSON(ctx, http.MethodGet, "/history/process-instance/"+url.PathEscape(processInstanceID), nil, &instance); err != nil {
+		return nil, err
+	}
+	return &instance, nil
+}
+
+// ListIncidents returns the open incidents of a process instance, or of all instances when
+// processInstanceID is empty.
+func (c *CamundaClient) ListIncidents(ctx context.Context, processInstanceID string) ([]Incident, error) {
+	query := url.Values{}
+	if processInstanceID != "" {
+		query.Set("processInstanceId", processInstanceID)
+	}
+	return c.queryIncidents(ctx, query)
+}
+
+// GetIncident loads an open incident; it fails with ErrNotFound once the incident is resolved.
+func (c *CamundaClient) GetIncident(ctx context.Context, incidentID string) (*Incident, error) {
+	incidents, err := c.queryIncidents(ctx, url.Values{"incidentId": {incidentID}})
+	if err != nil {
+		return nil, err
+	}
+	if len(incidents) == 0 {
+		return nil, fmt.Errorf("incident %s: %w", incidentID, ErrNotFound)
+	}
+	return &incidents[0], nil
+}
+
+func (c *CamundaClient) queryIncidents(ctx context.Context, query url.Values) ([]Incident, error) {
+	var incidents []Incident
+	if err := c.doJSON(ctx, http.MethodGet, "/incident?"+query.Encode(), nil, &incidents); err != nil {
+		return nil, err
+	}
+	return incidents, nil
+}
+
+// RetryIncident gives the failed external task or job behind an incident new retries, which
+// resolves the incident and lets the engine run the activity again.
+func (c *CamundaClient) RetryIncident(ctx context.Context, incident Incident, retries int) error {
+	var path string
+	switch incident.IncidentType {
+	case "failedExternalTask":
+		path = "/external-task/" + url.PathEscape(incident.Configuration) + "/retries"
+	case "failedJob":
+		path = "/job/" + url.PathEscape(incident.Configuration) + "/retries"
+	default:
+		return fmt.Errorf("incident %s of type %s cannot be retried", incident.ID, incident.IncidentType)
+	}
+	return c.doJSON(ctx, http.MethodPut, path, map[string]any{"retries": retries}, nil)
+}
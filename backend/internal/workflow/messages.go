@@ -0,0 +1,61 @@
+package workflow
+
+import (
+	"context"
+	"net/http"
+)
+
+// MessageCorrelation describes a BPMN message delivered to waiting catch events or receive tasks.
+type MessageCorrelation struct {
+	MessageName       string
+	BusinessKey       string
+	ProcessInstanceID string
+	Variables         map[string]any
+	// All correlates the message with every matching execution instead of exactly one.
+	All bool
+}
+
+// MessageCorrelationResult mirrors the Camunda response returned when resultEnabled is set.
+type MessageCorrelationResult struct {
+	ResultType string `json:"resultType"`
+	Execution  *struct {
+		ID                string `json:"id"`
+		ProcessInstanceID string `json:"processInstanceId"`
+	} `json:"execution,omitempty"`
+	ProcessInstance *struct {
+		ID          string `json:"id"`
+		BusinessKey string `json:"businessKey"`
+	} `json:"processInstance,omitempty"`
+}
+
+// ProcessInstanceID returns the process instance that received the message.
+func (r MessageCorrelationResult) ProcessInstanceID() string {
+	if r.ProcessInstance != nil {
+		return r.ProcessInstance.ID
+	}
+	if r.Execution != nil {
+		return r.Execution.ProcessInstanceID
+	}
+	return ""
+}
+
+// CorrelateMessage delivers a message to the process instances matching the correlation keys.
+func (c *CamundaClient) CorrelateMessage(ctx context.Context, msg MessageCorrelation) ([]MessageCorrelationResult, error) {
+	payload := map[string]any{
+		"messageName":      msg.MessageName,
+		"processVariables": typedVariables(msg.Variables),
+		"resultEnabled":    true,
+		"all":              msg.All,
+	}
+	if msg.BusinessKey != "" {
+		payload["businessKey"] = msg.BusinessKey
+	}
+	if msg.ProcessInstanceID != "" {
+		payload["processInstanceId"] = msg.ProcessInstanceID
+	}
+	var results []MessageCorrelationResult
+	if err := c.doJSON(ctx, http.MethodPost, "/message", payload, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
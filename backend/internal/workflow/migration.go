@@ -0,0 +1,131 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// MigrationPlan mirrors the Camunda migration plan representation.
+type MigrationPlan struct {
+	SourceProcessDefinitionID string                 `json:"sourceProcessDefinitionId"`
+	TargetProcessDefinitionID string                 `json:"targetProcessDefinitionId"`
+	Instructions              []MigrationInstruction `json:"instructions"`
+}
+
+// MigrationInstruction maps activities of the source definition onto the target definition.
+type MigrationInstruction struct {
+	SourceActivityIDs  []string `json:"sourceActivityIds"`
+	TargetActivityIDs  []string `json:"targetActivityIds"`
+	UpdateEventTrigger bool     `json:"updateEventTrigger"`
+}
+
+// MigrationPlanReport lists the problems Camunda found while validating a plan.
+type MigrationPlanReport struct {
+	InstructionReports []struct {
+		Instruction MigrationInstruction `json:"instruction"`
+		Failures    []string             `json:"failures"`
+	} `json:"instructionReports"`
+}
+
+// Failures flattens the instruction failures into readable messages.
+func (r *MigrationPlanReport) Failures() []string {
+	var out []string
+	for _, report := range r.InstructionReports {
+		for _, failure := range report.Failures {
+			out = append(out, strings.Join(report.Instruction.SourceActivityIDs, ",")+": "+failure)
+		}
+	}
+	return out
+}
+
+// MigrationExecution selects the process instances a plan is applied to.
+type MigrationExecution struct {
+	Plan                *MigrationPlan
+	ProcessInstanceIDs  []string
+	SkipCustomListeners bool
+	SkipIoMappings      bool
+}
+
+// Batch mirrors the Camunda batch returned by asynchronous operations.
+type Batch struct {
+	ID        string `json:"id"`
+	Type      string `json:"type"`
+	TotalJobs int    `json:"totalJobs"`
+}
+
+// GenerateMigrationPlan asks Camunda to map equal activities between two process definitions.
+func (c *CamundaClient) GenerateMigrationPlan(ctx context.Context, sourceDefinitionID, targetDefinitionID string, updateEventTriggers bool) (*MigrationPlan, error) {
+	payload := map[string]any{
+		"sourceProcessDefinitionId": sourceDefinitionID,
+		"targetProcessDefinitionId": targetDefinitionID,
+		"updateEventTriggers":       updateEventTriggers,
+	}
+	var plan MigrationPlan
+	if err := c.queryJSON(ctx, "/migration/generate", payload, &plan); err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// ValidateMigrationPlan checks the plan against both definitions without touching any instance.
+func (c *CamundaClient) ValidateMigrationPlan(ctx context.Context, plan *MigrationPlan) (*MigrationPlanReport, error) {
+	var report MigrationPlanReport
+	if err := c.queryJSON(ctx, "/migration/validate", plan, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// ExecuteMigration migrates the selected instances synchronously; it fails as a whole if any instance cannot be migrated.
+func (c *CamundaClient) ExecuteMigration(ctx context.Context, exec MigrationExecution) error {
+	return c.doJSON(ctx, http.MethodPost, "/migration/execute", migrationPayload(exec), nil)
+}
+
+// ExecuteMigrationAsync schedules the migration as a Camunda batch.
+func (c *CamundaClient) ExecuteMigrationAsync(ctx context.Context, exec MigrationExecution) (*Batch, error) {
+	var batch Batch
+	if err := c.doJSON(ctx, http.MethodPost, "/migration/executeAsync", migrationPayload(exec), &batch); err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// GetBatch loads a running batch. Camunda removes batches once all their jobs ran, so a finished
+// batch reads as ErrNotFound.
+func (c *CamundaClient) GetBatch(ctx context.Context, id string) (*Batch, error) {
+	var batch Batch
+	if err := c.doJSON(ctx, http.MethodGet, "/batch/"+url.PathEscape(id), nil, &batch); err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// GetProcessInstance loads a running process instance by id.
+func (c *CamundaClient) GetProcessInstance(ctx context.Context, id string) (*ProcessInstance, error) {
+	var instance ProcessInstance
+	if err := c.doJSON(ctx, http.MethodGet, "/process-instance/"+url.PathEscape(id), nil, &instance); err != nil {
+		return nil, err
+	}
+	return &instance, nil
+}
+
+// DeleteProcessInstance cancels a running process instance. Missing instances are not an error.
+func (c *CamundaClient) DeleteProcessInstance(ctx context.Context, id string) error {
+	err := c.doJSON(ctx, http.MethodDelete, "/process-instance/"+url.PathEscape(id)+"?failIfNotExists=false", nil, nil)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+func migrationPayload(exec MigrationExecution) map[string]any {
+	return map[string]any{
+		"migrationPlan":       exec.Plan,
+		"processInstanceIds":  exec.ProcessInstanceIDs,
+		"skipCustomListeners": exec.SkipCustomListeners,
+		"skipIoMappings":      exec.SkipIoMappings,
+	}
+}
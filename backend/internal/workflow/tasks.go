@@ -0,0 +1,138 @@
+package workflow
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Task mirrors a Camunda user task.
+type Task struct {
+	ID                string  `json:"id"`
+	Name              string  `json:"name"`
+	Assignee          string  `json:"assignee"`
+	Owner             string  `json:"owner"`
+	Created           string  `json:"created"`
+	Due               *string `json:"due"`
+	Priority          int     `json:"priority"`
+	DelegationState   string  `json:"delegationState"`
+	ProcessInstanceID string  `json:"processInstanceId"`
+	TaskDefinitionKey string  `json:"taskDefinitionKey"`
+	TenantID          string  `json:"tenantId,omitempty"`
+}
+
+// TaskQuery filters user tasks; zero-valued fields are ignored.
+type TaskQuery struct {
+	ProcessInstanceID    string   `json:"processInstanceId,omitempty"`
+	TaskDefinitionKey    string   `json:"taskDefinitionKey,omitempty"`
+	Assignee             string   `json:"assignee,omitempty"`
+	CandidateUser        string   `json:"candidateUser,omitempty"`
+	CandidateGroups      []string `json:"candidateGroups,omitempty"`
+	IncludeAssignedTasks bool     `json:"includeAssignedTasks,omitempty"`
+	Unassigned           bool     `json:"unassigned,omitempty"`
+	DelegationState      string   `json:"delegationState,omitempty"`
+	TenantIDIn           []string `json:"tenantIdIn,omitempty"`
+	// DueBefore takes a timestamp formatted with FormatTime.
+	DueBefore string `json:"dueBefore,omitempty"`
+	// OrQueries are OR-ed together and AND-ed with the remaining fields.
+	OrQueries []TaskQuery   `json:"orQueries,omitempty"`
+	Sorting   []TaskSorting `json:"sorting,omitempty"`
+}
+
+// TaskSorting orders task query results, e.g. {SortBy: "dueDate", SortOrder: "asc"}.
+type TaskSorting struct {
+	SortBy    string `json:"sortBy"`
+	SortOrder string `json:"sortOrder"`
+}
+
+// timeLayout is the format of dates in Camunda's REST API.
+const timeLayout = "2006-01-02T15:04:05.000-0700"
+
+// FormatTime renders a timestamp in the format Camunda's REST API expects for date parameters.
+func FormatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+// ParseTime reads a date returned by Camunda's REST API.
+func ParseTime(s string) (time.Time, error) {
+	return time.Parse(timeLayout, s)
+}
+
+// ListTasks returns the user tasks matching the query.
+func (c *CamundaClient) ListTasks(ctx context.Context, query TaskQuery) ([]Task, error) {
+	var tasks []Task
+	if err := c.queryJSON(ctx, "/task", query, &tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// QueryTasks returns one page of the user tasks matching the query.
+func (c *CamundaClient) QueryTasks(ctx context.Context, query TaskQuery, firstResult, maxResults int) ([]Task, error) {
+	params := url.Values{}
+	params.Set("firstResult", strconv.Itoa(firstResult))
+	params.Set("maxResults", strconv.Itoa(maxResults))
+	var tasks []Task
+	if err := c.queryJSON(ctx, "/task?"+params.Encode(), query, &tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// CountTasks returns how many user tasks match the query. Sorting is ignored.
+func (c *CamundaClient) CountTasks(ctx context.Context, query TaskQuery) (int64, error) {
+	query.Sorting = nil
+	var result struct {
+		Count int64 `json:"count"`
+	}
+	if err := c.queryJSON(ctx, "/task/count", query, &result); err != nil {
+		return 0, err
+	}
+	return result.Count, nil
+}
+
+// CompleteTask completes a user task, optionally setting process variables.
+func (c *CamundaClient) CompleteTask(ctx context.Context, taskID string, variables map[string]any) error {
+	return c.doJSON(ctx, http.MethodPost, "/task/"+url.PathEscape(taskID)+"/complete", map[string]any{
+		"variables": typedVariables(variables),
+	}, nil)
+}
+
+// SetTaskAssignee replaces the assignee of a user task regardless of who currently holds it.
+func (c *CamundaClient) SetTaskAssignee(ctx context.Context, taskID, userID string) error {
+	return c.doJSON(ctx, http.MethodPost, "/task/"+url.PathEscape(taskID)+"/assignee", map[string]any{"userId": userID}, nil)
+}
+
+// AddTaskCandidateGroup lets members of an additional group claim a user task.
+func (c *CamundaClient) AddTaskCandidateGroup(ctx context.Context, taskID, groupID string) error {
+	return c.doJSON(ctx, http.MethodPost, "/task/"+url.PathEscape(taskID)+"/identity-links", map[string]any{
+		"groupId": groupID,
+		"type":    "candidate",
+	}, nil)
+}
+
+// ClaimTask makes the user the assignee of an unassigned task. Camunda rejects claims of tasks
+// that already have a different assignee.
+func (c *CamundaClient) ClaimTask(ctx context.Context, taskID, userID string) error {
+	return c.doJSON(ctx, http.MethodPost, "/task/"+url.PathEscape(taskID)+"/claim", map[string]any{"userId": userID}, nil)
+}
+
+// UnclaimTask removes the assignee so the candidates can claim the task again.
+func (c *CamundaClient) UnclaimTask(ctx context.Context, taskID string) error {
+	return c.doJSON(ctx, http.MethodPost, "/task/"+url.PathEscape(taskID)+"/unclaim", map[string]any{}, nil)
+}
+
+// DelegateTask hands the task to another user; the current assignee becomes its owner and gets it
+// back once the delegate resolves it.
+func (c *CamundaClient) DelegateTask(ctx context.Context, taskID, userID string) error {
+	return c.doJSON(ctx, http.MethodPost, "/task/"+url.PathEscape(taskID)+"/delegate", map[string]any{"userId": userID}, nil)
+}
+
+// ResolveTask returns a delegated task to its owner, optionally setting process variables.
+func (c *CamundaClient) ResolveTask(ctx context.Context, taskID string, variables map[string]any) error {
+	return c.doJSON(ctx, http.MethodPost, "/task/"+url.PathEscape(taskID)+"/resolve", map[string]any{
+		"variables": typedVariables(variables),
+	}, nil)
+}
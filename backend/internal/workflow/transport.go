@@ -0,0 +1,162 @@
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// request is a single REST call. Idempotent calls are retried on connection errors and 5xx responses.
+type request struct {
+	method      string
+	path        string
+	contentType string
+	body        []byte
+	idempotent  bool
+}
+
+// doJSON issues a JSON request against the engine and decodes the response into out when provided.
+// Only GET, PUT and DELETE calls are considered idempotent.
+func (c *CamundaClient) doJSON(ctx context.Context, method, path string, payload, out any) error {
+	req := request{method: method, path: path, idempotent: method != http.MethodPost}
+	if payload != nil {
+		raw, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		req.body = raw
+		req.contentType = "application/json"
+	}
+	return c.do(ctx, req, out)
+}
+
+// queryJSON issues a read-only POST, such as a task query, which is safe to retry.
+func (c *CamundaClient) queryJSON(ctx context.Context, path string, payload, out any) error {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, request{method: http.MethodPost, path: path, contentType: "application/json", body: raw, idempotent: true}, out)
+}
+
+// do sends the request, retrying idempotent calls. The circuit breaker sees each call once,
+// with the outcome of its last attempt.
+func (c *CamundaClient) do(ctx context.Context, req request, out any) error {
+	if err := c.breaker.allow(); err != nil {
+		return fmt.Errorf("camunda %s %s: %w", req.method, req.path, err)
+	}
+	err := c.send(ctx, req, out)
+	for attempt := 0; err != nil && req.idempotent && attempt < c.cfg.MaxRetries && retryable(ctx, err); attempt++ {
+		if !sleep(ctx, c.backoff(attempt)) {
+			break
+		}
+		err = c.send(ctx, req, out)
+	}
+	c.breaker.record(classify(ctx, err))
+	return err
+}
+
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (c *CamundaClient) send(ctx context.Context, r request, out any) error {
+	httpReq, err := http.NewRequestWithContext(ctx, r.method, c.baseURL+r.path, bytes.NewReader(r.body))
+	if err != nil {
+		return err
+	}
+	if r.contentType != "" {
+		httpReq.Header.Set("Content-Type", r.contentType)
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	switch {
+	case c.cfg.BearerToken != "":
+		httpReq.Header.Set("Authorization", "Bearer "+c.cfg.BearerToken)
+	case c.cfg.Username != "":
+		httpReq.SetBasicAuth(c.cfg.Username, c.cfg.Password)
+	}
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return &connError{fmt.Errorf("camunda %s %s: %w", r.method, r.path, err)}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return decodeError(r, resp)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode camunda %s %s response: %w", r.method, r.path, err)
+	}
+	return nil
+}
+
+func decodeError(r request, resp *http.Response) error {
+	ce := &CamundaError{Method: r.method, Path: r.path, StatusCode: resp.StatusCode}
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	var body struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+		Code    int    `json:"code"`
+	}
+	if json.Unmarshal(raw, &body) == nil {
+		ce.Type, ce.Message, ce.Code = body.Type, body.Message, body.Code
+	}
+	return ce
+}
+
+// retryable reports whether a failed attempt may succeed when repeated.
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var conn *connError
+	if errors.As(err, &conn) {
+		return true
+	}
+	ce, ok := AsCamundaError(err)
+	return ok && (ce.StatusCode >= 500 || ce.StatusCode == http.StatusTooManyRequests)
+}
+
+// classify maps an attempt's result onto the circuit breaker. Rejections by a healthy engine,
+// including engine exceptions, count as successes.
+func classify(ctx context.Context, err error) outcome {
+	if err == nil {
+		return outcomeSuccess
+	}
+	if ctx.Err() != nil {
+		return outcomeIgnored
+	}
+	var conn *connError
+	if errors.As(err, &conn) {
+		return outcomeFailure
+	}
+	if ce, ok := AsCamundaError(err); ok && ce.engineUnavailable() {
+		return outcomeFailure
+	}
+	return outcomeSuccess
+}
+
+// backoff returns the jittered delay before retry number attempt+1.
+func (c *CamundaClient) backoff(attempt int) time.Duration {
+	d := c.cfg.RetryMaxBackoff
+	if attempt < 16 {
+		d = min(c.cfg.RetryBackoff<<attempt, c.cfg.RetryMaxBackoff)
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
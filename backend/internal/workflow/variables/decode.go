@@ -0,0 +1,213 @@
+package variables
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// IsNull reports whether the variable is missing or null.
+func (v Value) IsNull() bool {
+	return v.Value == nil && v.Type != TypeFile
+}
+
+// String returns string values as is and formats other values; null reads as "".
+func (v Value) String() string {
+	switch x := v.Value.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	default:
+		return fmt.Sprint(x)
+	}
+}
+
+// Bool returns the value of a Boolean variable, also accepting "true" and "false" strings.
+// Anything else reads as false.
+func (v Value) Bool() bool {
+	switch x := v.Value.(type) {
+	case bool:
+		return x
+	case string:
+		b, _ := strconv.ParseBool(x)
+		return b
+	}
+	return false
+}
+
+// Int returns an integral value; Doubles are truncated and anything else reads as 0.
+func (v Value) Int() int64 {
+	i, _ := v.AsInt()
+	return i
+}
+
+// AsInt is Int with an error for values that are not numbers.
+func (v Value) AsInt() (int64, error) {
+	switch x := v.Value.(type) {
+	case json.Number:
+		if i, err := x.Int64(); err == nil {
+			return i, nil
+		}
+		f, err := x.Float64()
+		return int64(f), err
+	case int64:
+		return x, nil
+	case int:
+		return int64(x), nil
+	case float64:
+		return int64(x), nil
+	case string:
+		return strconv.ParseInt(x, 10, 64)
+	}
+	return 0, fmt.Errorf("%s variable is not a number", v.typeName())
+}
+
+// Float returns a numeric value as float64; anything else reads as 0.
+func (v Value) Float() float64 {
+	f, _ := v.AsFloat()
+	return f
+}
+
+// AsFloat is Float with an error for values that are not numbers.
+func (v Value) AsFloat() (float64, error) {
+	switch x := v.Value.(type) {
+	case json.Number:
+		return x.Float64()
+	case float64:
+		return x, nil
+	case int64:
+		return float64(x), nil
+	case int:
+		return float64(x), nil
+	case string:
+		return strconv.ParseFloat(x, 64)
+	}
+	return 0, fmt.Errorf("%s variable is not a number", v.typeName())
+}
+
+// Time returns the value of a Date variable; anything else reads as the zero time.
+func (v Value) Time() time.Time {
+	t, _ := v.AsTime()
+	return t
+}
+
+// AsTime parses Date values and strings in the Camunda date format or RFC 3339.
+func (v Value) AsTime() (time.Time, error) {
+	switch x := v.Value.(type) {
+	case time.Time:
+		return x, nil
+	case string:
+		if t, err := time.Parse(DateFormat, x); err == nil {
+			return t, nil
+		}
+		return time.Parse(time.RFC3339Nano, x)
+	case json.Number:
+		// Dates serialized by the Java object mapper arrive as epoch milliseconds.
+		ms, err := x.Int64()
+		return time.UnixMilli(ms), err
+	}
+	return time.Time{}, fmt.Errorf("%s variable is not a date", v.typeName())
+}
+
+// Bytes returns the content of a Bytes or File variable; anything else reads as nil.
+func (v Value) Bytes() []byte {
+	b, _ := v.AsBytes()
+	return b
+}
+
+// AsBytes decodes the base64 content of Bytes and File variables.
+func (v Value) AsBytes() ([]byte, error) {
+	switch x := v.Value.(type) {
+	case []byte:
+		return x, nil
+	case string:
+		return base64.StdEncoding.DecodeString(x)
+	case nil:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("%s variable is not binary", v.typeName())
+}
+
+// File returns a File variable with the metadata from valueInfo.
+func (v Value) File() (File, error) {
+	data, err := v.AsBytes()
+	if err != nil {
+		return File{}, err
+	}
+	info := func(key string) string {
+		s, _ := v.ValueInfo[key].(string)
+		return s
+	}
+	return File{Name: info("filename"), MimeType: info("mimeType"), Encoding: info("encoding"), Data: data}, nil
+}
+
+// Decode unmarshals a Json or Object variable, or any other JSON-compatible value, into out.
+func (v Value) Decode(out any) error {
+	var raw []byte
+	switch x := v.Value.(type) {
+	case string:
+		if v.Type == TypeJSON || v.Type == TypeObject {
+			raw = []byte(x)
+			break
+		}
+		var err error
+		if raw, err = json.Marshal(x); err != nil {
+			return err
+		}
+	default:
+		var err error
+		if raw, err = json.Marshal(x); err != nil {
+			return err
+		}
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return fmt.Errorf("decode %s variable: %w", v.typeName(), err)
+	}
+	return nil
+}
+
+// Interface returns the value converted to a natural Go type: Date as time.Time, Bytes as
+// []byte, integral numbers as int64, Doubles as float64 and Json/Object as decoded JSON.
+func (v Value) Interface() (any, error) {
+	switch v.Type {
+	case TypeNull:
+		return nil, nil
+	case TypeShort, TypeInteger, TypeLong:
+		return v.AsInt()
+	case TypeDouble:
+		return v.AsFloat()
+	case TypeDate:
+		if v.Value == nil {
+			return nil, nil
+		}
+		return v.AsTime()
+	case TypeBytes:
+		return v.AsBytes()
+	case TypeFile:
+		return v.File()
+	case TypeJSON, TypeObject:
+		if v.Value == nil {
+			return nil, nil
+		}
+		var out any
+		err := v.Decode(&out)
+		return out, err
+	}
+	if n, ok := v.Value.(json.Number); ok {
+		if i, err := n.Int64(); err == nil {
+			return i, nil
+		}
+		return n.Float64()
+	}
+	return v.Value, nil
+}
+
+func (v Value) typeName() string {
+	if v.Type == "" {
+		return "untyped"
+	}
+	return v.Type
+}
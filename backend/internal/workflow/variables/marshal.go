@@ -0,0 +1,252 @@
+package variables
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	valueType = reflect.TypeOf(Value{})
+	fileType  = reflect.TypeOf(File{})
+	bytesType = reflect.TypeOf([]byte(nil))
+)
+
+type fieldSpec struct {
+	name      string
+	index     []int
+	omitEmpty bool
+	forceJSON bool
+}
+
+// Marshal converts the exported fields of a struct into variables. A field is named by its
+// `camunda` tag, or by its Go name with a lower-case first letter. Tag options:
+//
+//	camunda:"-"              skip the field
+//	camunda:"name,omitempty" skip zero values
+//	camunda:"name,json"      send as Json even when Of would choose another type
+//
+// Embedded structs without a tag are flattened.
+func Marshal(v any) (Map, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return Map{}, nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("marshal variables: %T is not a struct", v)
+	}
+	out := Map{}
+	for _, f := range fields(rv.Type()) {
+		fv, ok := fieldByIndex(rv, f.index)
+		if !ok || (f.omitEmpty && fv.IsZero()) {
+			continue
+		}
+		var typed Value
+		var err error
+		if f.forceJSON {
+			typed, err = Of(JSON{V: fv.Interface()})
+		} else {
+			typed, err = Of(fv.Interface())
+		}
+		if err != nil {
+			return nil, fmt.Errorf("variable %s: %w", f.name, err)
+		}
+		out[f.name] = typed
+	}
+	return out, nil
+}
+
+// Unmarshal fills the fields of the struct out points to from the variables, using the same
+// naming as Marshal. Fields without a matching variable are left untouched.
+func Unmarshal(m Map, out any) error {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("unmarshal variables: %T is not a pointer to a struct", out)
+	}
+	rv = rv.Elem()
+	for _, f := range fields(rv.Type()) {
+		v, ok := m[f.name]
+		if !ok {
+			continue
+		}
+		fv := rv
+		for _, i := range f.index {
+			if fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					fv.Set(reflect.New(fv.Type().Elem()))
+				}
+				fv = fv.Elem()
+			}
+			fv = fv.Field(i)
+		}
+		var err error
+		if f.forceJSON && !v.IsNull() {
+			err = v.Decode(fv.Addr().Interface())
+		} else {
+			err = assign(fv, v)
+		}
+		if err != nil {
+			return fmt.Errorf("variable %s: %w", f.name, err)
+		}
+	}
+	return nil
+}
+
+func assign(fv reflect.Value, v Value) error {
+	t := fv.Type()
+	switch {
+	case t == valueType:
+		fv.Set(reflect.ValueOf(v))
+		return nil
+	case t.Kind() == reflect.Pointer:
+		if v.IsNull() {
+			fv.Set(reflect.Zero(t))
+			return nil
+		}
+		elem := reflect.New(t.Elem())
+		if err := assign(elem.Elem(), v); err != nil {
+			return err
+		}
+		fv.Set(elem)
+		return nil
+	case t.ConvertibleTo(timeType) && t.Kind() == reflect.Struct:
+		if v.IsNull() {
+			fv.Set(reflect.Zero(t))
+			return nil
+		}
+		tm, err := v.AsTime()
+		if err != nil {
+			return err
+		}
+		fv.Set(reflect.ValueOf(tm).Convert(t))
+		return nil
+	case t == fileType:
+		file, err := v.File()
+		if err != nil {
+			return err
+		}
+		fv.Set(reflect.ValueOf(file))
+		return nil
+	case t == bytesType:
+		b, err := v.AsBytes()
+		if err != nil {
+			return err
+		}
+		fv.SetBytes(b)
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		fv.SetString(v.String())
+	case reflect.Bool:
+		switch v.Value.(type) {
+		case bool, string, nil:
+			fv.SetBool(v.Bool())
+		default:
+			return fmt.Errorf("%s variable is not a boolean", v.typeName())
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := v.AsInt()
+		if err != nil {
+			return err
+		}
+		if fv.OverflowInt(i) {
+			return fmt.Errorf("value %d overflows %s", i, t)
+		}
+		fv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, err := v.AsInt()
+		if err != nil {
+			return err
+		}
+		if i < 0 || fv.OverflowUint(uint64(i)) {
+			return fmt.Errorf("value %d overflows %s", i, t)
+		}
+		fv.SetUint(uint64(i))
+	case reflect.Float32, reflect.Float64:
+		f, err := v.AsFloat()
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	default:
+		if v.IsNull() {
+			fv.Set(reflect.Zero(t))
+			return nil
+		}
+		return v.Decode(fv.Addr().Interface())
+	}
+	return nil
+}
+
+// fields lists the variable fields of a struct type, flattening untagged embedded structs.
+func fields(t reflect.Type) []fieldSpec {
+	var out []fieldSpec
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, hasTag := sf.Tag.Lookup("camunda")
+		if tag == "-" {
+			continue
+		}
+		if sf.Anonymous && !hasTag {
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				if !sf.IsExported() {
+					// reflect cannot allocate through unexported embedded pointers.
+					continue
+				}
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct && ft != timeType {
+				for _, inner := range fields(ft) {
+					inner.index = append([]int{i}, inner.index...)
+					out = append(out, inner)
+				}
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = lowerFirst(sf.Name)
+		}
+		spec := fieldSpec{name: name, index: []int{i}}
+		for _, opt := range strings.Split(opts, ",") {
+			switch opt {
+			case "omitempty":
+				spec.omitEmpty = true
+			case "json":
+				spec.forceJSON = true
+			}
+		}
+		out = append(out, spec)
+	}
+	return out
+}
+
+// fieldByIndex follows an index path, reporting false when it crosses a nil embedded pointer.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+func lowerFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToLower(r)) + s[size:]
+}
@@ -0,0 +1,170 @@
+package variables
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type Audit struct {
+	CreatedBy string
+}
+
+type Nested struct {
+	Zone string `json:"zone"`
+}
+
+type approvalVars struct {
+	Audit
+	Approved  bool
+	Approver  string `camunda:"approvedBy"`
+	Comment   string `camunda:",omitempty"`
+	Amount    float64
+	Count     int
+	Level     int16
+	Due       time.Time
+	Reminder  *time.Time
+	Tags      []string
+	Location  Nested
+	Raw       string `camunda:"raw,json"`
+	Signature []byte
+	Contract  File
+	Secret    string `camunda:"-"`
+	hidden    string
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	due := time.Date(2024, 5, 2, 12, 0, 0, 0, time.UTC)
+	in := approvalVars{
+		Audit:     Audit{CreatedBy: "alice"},
+		Approved:  true,
+		Approver:  "bob",
+		Amount:    12.5,
+		Count:     3,
+		Level:     2,
+		Due:       due,
+		Tags:      []string{"a", "b"},
+		Location:  Nested{Zone: "eu"},
+		Raw:       `{"k":"v"}`,
+		Signature: []byte("sig"),
+		Contract:  File{Name: "c.pdf", MimeType: "application/pdf", Data: []byte("%PDF")},
+		Secret:    "s",
+		hidden:    "h",
+	}
+	m, err := Marshal(&in)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	wantTypes := map[string]string{
+		"createdBy":  TypeString,
+		"approved":   TypeBoolean,
+		"approvedBy": TypeString,
+		"amount":     TypeDouble,
+		"count":      TypeInteger,
+		"level":      TypeShort,
+		"due":        TypeDate,
+		"reminder":   TypeNull,
+		"tags":       TypeJSON,
+		"location":   TypeJSON,
+		"raw":        TypeJSON,
+		"signature":  TypeBytes,
+		"contract":   TypeFile,
+	}
+	got := map[string]string{}
+	for name, v := range m {
+		got[name] = v.Type
+	}
+	if !reflect.DeepEqual(got, wantTypes) {
+		t.Fatalf("types = %v, want %v", got, wantTypes)
+	}
+
+	wired := Map{}
+	for name, v := range m {
+		wired[name] = wire(t, v)
+	}
+	var out approvalVars
+	if err := Unmarshal(wired, &out); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if !out.Due.Equal(in.Due) {
+		t.Errorf("due = %v, want %v", out.Due, in.Due)
+	}
+	out.Due = in.Due
+	in.Secret, in.hidden = "", ""
+	if !reflect.DeepEqual(out, in) {
+		t.Errorf("round trip = %+v, want %+v", out, in)
+	}
+}
+
+func TestMarshalOmitEmpty(t *testing.T) {
+	m, err := Marshal(approvalVars{Comment: ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m["comment"]; ok {
+		t.Error("empty comment was sent despite omitempty")
+	}
+	m, err = Marshal(approvalVars{Comment: "ok"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Get("comment").String() != "ok" {
+		t.Errorf("comment = %v", m.Get("comment"))
+	}
+}
+
+func TestMarshalNilEmbeddedPointer(t *testing.T) {
+	type withPointer struct {
+		*Audit
+		Name string
+	}
+	m, err := Marshal(withPointer{Name: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m["createdBy"]; ok || m.Get("name").String() != "x" {
+		t.Errorf("variables = %v, want only name", m)
+	}
+
+	var out withPointer
+	if err := Unmarshal(Map{"createdBy": {Type: TypeString, Value: "alice"}}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Audit == nil || out.CreatedBy != "alice" {
+		t.Errorf("embedded pointer not allocated: %+v", out)
+	}
+}
+
+func TestMarshalRejectsNonStruct(t *testing.T) {
+	if _, err := Marshal(42); err == nil {
+		t.Error("Marshal(42) succeeded")
+	}
+	var out approvalVars
+	if err := Unmarshal(Map{}, out); err == nil {
+		t.Error("Unmarshal into a non-pointer succeeded")
+	}
+}
+
+func TestUnmarshalMismatchedTypes(t *testing.T) {
+	tests := []struct {
+		name string
+		vars Map
+	}{
+		{"string into int", Map{"count": {Type: TypeString, Value: "many"}}},
+		{"number into bool", Map{"approved": {Type: TypeLong, Value: int64(1)}}},
+		{"boolean into float", Map{"amount": {Type: TypeBoolean, Value: true}}},
+		{"overflowing int16", Map{"level": {Type: TypeLong, Value: int64(1 << 20)}}},
+		{"string into date", Map{"due": {Type: TypeString, Value: "soon"}}},
+		{"number into bytes", Map{"signature": {Type: TypeLong, Value: int64(1)}}},
+		{"object into slice", Map{"tags": {Type: TypeJSON, Value: `{"a":1}`}}},
+		{"invalid forced json", Map{"raw": {Type: TypeJSON, Value: `{`}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out approvalVars
+			if err := Unmarshal(tt.vars, &out); err == nil {
+				t.Fatalf("unmarshal succeeded: %+v", out)
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+// Package variables converts between Go values and Camunda's typed process variables.
+//
+// Camunda represents a variable as {"type": ..., "value": ..., "valueInfo": {...}}. Sending values
+// without a type makes the engine guess, which turns dates into strings, rounds large integers and
+// rejects structs, so everything sent to the engine goes through Of.
+package variables
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+)
+
+// Camunda variable type names.
+const (
+	TypeNull    = "Null"
+	TypeString  = "String"
+	TypeBoolean = "Boolean"
+	TypeShort   = "Short"
+	TypeInteger = "Integer"
+	TypeLong    = "Long"
+	TypeDouble  = "Double"
+	TypeDate    = "Date"
+	TypeJSON    = "Json"
+	TypeObject  = "Object"
+	TypeBytes   = "Bytes"
+	TypeFile    = "File"
+)
+
+// DateFormat is the layout of Date values on the wire.
+const DateFormat = "2006-01-02T15:04:05.000-0700"
+
+// Value is a single typed process variable.
+type Value struct {
+	Type      string         `json:"type,omitempty"`
+	Value     any            `json:"value"`
+	ValueInfo map[string]any `json:"valueInfo,omitempty"`
+}
+
+// Map maps variable names to typed values, as in request and response bodies.
+type Map map[string]Value
+
+// Get returns the named variable; a missing variable reads as null.
+func (m Map) Get(name string) Value {
+	return m[name]
+}
+
+// JSON forces v to be sent as a Json variable, e.g. a string that holds a JSON document.
+type JSON struct {
+	V any
+}
+
+// Object sends v as a serialized Java object of the given class, for delegates that expect one.
+type Object struct {
+	TypeName string
+	V        any
+}
+
+// File is a file variable.
+type File struct {
+	Name     string
+	MimeType string
+	Encoding string
+	Data     []byte
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// UnmarshalJSON keeps numbers as json.Number so Long values survive beyond 2^53.
+func (v *Value) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Type      string         `json:"type"`
+		Value     any            `json:"value"`
+		ValueInfo map[string]any `json:"valueInfo"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&raw); err != nil {
+		return err
+	}
+	*v = Value{Type: raw.Type, Value: raw.Value, ValueInfo: raw.ValueInfo}
+	return nil
+}
+
+// Of converts a Go value to a typed variable:
+//   - nil and nil pointers become Null; pointers are dereferenced
+//   - strings, bools and floats map to String, Boolean and Double
+//   - int16 and int8 map to Short, int32, uint16 and uint8 to Integer, int64, uint32, uint and
+//     uint64 to Long, and int to Integer or Long depending on its magnitude
+//   - time.Time becomes Date, []byte Bytes and File a File variable
+//   - JSON, json.RawMessage, maps, slices and structs are serialized as Json
+//   - Object becomes a serialized Object with its type name in valueInfo
+//   - a Value is passed through unchanged
+func Of(v any) (Value, error) {
+	switch x := v.(type) {
+	case nil:
+		return Value{Type: TypeNull}, nil
+	case Value:
+		return x, nil
+	case *Value:
+		if x == nil {
+			return Value{Type: TypeNull}, nil
+		}
+		return *x, nil
+	case string:
+		return Value{Type: TypeString, Value: x}, nil
+	case bool:
+		return Value{Type: TypeBoolean, Value: x}, nil
+	case int8:
+		return Value{Type: TypeShort, Value: int64(x)}, nil
+	case int16:
+		return Value{Type: TypeShort, Value: int64(x)}, nil
+	case int32:
+		return Value{Type: TypeInteger, Value: int64(x)}, nil
+	case uint8:
+		return Value{Type: TypeInteger, Value: int64(x)}, nil
+	case uint16:
+		return Value{Type: TypeInteger, Value: int64(x)}, nil
+	case int:
+		if x >= math.MinInt32 && x <= math.MaxInt32 {
+			return Value{Type: TypeInteger, Value: int64(x)}, nil
+		}
+		return Value{Type: TypeLong, Value: int64(x)}, nil
+	case int64:
+		return Value{Type: TypeLong, Value: x}, nil
+	case uint32:
+		return Value{Type: TypeLong, Value: int64(x)}, nil
+	case uint:
+		return ofUnsigned(uint64(x))
+	case uint64:
+		return ofUnsigned(x)
+	case float32:
+		return Value{Type: TypeDouble, Value: float64(x)}, nil
+	case float64:
+		return Value{Type: TypeDouble, Value: x}, nil
+	case json.Number:
+		if i, err := x.Int64(); err == nil {
+			return Value{Type: TypeLong, Value: i}, nil
+		}
+		f, err := x.Float64()
+		if err != nil {
+			return Value{}, fmt.Errorf("invalid number %q: %w", x, err)
+		}
+		return Value{Type: TypeDouble, Value: f}, nil
+	case time.Time:
+		return Value{Type: TypeDate, Value: x.Format(DateFormat)}, nil
+	case []byte:
+		return Value{Type: TypeBytes, Value: base64.StdEncoding.EncodeToString(x)}, nil
+	case File:
+		info := map[string]any{"filename": x.Name}
+		if x.MimeType != "" {
+			info["mimeType"] = x.MimeType
+		}
+		if x.Encoding != "" {
+			info["encoding"] = x.Encoding
+		}
+		return Value{Type: TypeFile, Value: base64.StdEncoding.EncodeToString(x.Data), ValueInfo: info}, nil
+	case json.RawMessage:
+		return Value{Type: TypeJSON, Value: string(x)}, nil
+	case JSON:
+		return serialized(TypeJSON, x.V, nil)
+	case Object:
+		return serialized(TypeObject, x.V, map[string]any{
+			"objectTypeName":          x.TypeName,
+			"serializationDataFormat": "application/json",
+		})
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return Value{Type: TypeNull}, nil
+		}
+		return Of(rv.Elem().Interface())
+	case reflect.String:
+		return Of(rv.String())
+	case reflect.Bool:
+		return Of(rv.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		// Named numeric types keep the mapping of their underlying type.
+		return Of(rv.Convert(basicType(rv.Kind())).Interface())
+	case reflect.Struct:
+		if rv.Type().ConvertibleTo(timeType) {
+			return Of(rv.Convert(timeType).Interface())
+		}
+		return serialized(TypeJSON, v, nil)
+	case reflect.Map, reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return Value{Type: TypeNull}, nil
+		}
+		return serialized(TypeJSON, v, nil)
+	}
+	return Value{}, fmt.Errorf("unsupported process variable type %T", v)
+}
+
+// FromMap converts every entry with Of.
+func FromMap(vars map[string]any) (Map, error) {
+	out := make(Map, len(vars))
+	for name, v := range vars {
+		typed, err := Of(v)
+		if err != nil {
+			return nil, fmt.Errorf("variable %s: %w", name, err)
+		}
+		out[name] = typed
+	}
+	return out, nil
+}
+
+func ofUnsigned(x uint64) (Value, error) {
+	if x > math.MaxInt64 {
+		return Value{}, fmt.Errorf("unsigned value %d overflows Long", x)
+	}
+	return Value{Type: TypeLong, Value: int64(x)}, nil
+}
+
+func serialized(typ string, v any, info map[string]any) (Value, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return Value{}, fmt.Errorf("serialize %s variable: %w", typ, err)
+	}
+	return Value{Type: typ, Value: string(raw), ValueInfo: info}, nil
+}
+
+func basicType(kind reflect.Kind) reflect.Type {
+	switch kind {
+	case reflect.Int:
+		return reflect.TypeOf(int(0))
+	case reflect.Int8:
+		return reflect.TypeOf(int8(0))
+	case reflect.Int16:
+		return reflect.TypeOf(int16(0))
+	case reflect.Int32:
+		return reflect.TypeOf(int32(0))
+	case reflect.Int64:
+		return reflect.TypeOf(int64(0))
+	case reflect.Uint:
+		return reflect.TypeOf(uint(0))
+	case reflect.Uint8:
+		return reflect.TypeOf(uint8(0))
+	case reflect.Uint16:
+		return reflect.TypeOf(uint16(0))
+	case reflect.Uint32:
+		return reflect.TypeOf(uint32(0))
+	case reflect.Uint64:
+		return reflect.TypeOf(uint64(0))
+	case reflect.Float32:
+		return reflect.TypeOf(float32(0))
+	default:
+		return reflect.TypeOf(float64(0))
+	}
+}
@@ -0,0 +1,188 @@
+package variables
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// wire sends v through JSON the way it travels to the engine and back.
+func wire(t *testing.T, v Value) Value {
+	t.Helper()
+	raw, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var out Value
+	if err := json.Unmarshal(raw, &out); err != nil {
+		t.Fatalf("unmarshal %s: %v", raw, err)
+	}
+	return out
+}
+
+func TestRoundTrip(t *testing.T) {
+	date := time.Date(2024, 3, 1, 9, 30, 15, 123e6, time.FixedZone("CET", 3600))
+	type payload struct {
+		Name  string `json:"name"`
+		Count int    `json:"count"`
+	}
+	tests := []struct {
+		name string
+		in   any
+		typ  string
+		want any
+	}{
+		{"string", "hello", TypeString, "hello"},
+		{"empty string", "", TypeString, ""},
+		{"boolean", true, TypeBoolean, true},
+		{"short", int16(-7), TypeShort, int64(-7)},
+		{"integer", 42, TypeInteger, int64(42)},
+		{"int32", int32(math.MinInt32), TypeInteger, int64(math.MinInt32)},
+		{"int beyond Integer", math.MaxInt32 + 1, TypeLong, int64(math.MaxInt32 + 1)},
+		{"long beyond 2^53", int64(1<<53 + 1), TypeLong, int64(1<<53 + 1)},
+		{"uint64", uint64(9), TypeLong, int64(9)},
+		{"double", 2.5, TypeDouble, 2.5},
+		{"whole double", 3.0, TypeDouble, 3.0},
+		{"date", date, TypeDate, date},
+		{"json map", map[string]any{"a": "b"}, TypeJSON, map[string]any{"a": "b"}},
+		{"json struct", payload{Name: "x", Count: 2}, TypeJSON, map[string]any{"name": "x", "count": 2.0}},
+		{"forced json", JSON{V: "[1,2]"}, TypeJSON, "[1,2]"},
+		{"raw json", json.RawMessage(`[1,2]`), TypeJSON, []any{1.0, 2.0}},
+		{"object", Object{TypeName: "com.example.Ticket", V: payload{Name: "x"}}, TypeObject, map[string]any{"name": "x", "count": 0.0}},
+		{"bytes", []byte{0, 1, 0xff}, TypeBytes, []byte{0, 1, 0xff}},
+		{"file", File{Name: "a.txt", MimeType: "text/plain", Encoding: "UTF-8", Data: []byte("hi")}, TypeFile,
+			File{Name: "a.txt", MimeType: "text/plain", Encoding: "UTF-8", Data: []byte("hi")}},
+		{"nil", nil, TypeNull, nil},
+		{"nil pointer", (*int)(nil), TypeNull, nil},
+		{"pointer", ptr("p"), TypeString, "p"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := Of(tt.in)
+			if err != nil {
+				t.Fatalf("Of: %v", err)
+			}
+			if v.Type != tt.typ {
+				t.Fatalf("type = %s, want %s", v.Type, tt.typ)
+			}
+			got, err := wire(t, v).Interface()
+			if err != nil {
+				t.Fatalf("Interface: %v", err)
+			}
+			if want, ok := tt.want.(time.Time); ok {
+				if tm, _ := got.(time.Time); !tm.Equal(want) {
+					t.Fatalf("got %v, want %v", got, want)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundTripValueInfo(t *testing.T) {
+	obj, err := Of(Object{TypeName: "com.example.Ticket", V: map[string]int{"a": 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := wire(t, obj)
+	if got.ValueInfo["objectTypeName"] != "com.example.Ticket" || got.ValueInfo["serializationDataFormat"] != "application/json" {
+		t.Errorf("object valueInfo = %v", got.ValueInfo)
+	}
+
+	file, err := Of(File{Name: "b.bin", Data: []byte{1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = wire(t, file)
+	if got.ValueInfo["filename"] != "b.bin" {
+		t.Errorf("file valueInfo = %v", got.ValueInfo)
+	}
+	if _, ok := got.ValueInfo["mimeType"]; ok {
+		t.Errorf("file valueInfo = %v, want no empty mimeType", got.ValueInfo)
+	}
+}
+
+func TestOfRejects(t *testing.T) {
+	for name, in := range map[string]any{
+		"uint64 beyond Long": uint64(math.MaxInt64) + 1,
+		"channel":            make(chan int),
+		"func in json":       map[string]any{"f": func() {}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if v, err := Of(in); err == nil {
+				t.Fatalf("Of = %+v, want an error", v)
+			}
+		})
+	}
+}
+
+// Values as the engine sends them, including the loosely typed ones older clients produced.
+func TestAccessorsOnEngineValues(t *testing.T) {
+	var m Map
+	body := `{
+		"flag": {"type": "Boolean", "value": true},
+		"flagText": {"type": "String", "value": "true"},
+		"long": {"type": "Long", "value": 9007199254740993},
+		"double": {"type": "Double", "value": 1.5},
+		"date": {"type": "Date", "value": "2024-03-01T09:30:15.123+0100"},
+		"epoch": {"type": "Object", "value": 1709281815123},
+		"missing": {"type": "Null", "value": null}
+	}`
+	if err := json.Unmarshal([]byte(body), &m); err != nil {
+		t.Fatal(err)
+	}
+	if !m.Get("flag").Bool() || !m.Get("flagText").Bool() {
+		t.Error("booleans did not read as true")
+	}
+	if got := m.Get("long").Int(); got != 9007199254740993 {
+		t.Errorf("long = %d", got)
+	}
+	if got := m.Get("double").Int(); got != 1 {
+		t.Errorf("double as int = %d, want it truncated", got)
+	}
+	want := time.Date(2024, 3, 1, 8, 30, 15, 123e6, time.UTC)
+	if got := m.Get("date").Time(); !got.Equal(want) {
+		t.Errorf("date = %v, want %v", got, want)
+	}
+	if got := m.Get("epoch").Time(); !got.Equal(want) {
+		t.Errorf("epoch date = %v, want %v", got, want)
+	}
+	if !m.Get("missing").IsNull() || !m.Get("absent").IsNull() {
+		t.Error("null and absent variables did not read as null")
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		v    Value
+		do   func(Value) error
+	}{
+		{"string as int", Value{Type: TypeString, Value: "abc"}, func(v Value) error { _, err := v.AsInt(); return err }},
+		{"boolean as int", Value{Type: TypeBoolean, Value: true}, func(v Value) error { _, err := v.AsInt(); return err }},
+		{"boolean as float", Value{Type: TypeBoolean, Value: false}, func(v Value) error { _, err := v.AsFloat(); return err }},
+		{"number as date", Value{Type: TypeDouble, Value: 1.5}, func(v Value) error { _, err := v.AsTime(); return err }},
+		{"bad date", Value{Type: TypeDate, Value: "yesterday"}, func(v Value) error { _, err := v.AsTime(); return err }},
+		{"number as bytes", Value{Type: TypeLong, Value: int64(1)}, func(v Value) error { _, err := v.AsBytes(); return err }},
+		{"bad base64", Value{Type: TypeBytes, Value: "%%%"}, func(v Value) error { _, err := v.AsBytes(); return err }},
+		{"typed Long not a number", Value{Type: TypeLong, Value: "x"}, func(v Value) error { _, err := v.Interface(); return err }},
+		{"json into wrong shape", Value{Type: TypeJSON, Value: `{"a":1}`}, func(v Value) error { var out []int; return v.Decode(&out) }},
+		{"invalid json", Value{Type: TypeJSON, Value: `{`}, func(v Value) error { _, err := v.Interface(); return err }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.do(tt.v); err == nil {
+				t.Fatal("got no error")
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -14,7 +14,9 @@ const statusLabel: Record<string, string> = {
   approved: '已通过',
   rejected: '已驳回',
   processing: '处理中',
-  completed: '已完成'
+  completed: '已完成',
+  failed: '处理失败',
+  cancelled: '已撤销'
 };
 
 export function TicketCard({ ticket, onSubmit, onApprove, isSubmitting, isDeciding }: Props) {